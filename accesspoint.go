@@ -0,0 +1,81 @@
+package s3
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+)
+
+// accessPoint is a parsed S3 access point or Multi-Region Access Point ARN
+// configured as the bucket, e.g.
+// arn:aws:s3:eu-west-1:123456789012:accesspoint/uploads or
+// arn:aws:s3::123456789012:accesspoint/mfzwi23gnjvgw.mrap. The SDK resolves
+// their endpoints and signs Multi-Region Access Point requests with SigV4a.
+type accessPoint struct {
+	region  string
+	account string
+	name    string
+}
+
+// parseAccessPoint parses an access point ARN configured as the bucket; it
+// returns nil for plain bucket names.
+func parseAccessPoint(bucket string) (*accessPoint, error) {
+	if !arn.IsARN(bucket) {
+		return nil, nil
+	}
+	a, err := arn.Parse(bucket)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bucket ARN %q: %w", bucket, err)
+	}
+	kind, name, _ := strings.Cut(a.Resource, "/")
+	if a.Service != "s3" || kind != "accesspoint" || name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("bucket ARN %q is not an S3 access point", bucket)
+	}
+	return &accessPoint{region: a.Region, account: a.AccountID, name: name}, nil
+}
+
+// multiRegion reports whether ap is a Multi-Region Access Point, whose ARN
+// has no region.
+func (ap *accessPoint) multiRegion() bool { return ap.region == "" }
+
+// hostPrefix is the label requests to ap put in front of the endpoint host.
+func (ap *accessPoint) hostPrefix() string {
+	if ap.multiRegion() {
+		return ap.name + "."
+	}
+	return ap.name + "-" + ap.account + "."
+}
+
+// validateAccessPoint rejects settings access points do not support.
+func validateAccessPoint(cfg *Config, ap *accessPoint) error {
+	switch {
+	case cfg.AddressingStyle == AddressingPath:
+		return errors.New("access points require virtual-hosted-style addressing")
+	case cfg.Accelerate:
+		return errors.New("access points do not support transfer acceleration")
+	case ap.multiRegion() && cfg.Endpoint != "":
+		return errors.New("multi-region access points cannot be used with a custom endpoint")
+	}
+	return nil
+}
+
+// isAccessPointHost reports whether host addresses the configured access
+// point, returning false for plain buckets.
+func (c *Client) isAccessPointHost(host string) bool {
+	if c.accessPoint == nil {
+		return false
+	}
+	rest, ok := strings.CutPrefix(host, strings.ToLower(c.accessPoint.hostPrefix()))
+	if !ok {
+		return false
+	}
+	if c.endpoint != "" {
+		return c.isServiceHost(rest)
+	}
+	if c.accessPoint.multiRegion() {
+		return rest == "accesspoint.s3-global.amazonaws.com"
+	}
+	return strings.HasPrefix(rest, "s3-accesspoint.") && strings.HasSuffix(rest, ".amazonaws.com")
+}
@@ -0,0 +1,143 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	allUsersGroup           = "http://acs.amazonaws.com/groups/global/AllUsers"
+	authenticatedUsersGroup = "http://acs.amazonaws.com/groups/global/AuthenticatedUsers"
+)
+
+// ACL is the access control list of an object or bucket. Buckets with
+// object ownership set to "bucket owner enforced", the default for new AWS
+// buckets, have ACLs disabled and reject changes with
+// AccessControlListNotSupported.
+type ACL struct {
+	// OwnerID is the canonical user ID of the owner.
+	OwnerID string
+	Grants  []Grant
+}
+
+// Grant gives Permission (FULL_CONTROL, READ, WRITE, READ_ACP, WRITE_ACP) to
+// a grantee, identified by canonical user ID, group URI or e-mail address
+// depending on Type.
+type Grant struct {
+	Type       types.Type
+	ID         string
+	URI        string
+	Email      string
+	Permission types.Permission
+}
+
+// Canned returns the canned ACL the grants correspond to: private,
+// public-read, public-read-write, authenticated-read or
+// bucket-owner-full-control for objects written by another account. It
+// returns "" for ACLs that match none of them.
+func (a *ACL) Canned() string {
+	var owner bool
+	var others int
+	public := map[types.Permission]bool{}
+	authenticated := map[types.Permission]bool{}
+	for _, g := range a.Grants {
+		switch {
+		case g.Type == types.TypeCanonicalUser && g.Permission == types.PermissionFullControl && g.ID == a.OwnerID:
+			owner = true
+		case g.Type == types.TypeCanonicalUser && g.Permission == types.PermissionFullControl:
+			others++
+		case g.Type == types.TypeGroup && g.URI == allUsersGroup:
+			public[g.Permission] = true
+		case g.Type == types.TypeGroup && g.URI == authenticatedUsersGroup:
+			authenticated[g.Permission] = true
+		default:
+			return ""
+		}
+	}
+	switch {
+	case !owner:
+		return ""
+	case others == 1 && len(public) == 0 && len(authenticated) == 0:
+		return string(types.ObjectCannedACLBucketOwnerFullControl)
+	case others > 0:
+		return ""
+	case len(public) == 0 && len(authenticated) == 0:
+		return string(types.ObjectCannedACLPrivate)
+	case len(authenticated) == 0 && len(public) == 1 && public[types.PermissionRead]:
+		return string(types.ObjectCannedACLPublicRead)
+	case len(authenticated) == 0 && len(public) == 2 && public[types.PermissionRead] && public[types.PermissionWrite]:
+		return string(types.ObjectCannedACLPublicReadWrite)
+	case len(public) == 0 && len(authenticated) == 1 && authenticated[types.PermissionRead]:
+		return string(types.ObjectCannedACLAuthenticatedRead)
+	}
+	return ""
+}
+
+func aclFromSDK(owner *types.Owner, grants []types.Grant) *ACL {
+	acl := &ACL{}
+	if owner != nil {
+		acl.OwnerID = aws.ToString(owner.ID)
+	}
+	for _, g := range grants {
+		grant := Grant{Permission: g.Permission}
+		if g.Grantee != nil {
+			grant.Type = g.Grantee.Type
+			grant.ID = aws.ToString(g.Grantee.ID)
+			grant.URI = aws.ToString(g.Grantee.URI)
+			grant.Email = aws.ToString(g.Grantee.EmailAddress)
+		}
+		acl.Grants = append(acl.Grants, grant)
+	}
+	return acl
+}
+
+// SetObjectACL replaces the ACL of key with a canned one. Uploads set it
+// with WithACL.
+func (c *Client) SetObjectACL(ctx context.Context, key string, acl types.ObjectCannedACL) error {
+	_, err := c.client.PutObjectAcl(ctx, &s3.PutObjectAclInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+		ACL:    acl,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set object ACL: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) GetObjectACL(ctx context.Context, key string) (*ACL, error) {
+	output, err := c.client.GetObjectAcl(ctx, &s3.GetObjectAclInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object ACL: %w", err)
+	}
+	return aclFromSDK(output.Owner, output.Grants), nil
+}
+
+// SetBucketACL replaces the bucket ACL with a canned one.
+func (c *Client) SetBucketACL(ctx context.Context, acl types.BucketCannedACL) error {
+	_, err := c.client.PutBucketAcl(ctx, &s3.PutBucketAclInput{
+		Bucket: aws.String(c.bucket),
+		ACL:    acl,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set bucket ACL: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) GetBucketACL(ctx context.Context) (*ACL, error) {
+	output, err := c.client.GetBucketAcl(ctx, &s3.GetBucketAclInput{
+		Bucket: aws.String(c.bucket),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bucket ACL: %w", err)
+	}
+	return aclFromSDK(output.Owner, output.Grants), nil
+}
@@ -0,0 +1,115 @@
+package s3
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// AddressingStyle selects how the bucket appears in request URLs.
+type AddressingStyle string
+
+const (
+	// AddressingAuto uses path-style for custom endpoints (MinIO, Ceph,
+	// most S3-compatible providers) and virtual-hosted-style for AWS.
+	AddressingAuto AddressingStyle = ""
+	// AddressingPath puts the bucket in the path: endpoint/bucket/key.
+	AddressingPath AddressingStyle = "path"
+	// AddressingVirtualHosted puts the bucket in the host:
+	// bucket.endpoint/key. Transfer acceleration requires it. AWS still
+	// falls back to path-style for bucket names that are not valid host
+	// names, e.g. with dots over HTTPS.
+	AddressingVirtualHosted AddressingStyle = "virtual-hosted"
+)
+
+// resolveAddressing checks the endpoint settings of cfg and reports whether
+// requests use path-style addressing.
+func resolveAddressing(cfg *Config) (bool, error) {
+	pathStyle, err := usePathStyle(cfg.AddressingStyle, cfg.Endpoint)
+	if err != nil {
+		return false, err
+	}
+	if cfg.DualStack && cfg.Endpoint != "" {
+		return false, errors.New("dual-stack endpoints cannot be used with a custom endpoint")
+	}
+	ap, err := parseAccessPoint(cfg.BucketName)
+	if err != nil {
+		return false, err
+	}
+	if ap != nil {
+		// The access point is always a host label, custom endpoint or not.
+		return false, validateAccessPoint(cfg, ap)
+	}
+	if cfg.Accelerate {
+		switch {
+		case cfg.Endpoint != "":
+			return false, errors.New("transfer acceleration cannot be used with a custom endpoint")
+		case pathStyle:
+			return false, errors.New("transfer acceleration requires virtual-hosted-style addressing")
+		case strings.Contains(cfg.BucketName, "."):
+			return false, fmt.Errorf("transfer acceleration does not support bucket names with dots: %q", cfg.BucketName)
+		}
+	}
+	return pathStyle, nil
+}
+
+// usePathStyle resolves style for the client's endpoint.
+func usePathStyle(style AddressingStyle, endpoint string) (bool, error) {
+	switch style {
+	case AddressingPath:
+		return true, nil
+	case AddressingVirtualHosted:
+		return false, nil
+	case AddressingAuto:
+		if endpoint == "" {
+			return false, nil
+		}
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Host == "" {
+			// Left to the SDK to reject.
+			return true, nil
+		}
+		return !strings.HasSuffix(strings.ToLower(u.Hostname()), ".amazonaws.com"), nil
+	}
+	return false, fmt.Errorf("unknown addressing style %q", style)
+}
+
+// bucketURL returns the URL of the bucket itself, as browser form uploads
+// target it.
+func (c *Client) bucketURL() string {
+	endpoint := strings.TrimRight(c.endpoint, "/")
+	switch {
+	case endpoint != "":
+	case c.accelerate && c.dualStack:
+		endpoint = "https://s3-accelerate.dualstack.amazonaws.com"
+	case c.accelerate:
+		endpoint = "https://s3-accelerate.amazonaws.com"
+	case c.dualStack:
+		endpoint = "https://s3.dualstack." + c.region + ".amazonaws.com"
+	default:
+		endpoint = "https://s3." + c.region + ".amazonaws.com"
+	}
+	u, err := url.Parse(endpoint)
+	if c.pathStyle || err != nil || u.Host == "" || strings.Contains(c.bucket, ".") {
+		return endpoint + "/" + c.bucket
+	}
+	u.Host = c.bucket + "." + u.Host
+	return u.String()
+}
+
+// ObjectURL returns the unsigned URL of key. It only opens objects that are
+// publicly readable; use GetPresignedURL for private ones.
+func (c *Client) ObjectURL(key string) string {
+	return c.bucketURL() + "/" + escapeKey(key)
+}
+
+// escapeKey escapes the segments of key for a URL path, keeping the slashes
+// between them.
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,78 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ObjectAttributes is what GetObjectAttributes returns about an object.
+type ObjectAttributes struct {
+	Key          string
+	Size         int64
+	ETag         string
+	StorageClass string
+	LastModified time.Time
+	VersionID    string
+	// PartsCount is the number of parts of a multipart-uploaded object and
+	// zero for objects uploaded in a single request.
+	PartsCount int
+	// Checksum is the checksum stored with the object, empty if it was
+	// uploaded without one. For multipart objects it is the checksum of the
+	// part checksums, suffixed with "-<parts>".
+	ChecksumAlgorithm types.ChecksumAlgorithm
+	Checksum          string
+}
+
+// GetAttributes returns size, checksum, storage class and parts count of key
+// in a single GetObjectAttributes call. Unlike HeadObject it reports how a
+// multipart object was split and its full-object checksum.
+func (c *Client) GetAttributes(ctx context.Context, key string) (*ObjectAttributes, error) {
+	output, err := c.client.GetObjectAttributes(ctx, &s3.GetObjectAttributesInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+		ObjectAttributes: []types.ObjectAttributes{
+			types.ObjectAttributesEtag,
+			types.ObjectAttributesChecksum,
+			types.ObjectAttributesObjectParts,
+			types.ObjectAttributesStorageClass,
+			types.ObjectAttributesObjectSize,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object attributes: %w", err)
+	}
+
+	attrs := &ObjectAttributes{
+		Key:          key,
+		Size:         aws.ToInt64(output.ObjectSize),
+		ETag:         aws.ToString(output.ETag),
+		StorageClass: string(output.StorageClass),
+		LastModified: aws.ToTime(output.LastModified),
+		VersionID:    aws.ToString(output.VersionId),
+	}
+	if output.ObjectParts != nil {
+		attrs.PartsCount = int(aws.ToInt32(output.ObjectParts.TotalPartsCount))
+	}
+	if checksum := output.Checksum; checksum != nil {
+		for _, candidate := range []struct {
+			algorithm types.ChecksumAlgorithm
+			value     *string
+		}{
+			{types.ChecksumAlgorithmSha256, checksum.ChecksumSHA256},
+			{types.ChecksumAlgorithmCrc32c, checksum.ChecksumCRC32C},
+			{types.ChecksumAlgorithmSha1, checksum.ChecksumSHA1},
+			{types.ChecksumAlgorithmCrc32, checksum.ChecksumCRC32},
+		} {
+			if value := aws.ToString(candidate.value); value != "" {
+				attrs.ChecksumAlgorithm, attrs.Checksum = candidate.algorithm, value
+				break
+			}
+		}
+	}
+	return attrs, nil
+}
@@ -0,0 +1,391 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	defaultAuditBatchSize     = 1000
+	defaultAuditFlushInterval = time.Minute
+	auditBufferedBatches      = 10
+)
+
+// AuditRecord describes one mutating operation: an upload (PutObject or a
+// completed multipart upload), a copy or a deleted key.
+type AuditRecord struct {
+	Time      time.Time `json:"time"`
+	Actor     string    `json:"actor,omitempty"`
+	Operation string    `json:"operation"`
+	Bucket    string    `json:"bucket"`
+	Key       string    `json:"key"`
+	// Source is the "bucket/key" copied from.
+	Source string `json:"source,omitempty"`
+	// Size is the uploaded size, when known up front.
+	Size      int64         `json:"size,omitempty"`
+	VersionID string        `json:"version_id,omitempty"`
+	Duration  time.Duration `json:"duration"`
+	Success   bool          `json:"success"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// AuditSink stores audit records. WriteAudit is called synchronously after
+// each operation; its errors are logged and do not fail the operation.
+type AuditSink interface {
+	WriteAudit(ctx context.Context, record AuditRecord) error
+}
+
+// AuditSinkFunc adapts a function, e.g. one producing to Kafka, to
+// AuditSink.
+type AuditSinkFunc func(ctx context.Context, record AuditRecord) error
+
+func (f AuditSinkFunc) WriteAudit(ctx context.Context, record AuditRecord) error {
+	return f(ctx, record)
+}
+
+type actorKey struct{}
+
+// WithActor returns a context whose operations are attributed to actor, e.g.
+// a user ID, in audit records.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+type noAuditKey struct{}
+
+// auditMiddleware reports the mutating operations passing through it to
+// sink. Installed first, it records the bucket and key as finally sent.
+func auditMiddleware(sink AuditSink, logger *slog.Logger) Middleware {
+	return func(next OperationHandler) OperationHandler {
+		return func(ctx context.Context, op *Operation) (any, error) {
+			if !auditedOperation(op.Name) || ctx.Value(noAuditKey{}) != nil {
+				return next(ctx, op)
+			}
+			start := time.Now()
+			out, err := next(ctx, op)
+			duration := time.Since(start)
+			for _, record := range auditRecords(op, out, err) {
+				record.Time = start.UTC()
+				record.Actor = ActorFromContext(ctx)
+				record.Duration = duration
+				if err := sink.WriteAudit(ctx, record); err != nil {
+					logger.ErrorContext(ctx, "failed to write audit record",
+						slog.String("op", record.Operation),
+						slog.String("key", record.Key),
+						slog.Any("error", err),
+					)
+				}
+			}
+			return out, err
+		}
+	}
+}
+
+func auditedOperation(name string) bool {
+	switch name {
+	case "PutObject", "CompleteMultipartUpload", "CopyObject", "DeleteObject", "DeleteObjects":
+		return true
+	}
+	return false
+}
+
+func auditRecords(op *Operation, out any, err error) []AuditRecord {
+	record := AuditRecord{Operation: op.Name, Bucket: op.Bucket, Key: op.Key, Success: err == nil}
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	switch input := op.Input.(type) {
+	case *s3.PutObjectInput:
+		record.Size = aws.ToInt64(input.ContentLength)
+		if output, ok := out.(*s3.PutObjectOutput); ok {
+			record.VersionID = aws.ToString(output.VersionId)
+		}
+	case *s3.CompleteMultipartUploadInput:
+		if output, ok := out.(*s3.CompleteMultipartUploadOutput); ok {
+			record.VersionID = aws.ToString(output.VersionId)
+		}
+	case *s3.CopyObjectInput:
+		source := aws.ToString(input.CopySource)
+		if unescaped, err := url.PathUnescape(source); err == nil {
+			source = unescaped
+		}
+		record.Source = source
+		if output, ok := out.(*s3.CopyObjectOutput); ok {
+			record.VersionID = aws.ToString(output.VersionId)
+		}
+	case *s3.DeleteObjectInput:
+		if output, ok := out.(*s3.DeleteObjectOutput); ok {
+			record.VersionID = aws.ToString(output.VersionId)
+		}
+	case *s3.DeleteObjectsInput:
+		// One record per key, with the per-key errors of a partial failure.
+		failed := make(map[string]string)
+		if output, ok := out.(*s3.DeleteObjectsOutput); ok {
+			for _, e := range output.Errors {
+				failed[aws.ToString(e.Key)] = fmt.Sprintf("%s: %s", aws.ToString(e.Code), aws.ToString(e.Message))
+			}
+		}
+		var records []AuditRecord
+		if input.Delete != nil {
+			for _, obj := range input.Delete.Objects {
+				r := record
+				r.Key = aws.ToString(obj.Key)
+				r.VersionID = aws.ToString(obj.VersionId)
+				if msg, ok := failed[r.Key]; ok {
+					r.Success, r.Error = false, msg
+				}
+				records = append(records, r)
+			}
+		}
+		return records
+	}
+	return []AuditRecord{record}
+}
+
+type logAuditSink struct {
+	logger *slog.Logger
+}
+
+// NewLogAuditSink returns a sink writing each record as an "audit" message
+// at info level.
+func NewLogAuditSink(logger *slog.Logger) AuditSink {
+	return &logAuditSink{logger: logger}
+}
+
+func (s *logAuditSink) WriteAudit(ctx context.Context, r AuditRecord) error {
+	attrs := []slog.Attr{
+		slog.String("operation", r.Operation),
+		slog.String("bucket", r.Bucket),
+		slog.String("key", r.Key),
+		slog.Bool("success", r.Success),
+		slog.Duration("duration", r.Duration),
+	}
+	if r.Actor != "" {
+		attrs = append(attrs, slog.String("actor", r.Actor))
+	}
+	if r.Source != "" {
+		attrs = append(attrs, slog.String("source", r.Source))
+	}
+	if r.Size > 0 {
+		attrs = append(attrs, slog.Int64("size", r.Size))
+	}
+	if r.VersionID != "" {
+		attrs = append(attrs, slog.String("version_id", r.VersionID))
+	}
+	if r.Error != "" {
+		attrs = append(attrs, slog.String("error", r.Error))
+	}
+	s.logger.LogAttrs(ctx, slog.LevelInfo, "audit", attrs...)
+	return nil
+}
+
+type S3AuditSinkOption func(*S3AuditSink)
+
+// WithAuditBatchSize sets how many records are buffered before they are
+// written, 1000 by default.
+func WithAuditBatchSize(n int) S3AuditSinkOption {
+	return func(s *S3AuditSink) {
+		if n > 0 {
+			s.batchSize = n
+		}
+	}
+}
+
+// WithAuditFlushInterval sets how often buffered records are written
+// regardless of their number, every minute by default.
+func WithAuditFlushInterval(d time.Duration) S3AuditSinkOption {
+	return func(s *S3AuditSink) {
+		if d > 0 {
+			s.flushInterval = d
+		}
+	}
+}
+
+// WithAuditMaxBuffered caps the records kept while writes to S3 fail, ten
+// batches by default. Records past the cap are dropped and the drop is
+// logged.
+func WithAuditMaxBuffered(n int) S3AuditSinkOption {
+	return func(s *S3AuditSink) {
+		if n > 0 {
+			s.maxBuffered = n
+		}
+	}
+}
+
+// S3AuditSink writes audit records as JSON Lines objects under a prefix,
+// named prefix/YYYY/MM/DD/HHMMSS.nnnnnnnnn-<random>.jsonl. The client may be
+// the audited one: the sink's own uploads are not audited. Records are
+// written in the background, so audited operations never wait for S3.
+type S3AuditSink struct {
+	client        *Client
+	prefix        string
+	batchSize     int
+	flushInterval time.Duration
+	maxBuffered   int
+
+	mu      sync.Mutex
+	lines   [][]byte
+	dropped int
+
+	// flushMu serializes flushes so records are written in order.
+	flushMu  sync.Mutex
+	flushNow chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewS3AuditSink starts a sink flushing in the background; Close it to
+// write the last records.
+func NewS3AuditSink(client *Client, prefix string, opts ...S3AuditSinkOption) *S3AuditSink {
+	s := &S3AuditSink{
+		client:        client,
+		prefix:        prefix,
+		batchSize:     defaultAuditBatchSize,
+		flushInterval: defaultAuditFlushInterval,
+		flushNow:      make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.maxBuffered == 0 {
+		s.maxBuffered = s.batchSize * auditBufferedBatches
+	}
+	if s.prefix != "" && !strings.HasSuffix(s.prefix, "/") {
+		s.prefix += "/"
+	}
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-s.flushNow:
+			case <-s.stop:
+				return
+			}
+			if err := s.Flush(context.Background()); err != nil {
+				s.client.logger.Error("failed to flush audit records", slog.Any("error", err))
+			}
+		}
+	}()
+	return s
+}
+
+// WriteAudit buffers the record and wakes the background flush once a batch
+// is full.
+func (s *S3AuditSink) WriteAudit(ctx context.Context, record AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	line = append(line, '\n')
+	s.mu.Lock()
+	if len(s.lines) >= s.maxBuffered {
+		s.drop(1)
+		s.mu.Unlock()
+		return nil
+	}
+	s.lines = append(s.lines, line)
+	// Only reaching the batch size wakes the flush, so a failing S3 is
+	// retried at the flush interval rather than on every record.
+	full := len(s.lines) == s.batchSize
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.flushNow <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// drop counts n dropped records, logging when records start being dropped.
+// s.mu must be held.
+func (s *S3AuditSink) drop(n int) {
+	if s.dropped == 0 {
+		s.client.logger.Warn("audit buffer full, dropping records", slog.Int("max_buffered", s.maxBuffered))
+	}
+	s.dropped += n
+}
+
+// Flush writes the buffered records. The buffer is swapped out first, so
+// records keep being accepted during the upload. On failure the records are
+// put back in front of the newer ones and retried with the next flush.
+func (s *S3AuditSink) Flush(ctx context.Context) error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	batch := s.lines
+	s.lines = nil
+	dropped := s.dropped
+	s.dropped = 0
+	s.mu.Unlock()
+	if dropped > 0 {
+		s.client.logger.Warn("dropped audit records", slog.Int("count", dropped))
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+
+	err := s.write(ctx, batch)
+	if err != nil {
+		s.mu.Lock()
+		lines := append(batch, s.lines...)
+		if excess := len(lines) - s.maxBuffered; excess > 0 {
+			lines = lines[:s.maxBuffered]
+			s.drop(excess)
+		}
+		s.lines = lines
+		s.mu.Unlock()
+	}
+	return err
+}
+
+func (s *S3AuditSink) write(ctx context.Context, batch [][]byte) error {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("failed to name audit object: %w", err)
+	}
+	key := s.prefix + time.Now().UTC().Format("2006/01/02/150405.000000000") + "-" + hex.EncodeToString(suffix) + ".jsonl"
+	ctx = context.WithValue(ctx, noAuditKey{}, true)
+	err := s.client.UploadLarge(ctx, key, bytes.NewReader(bytes.Join(batch, nil)), WithContentType("application/x-ndjson"))
+	if err != nil {
+		return fmt.Errorf("failed to write audit records: %w", err)
+	}
+	return nil
+}
+
+// Close stops the background flushing and writes the remaining records.
+func (s *S3AuditSink) Close(ctx context.Context) error {
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	<-s.done
+	return s.Flush(ctx)
+}
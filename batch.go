@@ -0,0 +1,216 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatchAttempts   = 3
+	defaultBatchBaseDelay  = 200 * time.Millisecond
+	defaultBatchMaxBackoff = 10 * time.Second
+)
+
+type BatchKind string
+
+const (
+	BatchUpload BatchKind = "upload"
+	BatchCopy   BatchKind = "copy"
+	BatchDelete BatchKind = "delete"
+	BatchTag    BatchKind = "tag"
+)
+
+// BatchOp is one operation of a Batch, built with UploadOp, CopyOp,
+// DeleteOp or TagOp.
+type BatchOp struct {
+	Kind BatchKind
+	// Key is the object the operation writes; Source is the copied key.
+	Key    string
+	Source string
+
+	run func(ctx context.Context, c *Client) error
+}
+
+// UploadOp uploads body to key with UploadLarge. body is rewound to its
+// start before every attempt.
+func UploadOp(key string, body io.ReadSeeker, opts ...UploadOption) BatchOp {
+	return BatchOp{Kind: BatchUpload, Key: key, run: func(ctx context.Context, c *Client) error {
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to rewind upload body: %w", err)
+		}
+		return c.UploadLarge(ctx, key, body, opts...)
+	}}
+}
+
+func CopyOp(srcKey, dstKey string, opts ...CopyOption) BatchOp {
+	return BatchOp{Kind: BatchCopy, Key: dstKey, Source: srcKey, run: func(ctx context.Context, c *Client) error {
+		return c.CopyFile(ctx, srcKey, dstKey, opts...)
+	}}
+}
+
+func DeleteOp(key string, opts ...DeleteOption) BatchOp {
+	return BatchOp{Kind: BatchDelete, Key: key, run: func(ctx context.Context, c *Client) error {
+		return c.DeleteFile(ctx, key, opts...)
+	}}
+}
+
+func TagOp(key string, tags map[string]string) BatchOp {
+	return BatchOp{Kind: BatchTag, Key: key, run: func(ctx context.Context, c *Client) error {
+		return c.SetTags(ctx, key, tags)
+	}}
+}
+
+type BatchOption func(*batchOptions)
+
+type batchOptions struct {
+	concurrency int
+	attempts    int
+	backoff     jitterBackoff
+}
+
+func WithBatchConcurrency(n int) BatchOption {
+	return func(o *batchOptions) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// WithBatchRetries sets how many times an operation is attempted, 3 by
+// default, and the base delay of the jittered exponential backoff between
+// attempts. Only transient failures (throttling, 5xx, connection errors,
+// open circuit) are retried; these retries come on top of the SDK's own.
+func WithBatchRetries(attempts int, baseDelay time.Duration) BatchOption {
+	return func(o *batchOptions) {
+		if attempts > 0 {
+			o.attempts = attempts
+		}
+		if baseDelay > 0 {
+			o.backoff.base = baseDelay
+		}
+	}
+}
+
+type BatchFailure struct {
+	// Index is the position of Op in the submitted slice.
+	Index    int
+	Op       BatchOp
+	Attempts int
+	Err      error
+}
+
+func (f BatchFailure) Error() string {
+	return fmt.Sprintf("batch %s of %s failed after %d attempts: %v", f.Op.Kind, f.Op.Key, f.Attempts, f.Err)
+}
+
+func (f BatchFailure) Unwrap() error { return f.Err }
+
+// BatchReport lists the outcome of every operation by its index in the
+// submitted slice.
+type BatchReport struct {
+	Succeeded []int
+	// Failed is sorted by Index.
+	Failed []BatchFailure
+	// NotRun lists the operations that were not started because the context
+	// was canceled.
+	NotRun []int
+
+	ops []BatchOp
+}
+
+// Remaining returns the failed and not started operations in their original
+// order, to resubmit them with another Batch call.
+func (r *BatchReport) Remaining() []BatchOp {
+	indexes := make([]int, 0, len(r.Failed)+len(r.NotRun))
+	for _, f := range r.Failed {
+		indexes = append(indexes, f.Index)
+	}
+	indexes = append(indexes, r.NotRun...)
+	sort.Ints(indexes)
+	ops := make([]BatchOp, 0, len(indexes))
+	for _, i := range indexes {
+		ops = append(ops, r.ops[i])
+	}
+	return ops
+}
+
+// Batch runs ops with a bounded worker pool and retries transient failures
+// per operation. A failed operation does not stop the others; the returned
+// error is only set when ctx ends before all operations ran.
+func (c *Client) Batch(ctx context.Context, ops []BatchOp, opts ...BatchOption) (*BatchReport, error) {
+	o := &batchOptions{
+		concurrency: defaultConcurrency,
+		attempts:    defaultBatchAttempts,
+		backoff:     jitterBackoff{base: defaultBatchBaseDelay, max: defaultBatchMaxBackoff},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	report := &BatchReport{ops: ops}
+	started := make([]bool, len(ops))
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	queue := make(chan int)
+	for i := 0; i < o.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range queue {
+				attempts, err := c.runBatchOp(ctx, ops[index], o)
+
+				mu.Lock()
+				if err != nil {
+					report.Failed = append(report.Failed, BatchFailure{Index: index, Op: ops[index], Attempts: attempts, Err: err})
+				} else {
+					report.Succeeded = append(report.Succeeded, index)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for index := range ops {
+		select {
+		case queue <- index:
+			started[index] = true
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	for index, ok := range started {
+		if !ok {
+			report.NotRun = append(report.NotRun, index)
+		}
+	}
+	sort.Ints(report.Succeeded)
+	sort.Slice(report.Failed, func(i, j int) bool { return report.Failed[i].Index < report.Failed[j].Index })
+	return report, ctx.Err()
+}
+
+func (c *Client) runBatchOp(ctx context.Context, op BatchOp, o *batchOptions) (int, error) {
+	for attempt := 1; ; attempt++ {
+		err := op.run(ctx, c)
+		if err == nil || attempt >= o.attempts || ctx.Err() != nil || !isEndpointFailure(err) {
+			return attempt, err
+		}
+		delay, _ := o.backoff.BackoffDelay(attempt, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt, err
+		}
+	}
+}
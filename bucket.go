@@ -0,0 +1,263 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+type BucketOption func(*bucketOptions)
+
+type bucketOptions struct {
+	versioning bool
+	sse        types.ServerSideEncryption
+	kmsKeyID   string
+}
+
+// WithVersioning enables versioning on the bucket.
+func WithVersioning() BucketOption {
+	return func(o *bucketOptions) { o.versioning = true }
+}
+
+// WithDefaultEncryption sets the bucket default encryption, applied by S3 to
+// objects uploaded without their own. kmsKeyID is only used with aws:kms.
+func WithDefaultEncryption(sse types.ServerSideEncryption, kmsKeyID string) BucketOption {
+	return func(o *bucketOptions) {
+		o.sse = sse
+		o.kmsKeyID = kmsKeyID
+	}
+}
+
+// EnsureBucket creates the configured bucket if it does not exist yet and
+// then applies the requested settings, so it is safe to call on every start.
+func (c *Client) EnsureBucket(ctx context.Context, opts ...BucketOption) error {
+	o := &bucketOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	_, err := c.client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(c.bucket),
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrBucketNotFound):
+		if err := c.createBucket(ctx); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("failed to check bucket: %w", err)
+	}
+
+	if o.versioning {
+		if err := c.EnableVersioning(ctx); err != nil {
+			return err
+		}
+	}
+	if o.sse != "" {
+		byDefault := &types.ServerSideEncryptionByDefault{SSEAlgorithm: o.sse}
+		if o.sse == types.ServerSideEncryptionAwsKms {
+			byDefault.KMSMasterKeyID = stringOrNil(o.kmsKeyID)
+		}
+		_, err := c.client.PutBucketEncryption(ctx, &s3.PutBucketEncryptionInput{
+			Bucket: aws.String(c.bucket),
+			ServerSideEncryptionConfiguration: &types.ServerSideEncryptionConfiguration{
+				Rules: []types.ServerSideEncryptionRule{{ApplyServerSideEncryptionByDefault: byDefault}},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set bucket encryption: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) createBucket(ctx context.Context) error {
+	input := &s3.CreateBucketInput{
+		Bucket: aws.String(c.bucket),
+	}
+	// us-east-1 is the default location and rejects an explicit constraint.
+	// MinIO and other S3-compatible servers accept their own region either way.
+	switch {
+	case IsDirectoryBucket(c.bucket):
+		input.CreateBucketConfiguration = &types.CreateBucketConfiguration{
+			Location: &types.LocationInfo{
+				Type: types.LocationTypeAvailabilityZone,
+				Name: aws.String(directoryBucketZone(c.bucket)),
+			},
+			Bucket: &types.BucketInfo{
+				Type:           types.BucketTypeDirectory,
+				DataRedundancy: types.DataRedundancySingleAvailabilityZone,
+			},
+		}
+	case c.region != "" && c.region != "us-east-1":
+		input.CreateBucketConfiguration = &types.CreateBucketConfiguration{
+			LocationConstraint: types.BucketLocationConstraint(c.region),
+		}
+	}
+	_, err := c.client.CreateBucket(ctx, input)
+	if err != nil {
+		// Another instance may have created it since HeadBucket.
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "BucketAlreadyOwnedByYou" {
+			return nil
+		}
+		return fmt.Errorf("failed to create bucket: %w", err)
+	}
+	return nil
+}
+
+// Ping checks with a HeadBucket request that the endpoint is reachable, the
+// credentials are valid and the bucket exists, e.g. for readiness probes.
+// A missing bucket is reported as ErrBucketNotFound, rejected credentials
+// as ErrAccessDenied. Wrap ctx with WithNoRetry for a probe that fails
+// fast.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(c.bucket),
+	})
+	if errors.Is(err, ErrNotFound) && !errors.Is(err, ErrBucketNotFound) {
+		// HeadBucket responses have no body to tell NoSuchBucket apart.
+		err = &apiError{err: err, kind: ErrBucketNotFound}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to ping bucket: %w", err)
+	}
+	return nil
+}
+
+// BucketInfo is a bucket owned by the account.
+type BucketInfo struct {
+	Name         string
+	CreationDate time.Time
+}
+
+// ListBuckets returns the buckets owned by the credentials' account, not
+// only the configured one.
+func (c *Client) ListBuckets(ctx context.Context) ([]BucketInfo, error) {
+	output, err := c.client.ListBuckets(ctx, &s3.ListBucketsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %w", err)
+	}
+	buckets := make([]BucketInfo, 0, len(output.Buckets))
+	for _, b := range output.Buckets {
+		buckets = append(buckets, BucketInfo{
+			Name:         aws.ToString(b.Name),
+			CreationDate: aws.ToTime(b.CreationDate),
+		})
+	}
+	return buckets, nil
+}
+
+// BucketExists reports whether the bucket name exists. A bucket owned by
+// someone else that the credentials may not access is reported as an
+// ErrAccessDenied error.
+func (c *Client) BucketExists(ctx context.Context, name string) (bool, error) {
+	derived, err := c.ForBucket(name)
+	if err != nil {
+		return false, err
+	}
+	err = derived.Ping(ctx)
+	if errors.Is(err, ErrBucketNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// DeleteBucket deletes the bucket name, which S3 only allows once it is
+// empty. With force, its objects, all their versions and delete markers,
+// and its unfinished multipart uploads are deleted first; objects under
+// object lock make it fail.
+func (c *Client) DeleteBucket(ctx context.Context, name string, force bool) error {
+	if force {
+		derived, err := c.ForBucket(name)
+		if err != nil {
+			return err
+		}
+		if err := derived.emptyBucket(ctx); err != nil {
+			return err
+		}
+	}
+	_, err := c.client.DeleteBucket(ctx, &s3.DeleteBucketInput{
+		Bucket: aws.String(name),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete bucket: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) emptyBucket(ctx context.Context) error {
+	aborted, err := c.AbortStaleUploads(ctx, 0)
+	var apiErr smithy.APIError
+	switch {
+	case errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchUpload":
+		// Some S3-compatible servers answer an empty listing this way.
+	case err != nil:
+		return err
+	case len(aborted.Failed) > 0:
+		return fmt.Errorf("failed to empty bucket: %w", aborted.Failed[0].Err)
+	}
+
+	result := &DeleteResult{}
+	if !IsDirectoryBucket(c.bucket) {
+		// Directory buckets are not versioned.
+		versions, err := c.deleteAllVersions(ctx)
+		if err != nil {
+			return err
+		}
+		result.merge(versions)
+	}
+	// Also catches objects of servers that list no versions in
+	// unversioned buckets.
+	objects, err := c.DeletePrefix(ctx, "")
+	if err != nil {
+		return err
+	}
+	result.merge(objects)
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("failed to empty bucket: %d objects not deleted: %w", len(result.Failed), result.Failed[0])
+	}
+	return nil
+}
+
+// deleteAllVersions deletes every object version and delete marker, which
+// in an unversioned bucket are the objects themselves.
+func (c *Client) deleteAllVersions(ctx context.Context) (*DeleteResult, error) {
+	result := &DeleteResult{}
+	paginator := s3.NewListObjectVersionsPaginator(c.client, &s3.ListObjectVersionsInput{
+		Bucket:  aws.String(c.bucket),
+		MaxKeys: aws.Int32(maxDeleteKeys),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return result, fmt.Errorf("failed to list object versions: %w", err)
+		}
+		objects := make([]types.ObjectIdentifier, 0, len(page.Versions)+len(page.DeleteMarkers))
+		for _, v := range page.Versions {
+			objects = append(objects, types.ObjectIdentifier{Key: v.Key, VersionId: v.VersionId})
+		}
+		for _, m := range page.DeleteMarkers {
+			objects = append(objects, types.ObjectIdentifier{Key: m.Key, VersionId: m.VersionId})
+		}
+		// Versions and markers together may exceed one request.
+		for start := 0; start < len(objects); start += maxDeleteKeys {
+			batch, err := c.deleteObjects(ctx, objects[start:min(start+maxDeleteKeys, len(objects))])
+			if err != nil {
+				return result, err
+			}
+			result.merge(batch)
+		}
+	}
+	return result, nil
+}
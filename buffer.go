@@ -0,0 +1,22 @@
+package s3
+
+import "sync"
+
+// bufferPools holds a *sync.Pool of upload buffers per buffer size, so
+// consecutive uploads reuse their part buffers instead of allocating new
+// ones.
+var bufferPools sync.Map
+
+func getBuffer(size int64) []byte {
+	pool, _ := bufferPools.LoadOrStore(size, &sync.Pool{})
+	if buf, ok := pool.(*sync.Pool).Get().(*[]byte); ok {
+		return *buf
+	}
+	return make([]byte, size)
+}
+
+func putBuffer(buf []byte) {
+	buf = buf[:cap(buf)]
+	pool, _ := bufferPools.LoadOrStore(int64(len(buf)), &sync.Pool{})
+	pool.(*sync.Pool).Put(&buf)
+}
@@ -0,0 +1,139 @@
+package s3
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// sdkChecksumValidationID is the SDK middleware replaced by checksumReader,
+// which reports mismatches as ChecksumMismatchError.
+const sdkChecksumValidationID = "AWSChecksum:ValidateOutputPayloadChecksum"
+
+// ChecksumMismatchError is returned from reading a downloaded body whose
+// content does not match the checksum stored with the object.
+type ChecksumMismatchError struct {
+	Key       string
+	Algorithm types.ChecksumAlgorithm
+	Expected  string
+	Actual    string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %q: %s expected %s, got %s", e.Key, e.Algorithm, e.Expected, e.Actual)
+}
+
+// WithChecksum makes S3 verify the upload with the given algorithm (CRC32C,
+// CRC32, SHA256 or SHA1) and store the checksum with the object. Multipart
+// uploads send a checksum per part. Non-seekable bodies passed to UploadFile
+// need an HTTPS endpoint, where the SDK can send the checksum as a trailer.
+func WithChecksum(algorithm types.ChecksumAlgorithm) UploadOption {
+	return func(o *uploadOptions) { o.checksum = algorithm }
+}
+
+// WithChecksumValidation requests the stored checksum on download and fails
+// the final Read with a ChecksumMismatchError if the content differs.
+// Objects without a checksum, and multipart objects whose checksum is
+// composite, are returned unverified.
+func WithChecksumValidation() DownloadOption {
+	return func(o *downloadOptions) { o.validateChecksum = true }
+}
+
+func withoutSDKChecksumValidation(stack *middleware.Stack) error {
+	_, _ = stack.Deserialize.Remove(sdkChecksumValidationID)
+	return nil
+}
+
+func newChecksumHash(algorithm types.ChecksumAlgorithm) hash.Hash {
+	switch algorithm {
+	case types.ChecksumAlgorithmCrc32c:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	case types.ChecksumAlgorithmCrc32:
+		return crc32.NewIEEE()
+	case types.ChecksumAlgorithmSha256:
+		return sha256.New()
+	case types.ChecksumAlgorithmSha1:
+		return sha1.New()
+	}
+	return nil
+}
+
+// responseChecksum picks the strongest full-object checksum in the response.
+func responseChecksum(output *s3.GetObjectOutput) (types.ChecksumAlgorithm, string) {
+	candidates := []struct {
+		algorithm types.ChecksumAlgorithm
+		value     *string
+	}{
+		{types.ChecksumAlgorithmSha256, output.ChecksumSHA256},
+		{types.ChecksumAlgorithmCrc32c, output.ChecksumCRC32C},
+		{types.ChecksumAlgorithmSha1, output.ChecksumSHA1},
+		{types.ChecksumAlgorithmCrc32, output.ChecksumCRC32},
+	}
+	for _, c := range candidates {
+		value := aws.ToString(c.value)
+		// Multipart objects store "<checksum of part checksums>-<parts>".
+		if value != "" && !strings.Contains(value, "-") {
+			return c.algorithm, value
+		}
+	}
+	return "", ""
+}
+
+// verifyChecksum wraps body so that reaching EOF checks it against the
+// checksum returned by GetObject.
+func verifyChecksum(key string, output *s3.GetObjectOutput, body io.ReadCloser) io.ReadCloser {
+	algorithm, expected := responseChecksum(output)
+	if algorithm == "" {
+		return body
+	}
+	return &checksumReader{
+		ReadCloser: body,
+		key:        key,
+		algorithm:  algorithm,
+		expected:   expected,
+		hash:       newChecksumHash(algorithm),
+	}
+}
+
+type checksumReader struct {
+	io.ReadCloser
+	key       string
+	algorithm types.ChecksumAlgorithm
+	expected  string
+	hash      hash.Hash
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF {
+		if actual := base64.StdEncoding.EncodeToString(r.hash.Sum(nil)); actual != r.expected {
+			return n, &ChecksumMismatchError{
+				Key:       r.key,
+				Algorithm: r.algorithm,
+				Expected:  r.expected,
+				Actual:    actual,
+			}
+		}
+	}
+	return n, err
+}
+
+// partChecksum copies the checksum returned by UploadPart into the
+// completed part, as CompleteMultipartUpload requires.
+func partChecksum(part *types.CompletedPart, output *s3.UploadPartOutput) {
+	part.ChecksumCRC32 = output.ChecksumCRC32
+	part.ChecksumCRC32C = output.ChecksumCRC32C
+	part.ChecksumSHA1 = output.ChecksumSHA1
+	part.ChecksumSHA256 = output.ChecksumSHA256
+}
@@ -0,0 +1,162 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aws/smithy-go/middleware"
+)
+
+const defaultCircuitCooldown = 30 * time.Second
+
+// ErrCircuitOpen is returned without sending a request while the circuit
+// breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+type CircuitState string
+
+const (
+	CircuitClosed CircuitState = "closed"
+	CircuitOpen   CircuitState = "open"
+	// CircuitHalfOpen lets a single probe request through after the
+	// cool-down; its outcome closes or reopens the circuit.
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitBreakerOptions makes the client fail fast with ErrCircuitOpen once
+// FailureThreshold operations in a row failed because of the endpoint
+// (unreachable, timed out, 5xx, throttled) after their retries. Requests
+// rejected for other reasons, like a missing object, do not count. The zero
+// value disables the breaker.
+type CircuitBreakerOptions struct {
+	FailureThreshold int
+	// Cooldown is how long the circuit stays open before a probe request
+	// is let through. Defaults to 30 seconds.
+	Cooldown time.Duration
+	// OnStateChange, when set, is called on every transition.
+	OnStateChange func(from, to CircuitState)
+}
+
+type circuitBreaker struct {
+	opts CircuitBreakerOptions
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newCircuitBreaker(opts CircuitBreakerOptions) *circuitBreaker {
+	if opts.FailureThreshold <= 0 {
+		return nil
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = defaultCircuitCooldown
+	}
+	return &circuitBreaker{opts: opts, state: CircuitClosed}
+}
+
+// CircuitState returns the state of the client's circuit breaker, always
+// CircuitClosed when it is disabled.
+func (c *Client) CircuitState() CircuitState {
+	if c.breaker == nil {
+		return CircuitClosed
+	}
+	c.breaker.mu.Lock()
+	defer c.breaker.mu.Unlock()
+	return c.breaker.state
+}
+
+// setState changes the state with b.mu held and returns the notification
+// to run once it is released.
+func (b *circuitBreaker) setState(state CircuitState) func() {
+	from := b.state
+	b.state = state
+	if from == state || b.opts.OnStateChange == nil {
+		return func() {}
+	}
+	return func() { b.opts.OnStateChange(from, state) }
+}
+
+// allow reports whether a request may be sent and whether it is the probe.
+func (b *circuitBreaker) allow() (allowed, probe bool) {
+	notify := func() {}
+	defer func() { notify() }()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.opts.Cooldown {
+			return false, false
+		}
+		notify = b.setState(CircuitHalfOpen)
+		b.probing = true
+		return true, true
+	case CircuitHalfOpen:
+		if b.probing {
+			return false, false
+		}
+		b.probing = true
+		return true, true
+	}
+	return true, false
+}
+
+func (b *circuitBreaker) done(err error, probe bool) {
+	notify := func() {}
+	defer func() { notify() }()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+	if errors.Is(err, context.Canceled) {
+		// Tells nothing about the endpoint; a cancelled probe lets the
+		// next request probe instead.
+		return
+	}
+	if !isEndpointFailure(err) {
+		b.failures = 0
+		if b.state == CircuitHalfOpen {
+			notify = b.setState(CircuitClosed)
+		}
+		return
+	}
+	b.failures++
+	if probe || b.failures >= b.opts.FailureThreshold {
+		b.openedAt = time.Now()
+		notify = b.setState(CircuitOpen)
+	}
+}
+
+func addCircuitBreaker(b *circuitBreaker) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		// CreateSession runs inside the directory bucket operation needing
+		// the session, which the breaker already let through.
+		if isPresignStack(stack) || stack.ID() == "CreateSession" {
+			return nil
+		}
+		// In Initialize, so an operation counts once however many times it
+		// was retried.
+		return stack.Initialize.Add(&circuitBreakerMiddleware{breaker: b}, middleware.After)
+	}
+}
+
+type circuitBreakerMiddleware struct {
+	breaker *circuitBreaker
+}
+
+func (*circuitBreakerMiddleware) ID() string { return "go-s3.CircuitBreaker" }
+
+func (m *circuitBreakerMiddleware) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	allowed, probe := m.breaker.allow()
+	if !allowed {
+		return middleware.InitializeOutput{}, middleware.Metadata{}, ErrCircuitOpen
+	}
+	out, metadata, err := next.HandleInitialize(ctx, in)
+	m.breaker.done(err, probe)
+	return out, metadata, err
+}
@@ -0,0 +1,88 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var (
+		requests atomic.Int32
+		status   atomic.Int32
+	)
+	status.Store(http.StatusInternalServerError)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	var transitions []string
+	client, err := New(&Config{
+		Endpoint:        server.URL,
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		BucketName:      "bucket",
+		Region:          "us-east-1",
+		AddressingStyle: AddressingPath,
+		Retry:           RetryOptions{MaxAttempts: 1},
+		CircuitBreaker: CircuitBreakerOptions{
+			FailureThreshold: 2,
+			Cooldown:         50 * time.Millisecond,
+			OnStateChange: func(from, to CircuitState) {
+				transitions = append(transitions, string(from)+"->"+string(to))
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// A missing object is not an endpoint failure.
+	status.Store(http.StatusNotFound)
+	if _, err := client.FileExists(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	status.Store(http.StatusInternalServerError)
+	for i := 0; i < 2; i++ {
+		if _, err := client.FileExists(ctx, "a"); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("request %d: err = %v, want a server error", i, err)
+		}
+	}
+	if state := client.CircuitState(); state != CircuitOpen {
+		t.Fatalf("state = %s, want %s", state, CircuitOpen)
+	}
+
+	sent := requests.Load()
+	if _, err := client.FileExists(ctx, "a"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if requests.Load() != sent {
+		t.Fatal("request sent while the circuit is open")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	status.Store(http.StatusOK)
+	if _, err := client.FileExists(ctx, "a"); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if state := client.CircuitState(); state != CircuitClosed {
+		t.Fatalf("state = %s, want %s", state, CircuitClosed)
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->closed"}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Fatalf("transitions = %v, want %v", transitions, want)
+		}
+	}
+}
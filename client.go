@@ -1,11 +1,13 @@
 package s3
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,79 +16,361 @@ import (
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"go.opentelemetry.io/otel"
 )
 
 type Client struct {
-	client   *s3.Client
-	bucket   string
-	endpoint string
+	client      *s3.Client
+	presigner   *s3.PresignClient
+	cloudFront  *cloudFrontSigner
+	credentials *rotatingCredentials
+	bucket      string
+	endpoint    string
+	pathStyle   bool
+	accessPoint *accessPoint
+	accelerate  bool
+	dualStack   bool
+	region      string
+	presignTTL  time.Duration
+	keyBuilder  KeyBuilder
+	detector    ContentTypeDetector
+	logger      *slog.Logger
+	sse         types.ServerSideEncryption
+	kmsKeyID    string
+	// sseCustomerKey is Config.SSECustomerKey, kept to validate
+	// ForBucket.
+	sseCustomerKey []byte
+	softDelete     *SoftDeleteConfig
+	validation     *ValidationConfig
+	middlewares    *middlewareChain
+	breaker        *circuitBreaker
+
+	presignConcurrency int
 }
 
 func New(cfg *Config) (*Client, error) {
-	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
-		return nil, errors.New("S3 credentials not configured")
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	awsCfg, err := awsconfig.LoadDefaultConfig(context.TODO(),
+	loadOptions := []func(*awsconfig.LoadOptions) error{
 		awsconfig.WithRegion(cfg.Region),
-		awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+		awsconfig.WithHTTPClient(httpClient),
+		awsconfig.WithRetryer(newRetryer(cfg.Retry)),
+	}
+	if cfg.Profile != "" {
+		loadOptions = append(loadOptions, awsconfig.WithSharedConfigProfile(cfg.Profile))
+	}
+	if cfg.CredentialsProvider != nil {
+		loadOptions = append(loadOptions, awsconfig.WithCredentialsProvider(cachedCredentials(cfg.CredentialsProvider)))
+	} else if !cfg.UseDefaultCredentialChain && cfg.WebIdentity == nil && cfg.Profile == "" {
+		if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+			return nil, errors.New("S3 credentials not configured")
+		}
+		loadOptions = append(loadOptions, awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
 			cfg.AccessKeyID,
 			cfg.SecretAccessKey,
 			"",
-		)),
-	)
+		)))
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.TODO(), loadOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
+	if cfg.WebIdentity != nil {
+		if cfg.WebIdentity.RoleARN == "" {
+			return nil, errors.New("web identity role ARN not configured")
+		}
+		if cfg.WebIdentity.TokenFile == "" && cfg.WebIdentity.TokenProvider == nil {
+			return nil, errors.New("web identity token not configured")
+		}
+		awsCfg.Credentials = webIdentityProvider(awsCfg, cfg.WebIdentity)
+	}
+	if cfg.AssumeRole != nil {
+		if cfg.AssumeRole.RoleARN == "" {
+			return nil, errors.New("assume role ARN not configured")
+		}
+		awsCfg.Credentials = assumeRoleProvider(awsCfg, cfg.AssumeRole)
+	}
+	rotating := &rotatingCredentials{provider: awsCfg.Credentials}
+	awsCfg.Credentials = rotating
 
+	if cfg.SSECustomerKey != nil {
+		if err := validateSSECustomerKey(cfg.SSECustomerKey); err != nil {
+			return nil, err
+		}
+		if cfg.ServerSideEncryption != "" || cfg.KMSKeyID != "" {
+			return nil, errors.New("SSE-C cannot be combined with ServerSideEncryption or KMSKeyID")
+		}
+	}
+
+	pathStyle, err := resolveAddressing(cfg)
+	if err != nil {
+		return nil, err
+	}
+	accessPoint, _ := parseAccessPoint(cfg.BucketName)
+	if err := validateDirectoryBucket(cfg, pathStyle); err != nil {
+		return nil, err
+	}
+
+	cloudFront, err := newCloudFrontSigner(cfg.CloudFront)
+	if err != nil {
+		return nil, err
+	}
+
+	tracerProvider := cfg.TracerProvider
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+
+	rateLimit := addRateLimit(cfg.RateLimit)
+	breaker := newCircuitBreaker(cfg.CircuitBreaker)
+	middlewares := &middlewareChain{}
 	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
-		o.BaseEndpoint = aws.String(cfg.Endpoint)
-		o.UsePathStyle = true
+		o.APIOptions = append(o.APIOptions, addErrorMapping, addMiddlewareChain(middlewares), addTracing(tracerProvider), addSSECustomerKey(cfg.SSECustomerKey), addRequesterPays(cfg.RequesterPays), addTimeouts(cfg.Timeouts), addRequestOptions)
+		if cfg.Metrics != nil {
+			o.APIOptions = append(o.APIOptions, addMetrics(cfg.Metrics))
+		}
+		if cfg.RateLimit != (RateLimitOptions{}) {
+			o.APIOptions = append(o.APIOptions, rateLimit)
+		}
+		if breaker != nil {
+			o.APIOptions = append(o.APIOptions, addCircuitBreaker(breaker))
+		}
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = pathStyle
+		o.UseAccelerate = cfg.Accelerate
+		o.UseARNRegion = cfg.UseARNRegion
+		if cfg.DualStack {
+			o.EndpointOptions.UseDualStackEndpoint = aws.DualStackEndpointStateEnabled
+		}
+		if cfg.ExpressCredentials != nil {
+			o.ExpressCredentials = cfg.ExpressCredentials
+		}
+		if cfg.DisableExpressSessionAuth {
+			o.DisableS3ExpressSessionAuth = aws.Bool(true)
+		}
 	})
 
+	presignTTL := cfg.DefaultPresignTTL
+	if presignTTL <= 0 {
+		presignTTL = defaultPresignTTL
+	}
+	keyBuilder := cfg.KeyBuilder
+	if keyBuilder == nil {
+		keyBuilder = defaultKeyBuilder
+	}
+	detector := cfg.ContentTypeDetector
+	if detector == nil {
+		detector = DetectContentType
+	}
+	presignConcurrency := cfg.PresignConcurrency
+	if presignConcurrency <= 0 {
+		presignConcurrency = defaultPresignConcurrency
+	}
+	sse := cfg.ServerSideEncryption
+	if sse == "" && cfg.KMSKeyID != "" {
+		sse = types.ServerSideEncryptionAwsKms
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger = logger.With(slog.String("component", "go-s3"))
+	if cfg.Audit != nil {
+		middlewares.list = append(middlewares.list, auditMiddleware(cfg.Audit, logger))
+	}
+
 	return &Client{
-		client:   client,
-		bucket:   cfg.BucketName,
-		endpoint: cfg.Endpoint,
+		client:         client,
+		presigner:      s3.NewPresignClient(client),
+		cloudFront:     cloudFront,
+		credentials:    rotating,
+		bucket:         cfg.BucketName,
+		endpoint:       cfg.Endpoint,
+		pathStyle:      pathStyle,
+		accessPoint:    accessPoint,
+		accelerate:     cfg.Accelerate,
+		dualStack:      cfg.DualStack,
+		region:         cfg.Region,
+		presignTTL:     presignTTL,
+		keyBuilder:     keyBuilder,
+		detector:       detector,
+		logger:         logger,
+		sse:            sse,
+		kmsKeyID:       cfg.KMSKeyID,
+		sseCustomerKey: cfg.SSECustomerKey,
+		softDelete:     newSoftDeleteConfig(cfg.SoftDelete),
+		validation:     cfg.Validation,
+		middlewares:    middlewares,
+		breaker:        breaker,
+
+		presignConcurrency: presignConcurrency,
 	}, nil
 }
 
-func (c *Client) UploadFile(ctx context.Context, objectID string, key string, body io.Reader, contentType string) (string, error) {
-	objectKey := fmt.Sprintf("%s/%s", objectID, key)
-	_, err := c.client.PutObject(ctx, &s3.PutObjectInput{
+// UploadResult describes an object stored by UploadFile.
+type UploadResult struct {
+	Key  string
+	ETag string
+	// VersionID is set in versioned buckets.
+	VersionID string
+	// Size is the number of bytes stored, after compression.
+	Size int64
+	// Location is the unsigned object URL, see ObjectURL.
+	Location string
+	// URL is a presigned download URL, set only with WithPresignedURL.
+	URL string
+}
+
+func (c *Client) uploadResult(key string, etag, versionID *string, size int64) *UploadResult {
+	return &UploadResult{
+		Key:       key,
+		ETag:      aws.ToString(etag),
+		VersionID: aws.ToString(versionID),
+		Size:      size,
+		Location:  c.ObjectURL(key),
+	}
+}
+
+// UploadFile stores body under the key built by the KeyBuilder. No URL is
+// presigned unless WithPresignedURL is passed; GetPresignedURL presigns one
+// later.
+func (c *Client) UploadFile(ctx context.Context, objectID string, key string, body io.Reader, contentType string, opts ...PutOption) (*UploadResult, error) {
+	key, err := c.validation.filename(key)
+	if err != nil {
+		return nil, err
+	}
+	objectKey := c.keyBuilder(objectID, key)
+	o := c.newUploadOptions(opts)
+	if contentType == "" && o.contentType == "" {
+		if contentType, body, err = c.sniffContentType(objectKey, body); err != nil {
+			return nil, err
+		}
+	}
+	effectiveType := contentType
+	if o.contentType != "" {
+		effectiveType = o.contentType
+	}
+	if err := c.validation.checkContentType(effectiveType); err != nil {
+		return nil, err
+	}
+	if readerSize(body) < 0 {
+		// Bodies of unknown length, such as pipes and request bodies, are
+		// streamed through UploadLarge instead of being buffered in full.
+		result, err := c.uploadLarge(ctx, objectKey, body, append(opts, WithContentType(effectiveType))...)
+		if err != nil {
+			return nil, err
+		}
+		return result, c.presignResult(ctx, result, o)
+	}
+	if validated := c.validation.body(body); validated != nil {
+		// PutObject stores the object once the body is sent, so the body is
+		// checked in full before the request starts.
+		data, err := io.ReadAll(validated)
+		if err != nil {
+			validated.close()
+			return nil, fmt.Errorf("failed to read upload body: %w", err)
+		}
+		if err := validated.verdict(); err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+	input := &s3.PutObjectInput{
 		Bucket:      aws.String(c.bucket),
 		Key:         aws.String(objectKey),
 		Body:        body,
 		ContentType: aws.String(contentType),
-	})
+	}
+	if o.compression != "" {
+		// PutObject needs a seekable body to sign it, so the compressed
+		// payload is buffered.
+		compressed, err := o.compress(body, effectiveType)
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(compressed)
+		compressed.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to compress upload body: %w", err)
+		}
+		body = bytes.NewReader(data)
+		input.Body = body
+	}
+	o.applyPut(input)
+	size := readerSize(body)
+	progress := newProgressTracker(o.progress, size)
+	output, err := c.client.PutObject(ctx, input, append(progress.apiOptions(), o.conditionalWrite()...)...)
 	if err != nil {
-		return "", fmt.Errorf("failed to upload file to S3: %w", err)
+		return nil, fmt.Errorf("failed to upload file to S3: %w", err)
 	}
+	progress.finish()
 
-	presignedURL, err := c.GetPresignedURL(ctx, objectKey, 15*time.Minute)
+	result := c.uploadResult(objectKey, output.ETag, output.VersionId, size)
+	return result, c.presignResult(ctx, result, o)
+}
+
+func (c *Client) presignResult(ctx context.Context, result *UploadResult, o *uploadOptions) error {
+	if o.presignExpiration <= 0 {
+		return nil
+	}
+	presignedURL, err := c.GetPresignedURL(ctx, result.Key, o.presignExpiration)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
+		return fmt.Errorf("failed to generate presigned URL: %w", err)
 	}
-	return presignedURL, nil
+	result.URL = presignedURL
+	return nil
 }
 
-func (c *Client) DeleteFile(ctx context.Context, key string) error {
-	_, err := c.client.DeleteObject(ctx, &s3.DeleteObjectInput{
-		Bucket: aws.String(c.bucket),
-		Key:    aws.String(key),
-	})
+// DeleteFile removes the object, or moves it to the trash when soft delete is
+// configured.
+func (c *Client) DeleteFile(ctx context.Context, key string, opts ...DeleteOption) error {
+	o := &deleteOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if c.trashed(key, o) {
+		return c.moveToTrash(ctx, key, o)
+	}
+	return c.deleteObject(ctx, key, o)
+}
+
+func (c *Client) deleteObject(ctx context.Context, key string, o *deleteOptions) error {
+	var optFns []func(*s3.Options)
+	if o.ifMatch != "" {
+		optFns = append(optFns, s3.WithAPIOptions(smithyhttp.SetHeaderValue("If-Match", o.ifMatch)))
+	}
+	input := &s3.DeleteObjectInput{
+		Bucket:    aws.String(c.bucket),
+		Key:       aws.String(key),
+		VersionId: stringOrNil(o.versionID),
+	}
+	if o.bypassGovernance {
+		input.BypassGovernanceRetention = aws.Bool(true)
+	}
+	_, err := c.client.DeleteObject(ctx, input, optFns...)
 	if err != nil {
 		return fmt.Errorf("failed to delete file from S3: %w", err)
 	}
 	return nil
 }
 
-func (c *Client) GetPresignedURL(ctx context.Context, key string, expiration time.Duration) (string, error) {
-	presignClient := s3.NewPresignClient(c.client)
-	request, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
+func (c *Client) GetPresignedURL(ctx context.Context, key string, expiration time.Duration, opts ...PresignOption) (string, error) {
+	input := &s3.GetObjectInput{
 		Bucket: aws.String(c.bucket),
 		Key:    aws.String(key),
-	}, func(opts *s3.PresignOptions) {
+	}
+	for _, opt := range opts {
+		opt(input)
+	}
+
+	request, err := c.presigner.PresignGetObject(ctx, input, func(opts *s3.PresignOptions) {
 		opts.Expires = expiration
 	})
 	if err != nil {
@@ -101,100 +385,90 @@ func (c *Client) FileExists(ctx context.Context, key string) (bool, error) {
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		return false, nil
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check file existence in S3: %w", err)
 	}
 	return true, nil
 }
 
+// Deprecated: Use KeyFromURL, which parses the key out of the URL instead of
+// listing and presigning every object under prefix.
 func (c *Client) FindKeyByPresignedURL(ctx context.Context, presignedURL string, prefix string) (string, error) {
-	objects, err := c.client.ListObjects(ctx, &s3.ListObjectsInput{
-		Bucket: aws.String(c.bucket),
-		Prefix: aws.String(prefix),
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to list objects: %w", err)
-	}
-
-	normalizedTarget := normalizeURL(presignedURL)
-	for _, obj := range objects.Contents {
-		objPresignedURL, err := c.GetPresignedURL(ctx, *obj.Key, 15*time.Minute)
-		if err != nil {
-			continue
-		}
-		if normalizeURL(objPresignedURL) == normalizedTarget {
-			return *obj.Key, nil
-		}
+	key, err := c.KeyFromURL(presignedURL)
+	if err != nil || !strings.HasPrefix(key, prefix) {
+		return "", fmt.Errorf("object not found for the given presigned URL")
 	}
-
-	return "", fmt.Errorf("object not found for the given presigned URL")
-}
-
-func normalizeURL(url string) string {
-	for i := 0; i < len(url); i++ {
-		if url[i] == '?' || url[i] == '#' {
-			return url[:i]
-		}
-	}
-	return url
-}
-
-type presignedURLResult struct {
-	index int
-	url   string
-	err   error
+	return key, nil
 }
 
+// GetObjects returns presigned URLs for every object under prefix. URLs are
+// generated by a bounded pool of workers (Config.PresignConcurrency) that
+// stops as soon as ctx is cancelled.
 func (c *Client) GetObjects(ctx context.Context, prefix string) ([]string, error) {
-	objects, err := c.client.ListObjects(ctx, &s3.ListObjectsInput{
-		Bucket: aws.String(c.bucket),
-		Prefix: aws.String(prefix),
+	var keys []string
+	err := c.ListAll(ctx, prefix, func(obj ObjectInfo) error {
+		keys = append(keys, obj.Key)
+		return nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list objects: %w", err)
+		return nil, err
 	}
 
-	if len(objects.Contents) == 0 {
+	if len(keys) == 0 {
 		return []string{}, nil
 	}
 
-	resultsChan := make(chan presignedURLResult, len(objects.Contents))
+	presignedURLs := make([]string, len(keys))
+	errs := make([]error, len(keys))
 	var wg sync.WaitGroup
-
-	for i, object := range objects.Contents {
+	jobs := make(chan int)
+	for i := 0; i < min(c.presignConcurrency, len(keys)); i++ {
 		wg.Add(1)
-		go func(idx int, obj types.Object) {
+		go func() {
 			defer wg.Done()
-			presignedURL, err := c.GetPresignedURL(ctx, *obj.Key, 15*time.Minute)
-			resultsChan <- presignedURLResult{
-				index: idx,
-				url:   presignedURL,
-				err:   err,
+			for idx := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				presignedURLs[idx], errs[idx] = c.GetPresignedURL(ctx, keys[idx], c.presignTTL)
 			}
-		}(i, object)
+		}()
 	}
 
-	go func() {
-		wg.Wait()
-		close(resultsChan)
-	}()
+feed:
+	for i := range keys {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	presignedURLs := make([]string, len(objects.Contents))
 	errorCount := 0
 	var firstError error
-
-	for result := range resultsChan {
-		if result.err != nil {
-			errorCount++
-			if firstError == nil {
-				firstError = result.err
-			}
-			log.Printf("[go-s3 GetObjects] ERROR: Failed to get presigned URL for index %d: %v", result.index, result.err)
+	for idx, err := range errs {
+		if err == nil {
 			continue
 		}
-		presignedURLs[result.index] = result.url
+		errorCount++
+		if firstError == nil {
+			firstError = err
+		}
+		c.logger.ErrorContext(ctx, "failed to get presigned URL",
+			slog.String("op", "GetObjects"),
+			slog.String("key", keys[idx]),
+			slog.Any("error", err),
+		)
 	}
 
-	if errorCount == len(objects.Contents) {
+	if errorCount == len(keys) {
 		return nil, fmt.Errorf("failed to get presigned URLs: %w", firstError)
 	}
 
@@ -205,12 +479,45 @@ func (c *Client) GetObjects(ctx context.Context, prefix string) ([]string, error
 				validURLs = append(validURLs, url)
 			}
 		}
-		log.Printf("[go-s3 GetObjects] WARNING: %d out of %d presigned URLs failed to generate", errorCount, len(objects.Contents))
+		c.logger.WarnContext(ctx, "some presigned URLs failed to generate",
+			slog.String("op", "GetObjects"),
+			slog.Int("failed", errorCount),
+			slog.Int("total", len(keys)),
+		)
 		return validURLs, nil
 	}
 
 	return presignedURLs, nil
 }
 
+// ForBucket returns a client for another bucket that shares c's SDK client,
+// credentials and settings, so deriving it is cheap. bucket may be an access
+// point ARN; it is checked against c's addressing settings as New would.
+func (c *Client) ForBucket(bucket string) (*Client, error) {
+	cfg := &Config{
+		BucketName:      bucket,
+		Endpoint:        c.endpoint,
+		Accelerate:      c.accelerate,
+		DualStack:       c.dualStack,
+		SSECustomerKey:  c.sseCustomerKey,
+		AddressingStyle: AddressingVirtualHosted,
+	}
+	if c.pathStyle {
+		cfg.AddressingStyle = AddressingPath
+	}
+	if _, err := resolveAddressing(cfg); err != nil {
+		return nil, err
+	}
+	if err := validateDirectoryBucket(cfg, c.pathStyle); err != nil {
+		return nil, err
+	}
+	accessPoint, _ := parseAccessPoint(bucket)
+
+	derived := *c
+	derived.bucket = bucket
+	derived.accessPoint = accessPoint
+	return &derived, nil
+}
+
 func (c *Client) Bucket() string   { return c.bucket }
 func (c *Client) Endpoint() string { return c.endpoint }
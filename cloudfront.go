@@ -0,0 +1,176 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errCloudFrontDisabled = errors.New("CloudFront signing not configured")
+
+// cloudFrontEncoding is base64 with the characters CloudFront does not
+// accept in query strings replaced: "+" by "-", "=" by "_" and "/" by "~".
+var cloudFrontEncoding = strings.NewReplacer("+", "-", "=", "_", "/", "~")
+
+// CloudFrontConfig signs URLs and cookies for a CloudFront distribution in
+// front of the bucket, whose paths map to object keys.
+type CloudFrontConfig struct {
+	// Domain is the distribution URL, e.g. "https://d111111abcdef8.cloudfront.net"
+	// or a CNAME.
+	Domain string
+	// KeyPairID is the ID of the public key in the distribution's trusted
+	// key group.
+	KeyPairID string
+	// PrivateKeyPEM is the matching RSA private key, PKCS#1 or PKCS#8.
+	PrivateKeyPEM []byte
+}
+
+type cloudFrontSigner struct {
+	domain    string
+	keyPairID string
+	key       *rsa.PrivateKey
+}
+
+func newCloudFrontSigner(cfg *CloudFrontConfig) (*cloudFrontSigner, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	if cfg.Domain == "" || cfg.KeyPairID == "" {
+		return nil, errors.New("CloudFront domain and key pair ID not configured")
+	}
+	block, _ := pem.Decode(cfg.PrivateKeyPEM)
+	if block == nil {
+		return nil, errors.New("CloudFront private key is not PEM encoded")
+	}
+	var key *rsa.PrivateKey
+	if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		var ok bool
+		if key, ok = parsed.(*rsa.PrivateKey); !ok {
+			return nil, errors.New("CloudFront private key is not an RSA key")
+		}
+	} else if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+		return nil, fmt.Errorf("failed to parse CloudFront private key: %w", err)
+	}
+
+	domain := strings.TrimSuffix(cfg.Domain, "/")
+	if !strings.Contains(domain, "://") {
+		domain = "https://" + domain
+	}
+	return &cloudFrontSigner{domain: domain, keyPairID: cfg.KeyPairID, key: key}, nil
+}
+
+// resource returns the distribution URL of key.
+func (s *cloudFrontSigner) resource(key string) string {
+	return s.domain + "/" + escapeKey(key)
+}
+
+type cloudFrontPolicy struct {
+	Statement []cloudFrontStatement `json:"Statement"`
+}
+
+type cloudFrontStatement struct {
+	Resource  string `json:"Resource"`
+	Condition struct {
+		DateLessThan struct {
+			EpochTime int64 `json:"AWS:EpochTime"`
+		} `json:"DateLessThan"`
+	} `json:"Condition"`
+}
+
+// policy renders the policy that allows resource until expires. URLs use it
+// as the canned policy, which CloudFront rebuilds from Expires, so it must be
+// byte-for-byte the documented form.
+func (s *cloudFrontSigner) policy(resource string, expires time.Time) ([]byte, error) {
+	statement := cloudFrontStatement{Resource: resource}
+	statement.Condition.DateLessThan.EpochTime = expires.Unix()
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(cloudFrontPolicy{Statement: []cloudFrontStatement{statement}}); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func (s *cloudFrontSigner) sign(policy []byte) (string, error) {
+	digest := sha1.Sum(policy)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA1, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign CloudFront policy: %w", err)
+	}
+	return cloudFrontEncoding.Replace(base64.StdEncoding.EncodeToString(signature)), nil
+}
+
+// GetCloudFrontURL returns a CloudFront signed URL for key, valid for
+// expiration. It mirrors GetPresignedURL for objects served through the
+// distribution in Config.CloudFront.
+func (c *Client) GetCloudFrontURL(ctx context.Context, key string, expiration time.Duration) (string, error) {
+	if c.cloudFront == nil {
+		return "", errCloudFrontDisabled
+	}
+	resource := c.cloudFront.resource(key)
+	expires := time.Now().Add(expiration)
+	policy, err := c.cloudFront.policy(resource, expires)
+	if err != nil {
+		return "", err
+	}
+	signature, err := c.cloudFront.sign(policy)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	query.Set("Expires", strconv.FormatInt(expires.Unix(), 10))
+	query.Set("Signature", signature)
+	query.Set("Key-Pair-Id", c.cloudFront.keyPairID)
+	return resource + "?" + query.Encode(), nil
+}
+
+// GetCloudFrontCookies returns signed cookies that grant access to every
+// object matching pattern, e.g. "videos/123/*", for expiration. Set them on
+// the response for the CloudFront domain; the browser then fetches the
+// objects with plain URLs.
+func (c *Client) GetCloudFrontCookies(ctx context.Context, pattern string, expiration time.Duration) ([]*http.Cookie, error) {
+	if c.cloudFront == nil {
+		return nil, errCloudFrontDisabled
+	}
+	expires := time.Now().Add(expiration)
+	// Escaping would turn the "*" wildcard into a literal.
+	policy, err := c.cloudFront.policy(c.cloudFront.domain+"/"+pattern, expires)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := c.cloudFront.sign(policy)
+	if err != nil {
+		return nil, err
+	}
+	cookies := make([]*http.Cookie, 0, 3)
+	for _, cookie := range []struct{ name, value string }{
+		{"CloudFront-Policy", cloudFrontEncoding.Replace(base64.StdEncoding.EncodeToString(policy))},
+		{"CloudFront-Signature", signature},
+		{"CloudFront-Key-Pair-Id", c.cloudFront.keyPairID},
+	} {
+		cookies = append(cookies, &http.Cookie{
+			Name:     cookie.name,
+			Value:    cookie.value,
+			Path:     "/",
+			Expires:  expires,
+			Secure:   true,
+			HttpOnly: true,
+		})
+	}
+	return cookies, nil
+}
@@ -0,0 +1,149 @@
+package s3
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const defaultCompressionThreshold = 1024
+
+// Compression is a Content-Encoding applied by WithCompression.
+type Compression string
+
+const (
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+)
+
+// incompressibleTypes are content types that are compressed already.
+var incompressibleTypes = map[string]bool{
+	"application/gzip":             true,
+	"application/x-gzip":           true,
+	"application/zip":              true,
+	"application/zstd":             true,
+	"application/x-bzip2":          true,
+	"application/x-xz":             true,
+	"application/x-7z-compressed":  true,
+	"application/x-rar-compressed": true,
+	"application/vnd.rar":          true,
+	"application/pdf":              true,
+	"font/woff":                    true,
+	"font/woff2":                   true,
+}
+
+// WithCompression compresses the body and sets Content-Encoding accordingly.
+// Bodies smaller than the threshold (1 KiB unless set with
+// WithCompressionThreshold), content that is compressed already (images
+// other than SVG, audio, video, archives) and uploads with an explicit
+// Content-Encoding are stored as is.
+func WithCompression(compression Compression) UploadOption {
+	return func(o *uploadOptions) { o.compression = compression }
+}
+
+func WithCompressionThreshold(n int64) UploadOption {
+	return func(o *uploadOptions) {
+		if n >= 0 {
+			o.compressionThreshold = n
+		}
+	}
+}
+
+// WithDecompression decodes gzip and zstd Content-Encoding in DownloadFile,
+// so the body reads as the original content. The returned ObjectInfo still
+// describes the stored object.
+func WithDecompression() DownloadOption {
+	return func(o *downloadOptions) { o.decompress = true }
+}
+
+// compress returns r compressed as requested by WithCompression, and sets
+// the Content-Encoding on o. The caller must close the result.
+func (o *uploadOptions) compress(r io.Reader, contentType string) (io.ReadCloser, error) {
+	if o.compression == "" || o.contentEncoding != "" || !compressible(contentType) {
+		return io.NopCloser(r), nil
+	}
+	head := make([]byte, o.compressionThreshold)
+	n, err := io.ReadFull(r, head)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return io.NopCloser(bytes.NewReader(head[:n])), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload body: %w", err)
+	}
+
+	var encoder io.WriteCloser
+	pr, pw := io.Pipe()
+	switch o.compression {
+	case CompressionGzip:
+		encoder = gzip.NewWriter(pw)
+	case CompressionZstd:
+		encoder, err = zstd.NewWriter(pw)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", o.compression)
+	}
+	o.contentEncoding = string(o.compression)
+
+	go func() {
+		_, err := io.Copy(encoder, io.MultiReader(bytes.NewReader(head), r))
+		if closeErr := encoder.Close(); err == nil {
+			err = closeErr
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr, nil
+}
+
+func compressible(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if strings.HasPrefix(mediaType, "image/") {
+		return mediaType == "image/svg+xml" || mediaType == "image/bmp"
+	}
+	if strings.HasPrefix(mediaType, "video/") || strings.HasPrefix(mediaType, "audio/") {
+		return false
+	}
+	return !incompressibleTypes[mediaType]
+}
+
+// decompress wraps body in a decoder for contentEncoding, if it is one
+// WithCompression produces.
+func decompress(body io.ReadCloser, contentEncoding string) (io.ReadCloser, error) {
+	switch Compression(contentEncoding) {
+	case CompressionGzip:
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			body.Close()
+			return nil, fmt.Errorf("failed to decompress body: %w", err)
+		}
+		return &decompressReader{Reader: zr, body: body}, nil
+	case CompressionZstd:
+		zr, err := zstd.NewReader(body)
+		if err != nil {
+			body.Close()
+			return nil, fmt.Errorf("failed to decompress body: %w", err)
+		}
+		return &decompressReader{Reader: zr, body: body, release: zr.Close}, nil
+	}
+	return body, nil
+}
+
+type decompressReader struct {
+	io.Reader
+	body    io.ReadCloser
+	release func()
+}
+
+func (r *decompressReader) Close() error {
+	if r.release != nil {
+		r.release()
+	}
+	return r.body.Close()
+}
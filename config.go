@@ -1,9 +1,131 @@
 package s3
 
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultPresignTTL         = 15 * time.Minute
+	defaultPresignConcurrency = 16
+)
+
+// KeyBuilder maps the objectID and key passed to UploadFile to the object key
+// stored in the bucket.
+type KeyBuilder func(objectID, key string) string
+
 type Config struct {
 	Endpoint        string
 	AccessKeyID     string
 	SecretAccessKey string
-	BucketName      string
-	Region          string
+	// BucketName may also be an access point or Multi-Region Access Point
+	// ARN.
+	BucketName string
+	Region     string
+	// UseARNRegion sends requests for an access point ARN in another region
+	// to that region instead of failing.
+	UseARNRegion bool
+	// AddressingStyle defaults to AddressingAuto.
+	AddressingStyle AddressingStyle
+	// Accelerate sends requests through the S3 Transfer Acceleration edge
+	// network. The bucket must have acceleration enabled; it cannot be
+	// combined with a custom Endpoint or path-style addressing.
+	Accelerate bool
+	// DualStack uses the AWS endpoints reachable over both IPv4 and IPv6.
+	DualStack bool
+	// ExpressCredentials replaces the SDK's cache of S3 Express session
+	// credentials for directory buckets, e.g. to share one between clients.
+	ExpressCredentials s3.ExpressCredentialsProvider
+	// DisableExpressSessionAuth signs directory bucket requests with the
+	// credentials above instead of CreateSession session credentials.
+	DisableExpressSessionAuth bool
+
+	// CredentialsProvider replaces AccessKeyID and SecretAccessKey, e.g.
+	// with a provider backed by the Vault AWS secrets engine. It is cached
+	// and refreshed before the credentials expire.
+	CredentialsProvider aws.CredentialsProvider
+	// UseDefaultCredentialChain ignores AccessKeyID and SecretAccessKey and
+	// resolves credentials the way the AWS SDK does: environment, shared
+	// config, web identity (IRSA), ECS task role, EC2 instance role.
+	UseDefaultCredentialChain bool
+	// Profile selects a profile of the AWS shared config and credentials
+	// files (~/.aws/config), which then provides the credentials and, when
+	// Region is empty, the region.
+	Profile string
+	// AssumeRole, when set, exchanges the credentials above (static or from
+	// the default chain) for temporary credentials of another role.
+	AssumeRole *AssumeRoleConfig
+	// WebIdentity exchanges an OIDC token for role credentials with
+	// AssumeRoleWithWebIdentity. Static keys are not required in this mode;
+	// combined with AssumeRole the web identity role is assumed first.
+	WebIdentity *WebIdentityConfig
+
+	// HTTPClient replaces the SDK HTTP client entirely; HTTP and TLS are
+	// ignored when it is set.
+	HTTPClient *http.Client
+	HTTP       HTTPOptions
+	TLS        TLSOptions
+	Retry      RetryOptions
+	// Timeouts bound operations whose context has no deadline; metadata
+	// operations time out after 30 seconds by default.
+	Timeouts  TimeoutOptions
+	RateLimit RateLimitOptions
+	// CircuitBreaker fails calls fast with ErrCircuitOpen while the
+	// endpoint is down.
+	CircuitBreaker CircuitBreakerOptions
+
+	// Logger receives the client's diagnostics. Defaults to slog.Default().
+	Logger *slog.Logger
+	// TracerProvider creates a span for every S3 request. Defaults to the
+	// global provider from otel.GetTracerProvider().
+	TracerProvider trace.TracerProvider
+	// Metrics, when set, receives a sample for every S3 request.
+	Metrics MetricsRecorder
+
+	// DefaultPresignTTL is the lifetime of URLs returned by GetObjects,
+	// UploadFromRequest and PresignPostPolicy. Defaults to 15 minutes.
+	DefaultPresignTTL time.Duration
+	// PresignConcurrency bounds the goroutines GetObjects uses to presign
+	// URLs. Defaults to 16.
+	PresignConcurrency int
+	// KeyBuilder defaults to joining objectID and key with "/".
+	KeyBuilder KeyBuilder
+	// CloudFront enables GetCloudFrontURL and GetCloudFrontCookies for a
+	// distribution serving the bucket.
+	CloudFront *CloudFrontConfig
+	// ContentTypeDetector sets the Content-Type of uploads made without one.
+	// Defaults to DetectContentType.
+	ContentTypeDetector ContentTypeDetector
+
+	// ServerSideEncryption is applied to every upload and copy that does not
+	// set its own. KMSKeyID selects the key for aws:kms and implies aws:kms
+	// when ServerSideEncryption is empty.
+	ServerSideEncryption types.ServerSideEncryption
+	KMSKeyID             string
+	// SSECustomerKey is a 256-bit key for SSE-C, sent with every object
+	// request. It cannot be combined with ServerSideEncryption or KMSKeyID;
+	// use WithSSECustomerKey for per-call keys.
+	SSECustomerKey []byte
+	// RequesterPays accepts the request charges of requester-pays buckets on
+	// every request; use WithRequesterPays for single calls.
+	RequesterPays bool
+
+	// SoftDelete, when set, makes DeleteFile reversible with Restore.
+	SoftDelete *SoftDeleteConfig
+	// Validation, when set, checks uploads before they are stored.
+	Validation *ValidationConfig
+	// Audit, when set, receives a record for every upload, copy and delete,
+	// attributed to the actor set with WithActor.
+	Audit AuditSink
+}
+
+func defaultKeyBuilder(objectID, key string) string {
+	return fmt.Sprintf("%s/%s", objectID, key)
 }
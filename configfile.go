@@ -0,0 +1,426 @@
+package s3
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultRegion = "us-east-1"
+
+// fileConfig is the serializable part of Config read by LoadConfig and
+// ConfigFromEnv.
+type fileConfig struct {
+	Endpoint                  string `json:"endpoint" yaml:"endpoint"`
+	Region                    string `json:"region" yaml:"region"`
+	Bucket                    string `json:"bucket" yaml:"bucket"`
+	AddressingStyle           string `json:"addressing_style" yaml:"addressing_style"`
+	Accelerate                bool   `json:"accelerate" yaml:"accelerate"`
+	DualStack                 bool   `json:"dual_stack" yaml:"dual_stack"`
+	UseARNRegion              bool   `json:"use_arn_region" yaml:"use_arn_region"`
+	RequesterPays             bool   `json:"requester_pays" yaml:"requester_pays"`
+	AccessKeyID               string `json:"access_key_id" yaml:"access_key_id"`
+	SecretAccessKey           string `json:"secret_access_key" yaml:"secret_access_key"`
+	UseDefaultCredentialChain bool   `json:"use_default_credential_chain" yaml:"use_default_credential_chain"`
+	AWSProfile                string `json:"aws_profile" yaml:"aws_profile"`
+
+	HTTP struct {
+		Timeout               duration `json:"timeout" yaml:"timeout"`
+		DialTimeout           duration `json:"dial_timeout" yaml:"dial_timeout"`
+		TLSHandshakeTimeout   duration `json:"tls_handshake_timeout" yaml:"tls_handshake_timeout"`
+		ResponseHeaderTimeout duration `json:"response_header_timeout" yaml:"response_header_timeout"`
+		IdleConnTimeout       duration `json:"idle_conn_timeout" yaml:"idle_conn_timeout"`
+		MaxIdleConns          int      `json:"max_idle_conns" yaml:"max_idle_conns"`
+		MaxIdleConnsPerHost   int      `json:"max_idle_conns_per_host" yaml:"max_idle_conns_per_host"`
+		ProxyURL              string   `json:"proxy_url" yaml:"proxy_url"`
+	} `json:"http" yaml:"http"`
+	TLS struct {
+		CAFile             string `json:"ca_file" yaml:"ca_file"`
+		CertFile           string `json:"cert_file" yaml:"cert_file"`
+		KeyFile            string `json:"key_file" yaml:"key_file"`
+		InsecureSkipVerify bool   `json:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+	} `json:"tls" yaml:"tls"`
+	Retry struct {
+		MaxAttempts int      `json:"max_attempts" yaml:"max_attempts"`
+		BaseDelay   duration `json:"base_delay" yaml:"base_delay"`
+		MaxBackoff  duration `json:"max_backoff" yaml:"max_backoff"`
+	} `json:"retry" yaml:"retry"`
+	Timeouts struct {
+		Metadata duration `json:"metadata" yaml:"metadata"`
+		Transfer duration `json:"transfer" yaml:"transfer"`
+	} `json:"timeouts" yaml:"timeouts"`
+
+	PresignTTL           duration `json:"presign_ttl" yaml:"presign_ttl"`
+	PresignConcurrency   int      `json:"presign_concurrency" yaml:"presign_concurrency"`
+	ServerSideEncryption string   `json:"server_side_encryption" yaml:"server_side_encryption"`
+	KMSKeyID             string   `json:"kms_key_id" yaml:"kms_key_id"`
+}
+
+// duration reads "30s"-style strings from JSON and YAML.
+type duration time.Duration
+
+func (d *duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+// LoadConfig reads a Config from a JSON (.json) or YAML (.yaml, .yml) file.
+// ${VAR} references are replaced with environment variables first, so
+// secrets can stay out of the file:
+//
+//	endpoint: https://minio.internal:9000
+//	bucket: uploads
+//	access_key_id: ${MINIO_ACCESS_KEY}
+//	secret_access_key: ${MINIO_SECRET_KEY}
+//	http:
+//	  timeout: 30s
+//
+// Without static keys the default credential chain is used, and the region
+// defaults to us-east-1 unless an aws_profile provides it.
+//
+// A file may also hold named profiles, see LoadProfile; LoadConfig then
+// selects the one named by the S3_PROFILE environment variable or the
+// file's default_profile.
+func LoadConfig(path string) (*Config, error) {
+	return LoadProfile(path, "")
+}
+
+// LoadProfile reads the named profile of a config file with one entry per
+// environment. Settings at the top level are shared by all profiles, which
+// override them:
+//
+//	region: eu-central-1
+//	default_profile: dev
+//	profiles:
+//	  dev:
+//	    endpoint: http://localhost:9000
+//	    bucket: uploads-dev
+//	  prod:
+//	    bucket: uploads
+//	    aws_profile: prod
+//
+// An empty name selects S3_PROFILE, then default_profile.
+func LoadProfile(path, name string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	data = []byte(os.ExpandEnv(string(data)))
+
+	var file profilesFile
+	if err := decodeConfigFile(path, data, &file); err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = os.Getenv("S3_PROFILE")
+	}
+	if name == "" {
+		name = file.DefaultProfile
+	}
+
+	fc := file.fileConfig
+	switch {
+	case name == "" && len(file.Profiles) > 0:
+		return nil, fmt.Errorf("no profile selected in config %s", path)
+	case name != "":
+		profile, ok := file.Profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("profile %q not found in config %s", name, path)
+		}
+		// Decoded over the shared settings, so only the fields the profile
+		// sets are overridden.
+		if err := profile.decode(&fc); err != nil {
+			return nil, fmt.Errorf("failed to parse profile %q of config %s: %w", name, path, err)
+		}
+	}
+	return fc.config()
+}
+
+// NewFromProfile creates a client for the named profile of the config file
+// at S3_CONFIG_FILE, s3.yaml by default; an empty name selects S3_PROFILE,
+// then the file's default_profile. Without a config file, name is the AWS
+// shared config profile to use and the rest of the configuration comes from
+// ConfigFromEnv.
+func NewFromProfile(name string) (*Client, error) {
+	path := os.Getenv("S3_CONFIG_FILE")
+	if path == "" {
+		path = "s3.yaml"
+	}
+
+	var (
+		cfg *Config
+		err error
+	)
+	if _, statErr := os.Stat(path); errors.Is(statErr, fs.ErrNotExist) {
+		cfg, err = ConfigFromEnv()
+		if err == nil && name != "" {
+			cfg.Profile = name
+		}
+	} else {
+		cfg, err = LoadProfile(path, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg)
+}
+
+type profilesFile struct {
+	fileConfig     `yaml:",inline"`
+	DefaultProfile string                 `json:"default_profile" yaml:"default_profile"`
+	Profiles       map[string]profileNode `json:"profiles" yaml:"profiles"`
+}
+
+// profileNode keeps a profile undecoded until its base settings are known.
+type profileNode struct {
+	decode func(v any) error
+}
+
+func (p *profileNode) UnmarshalJSON(data []byte) error {
+	raw := append([]byte(nil), data...)
+	p.decode = func(v any) error {
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		return decoder.Decode(v)
+	}
+	return nil
+}
+
+func (p *profileNode) UnmarshalYAML(node *yaml.Node) error {
+	p.decode = node.Decode
+	return nil
+}
+
+func decodeConfigFile(path string, data []byte, v any) error {
+	var err error
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(v)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(v)
+	default:
+		return fmt.Errorf("unsupported config format %q", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return nil
+}
+
+// ConfigFromEnv reads a Config from environment variables:
+//
+//	S3_ENDPOINT, S3_REGION (or AWS_REGION), S3_BUCKET, S3_ADDRESSING_STYLE,
+//	S3_ACCELERATE, S3_DUAL_STACK, S3_USE_ARN_REGION, S3_REQUESTER_PAYS,
+//	S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY (or the AWS_ variants),
+//	S3_USE_DEFAULT_CREDENTIAL_CHAIN,
+//	S3_TIMEOUT, S3_DIAL_TIMEOUT, S3_TLS_HANDSHAKE_TIMEOUT,
+//	S3_RESPONSE_HEADER_TIMEOUT, S3_IDLE_CONN_TIMEOUT, S3_MAX_IDLE_CONNS,
+//	S3_MAX_IDLE_CONNS_PER_HOST, S3_PROXY_URL,
+//	S3_CA_FILE, S3_CERT_FILE, S3_KEY_FILE, S3_INSECURE_SKIP_VERIFY,
+//	S3_MAX_ATTEMPTS, S3_RETRY_BASE_DELAY, S3_RETRY_MAX_BACKOFF,
+//	S3_METADATA_TIMEOUT, S3_TRANSFER_TIMEOUT,
+//	S3_PRESIGN_TTL, S3_PRESIGN_CONCURRENCY,
+//	S3_SERVER_SIDE_ENCRYPTION, S3_KMS_KEY_ID
+//
+// Durations use time.ParseDuration syntax ("30s"). The defaults are those of
+// LoadConfig.
+func ConfigFromEnv() (*Config, error) {
+	var fc fileConfig
+	fc.Endpoint = os.Getenv("S3_ENDPOINT")
+	fc.Region = firstEnv("S3_REGION", "AWS_REGION")
+	fc.Bucket = os.Getenv("S3_BUCKET")
+	fc.AddressingStyle = os.Getenv("S3_ADDRESSING_STYLE")
+	fc.AccessKeyID = firstEnv("S3_ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID")
+	fc.SecretAccessKey = firstEnv("S3_SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY")
+	fc.HTTP.ProxyURL = os.Getenv("S3_PROXY_URL")
+	fc.TLS.CAFile = os.Getenv("S3_CA_FILE")
+	fc.TLS.CertFile = os.Getenv("S3_CERT_FILE")
+	fc.TLS.KeyFile = os.Getenv("S3_KEY_FILE")
+	fc.ServerSideEncryption = os.Getenv("S3_SERVER_SIDE_ENCRYPTION")
+	fc.KMSKeyID = os.Getenv("S3_KMS_KEY_ID")
+
+	var errs []error
+	envBool(&errs, "S3_ACCELERATE", &fc.Accelerate)
+	envBool(&errs, "S3_DUAL_STACK", &fc.DualStack)
+	envBool(&errs, "S3_USE_ARN_REGION", &fc.UseARNRegion)
+	envBool(&errs, "S3_REQUESTER_PAYS", &fc.RequesterPays)
+	envBool(&errs, "S3_USE_DEFAULT_CREDENTIAL_CHAIN", &fc.UseDefaultCredentialChain)
+	envBool(&errs, "S3_INSECURE_SKIP_VERIFY", &fc.TLS.InsecureSkipVerify)
+	envDuration(&errs, "S3_TIMEOUT", &fc.HTTP.Timeout)
+	envDuration(&errs, "S3_DIAL_TIMEOUT", &fc.HTTP.DialTimeout)
+	envDuration(&errs, "S3_TLS_HANDSHAKE_TIMEOUT", &fc.HTTP.TLSHandshakeTimeout)
+	envDuration(&errs, "S3_RESPONSE_HEADER_TIMEOUT", &fc.HTTP.ResponseHeaderTimeout)
+	envDuration(&errs, "S3_IDLE_CONN_TIMEOUT", &fc.HTTP.IdleConnTimeout)
+	envInt(&errs, "S3_MAX_IDLE_CONNS", &fc.HTTP.MaxIdleConns)
+	envInt(&errs, "S3_MAX_IDLE_CONNS_PER_HOST", &fc.HTTP.MaxIdleConnsPerHost)
+	envInt(&errs, "S3_MAX_ATTEMPTS", &fc.Retry.MaxAttempts)
+	envDuration(&errs, "S3_RETRY_BASE_DELAY", &fc.Retry.BaseDelay)
+	envDuration(&errs, "S3_RETRY_MAX_BACKOFF", &fc.Retry.MaxBackoff)
+	envDuration(&errs, "S3_METADATA_TIMEOUT", &fc.Timeouts.Metadata)
+	envDuration(&errs, "S3_TRANSFER_TIMEOUT", &fc.Timeouts.Transfer)
+	envDuration(&errs, "S3_PRESIGN_TTL", &fc.PresignTTL)
+	envInt(&errs, "S3_PRESIGN_CONCURRENCY", &fc.PresignConcurrency)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return fc.config()
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func envBool(errs *[]error, name string, dst *bool) {
+	if value := os.Getenv(name); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("invalid %s: %w", name, err))
+			return
+		}
+		*dst = parsed
+	}
+}
+
+func envInt(errs *[]error, name string, dst *int) {
+	if value := os.Getenv(name); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("invalid %s: %w", name, err))
+			return
+		}
+		*dst = parsed
+	}
+}
+
+func envDuration(errs *[]error, name string, dst *duration) {
+	if value := os.Getenv(name); value != "" {
+		if err := dst.UnmarshalText([]byte(value)); err != nil {
+			*errs = append(*errs, fmt.Errorf("invalid %s: %w", name, err))
+		}
+	}
+}
+
+// config validates fc and converts it, applying the defaults.
+func (fc *fileConfig) config() (*Config, error) {
+	var errs []error
+	if fc.Bucket == "" {
+		errs = append(errs, errors.New("bucket not configured"))
+	}
+	if fc.Endpoint != "" {
+		if u, err := url.Parse(fc.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("invalid endpoint %q", fc.Endpoint))
+		}
+	}
+	endpointCfg := &Config{
+		Endpoint:        fc.Endpoint,
+		BucketName:      fc.Bucket,
+		AddressingStyle: AddressingStyle(fc.AddressingStyle),
+		Accelerate:      fc.Accelerate,
+		DualStack:       fc.DualStack,
+	}
+	if pathStyle, err := resolveAddressing(endpointCfg); err != nil {
+		errs = append(errs, err)
+	} else if err := validateDirectoryBucket(endpointCfg, pathStyle); err != nil {
+		errs = append(errs, err)
+	}
+	if (fc.TLS.CertFile == "") != (fc.TLS.KeyFile == "") {
+		errs = append(errs, errors.New("TLS cert file and key file must be set together"))
+	}
+	if (fc.AccessKeyID == "") != (fc.SecretAccessKey == "") {
+		errs = append(errs, errors.New("access key ID and secret access key must be set together"))
+	}
+	switch sse := types.ServerSideEncryption(fc.ServerSideEncryption); sse {
+	case "", types.ServerSideEncryptionAes256, types.ServerSideEncryptionAwsKms, types.ServerSideEncryptionAwsKmsDsse:
+	default:
+		errs = append(errs, fmt.Errorf("invalid server-side encryption %q", sse))
+	}
+	for name, d := range map[string]duration{
+		"timeout":                 fc.HTTP.Timeout,
+		"dial timeout":            fc.HTTP.DialTimeout,
+		"TLS handshake timeout":   fc.HTTP.TLSHandshakeTimeout,
+		"response header timeout": fc.HTTP.ResponseHeaderTimeout,
+		"idle connection timeout": fc.HTTP.IdleConnTimeout,
+		"retry base delay":        fc.Retry.BaseDelay,
+		"retry max backoff":       fc.Retry.MaxBackoff,
+		"presign TTL":             fc.PresignTTL,
+	} {
+		if d < 0 {
+			errs = append(errs, fmt.Errorf("negative %s", name))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid S3 config: %w", errors.Join(errs...))
+	}
+
+	region := fc.Region
+	if region == "" && fc.AWSProfile == "" {
+		region = defaultRegion
+	}
+	return &Config{
+		Endpoint:                  fc.Endpoint,
+		AccessKeyID:               fc.AccessKeyID,
+		SecretAccessKey:           fc.SecretAccessKey,
+		BucketName:                fc.Bucket,
+		AddressingStyle:           AddressingStyle(fc.AddressingStyle),
+		Accelerate:                fc.Accelerate,
+		DualStack:                 fc.DualStack,
+		UseARNRegion:              fc.UseARNRegion,
+		RequesterPays:             fc.RequesterPays,
+		Region:                    region,
+		UseDefaultCredentialChain: fc.UseDefaultCredentialChain || fc.AccessKeyID == "",
+		Profile:                   fc.AWSProfile,
+		HTTP: HTTPOptions{
+			MaxIdleConns:          fc.HTTP.MaxIdleConns,
+			MaxIdleConnsPerHost:   fc.HTTP.MaxIdleConnsPerHost,
+			IdleConnTimeout:       time.Duration(fc.HTTP.IdleConnTimeout),
+			DialTimeout:           time.Duration(fc.HTTP.DialTimeout),
+			TLSHandshakeTimeout:   time.Duration(fc.HTTP.TLSHandshakeTimeout),
+			ResponseHeaderTimeout: time.Duration(fc.HTTP.ResponseHeaderTimeout),
+			Timeout:               time.Duration(fc.HTTP.Timeout),
+			ProxyURL:              fc.HTTP.ProxyURL,
+		},
+		TLS: TLSOptions{
+			CAFile:             fc.TLS.CAFile,
+			CertFile:           fc.TLS.CertFile,
+			KeyFile:            fc.TLS.KeyFile,
+			InsecureSkipVerify: fc.TLS.InsecureSkipVerify,
+		},
+		Retry: RetryOptions{
+			MaxAttempts: fc.Retry.MaxAttempts,
+			BaseDelay:   time.Duration(fc.Retry.BaseDelay),
+			MaxBackoff:  time.Duration(fc.Retry.MaxBackoff),
+		},
+		Timeouts: TimeoutOptions{
+			Metadata: time.Duration(fc.Timeouts.Metadata),
+			Transfer: time.Duration(fc.Timeouts.Transfer),
+		},
+		DefaultPresignTTL:    time.Duration(fc.PresignTTL),
+		PresignConcurrency:   fc.PresignConcurrency,
+		ServerSideEncryption: types.ServerSideEncryption(fc.ServerSideEncryption),
+		KMSKeyID:             fc.KMSKeyID,
+	}, nil
+}
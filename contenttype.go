@@ -0,0 +1,59 @@
+package s3
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// ContentTypeDetector picks the Content-Type of an upload made without one
+// from the object key and the first 512 bytes of the body (fewer for short
+// bodies).
+type ContentTypeDetector func(key string, head []byte) string
+
+// genericContentTypes are the http.DetectContentType results that say little
+// about the file: no signature matched, plain text, or a container format
+// such as docx or xlsx. The key's extension is more specific for them.
+var genericContentTypes = map[string]bool{
+	"application/octet-stream": true,
+	"text/plain":               true,
+	"text/xml":                 true,
+	"application/zip":          true,
+}
+
+// DetectContentType is the default ContentTypeDetector. It sniffs head with
+// http.DetectContentType and falls back to the key's extension when that
+// only finds a generic type.
+func DetectContentType(key string, head []byte) string {
+	detected := http.DetectContentType(head)
+	mediaType, _, _ := strings.Cut(detected, ";")
+	if genericContentTypes[mediaType] {
+		if byExtension := mime.TypeByExtension(path.Ext(key)); byExtension != "" {
+			return byExtension
+		}
+	}
+	return detected
+}
+
+// sniffContentType detects the content type of r and returns a reader that
+// still yields the whole body. Seekable bodies are rewound so they stay
+// seekable for signing and progress reporting.
+func (c *Client) sniffContentType(key string, r io.Reader) (string, io.Reader, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", nil, fmt.Errorf("failed to read upload body: %w", err)
+	}
+	head = head[:n]
+	contentType := c.detector(key, head)
+	if seeker, ok := r.(io.Seeker); ok {
+		if _, err := seeker.Seek(int64(-n), io.SeekCurrent); err == nil {
+			return contentType, r, nil
+		}
+	}
+	return contentType, io.MultiReader(bytes.NewReader(head), r), nil
+}
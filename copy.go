@@ -0,0 +1,220 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	maxCopyObjectSize = 5 * 1024 * 1024 * 1024
+	copyPartSize      = 512 * 1024 * 1024
+)
+
+// CopyFile copies srcKey to dstKey inside the bucket without downloading the
+// object. Objects larger than 5 GB, which CopyObject rejects, are copied with
+// UploadPartCopy.
+func (c *Client) CopyFile(ctx context.Context, srcKey, dstKey string, opts ...CopyOption) error {
+	o := &copyOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	_, err := c.copyFile(ctx, srcKey, dstKey, o)
+	return err
+}
+
+// copyFile pins the copy to the source ETag seen by HeadObject, after
+// checking the source conditions in o, and returns the source head for
+// verification.
+func (c *Client) copyFile(ctx context.Context, srcKey, dstKey string, o *copyOptions) (*s3.HeadObjectOutput, error) {
+	head, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:            aws.String(c.bucket),
+		Key:               aws.String(srcKey),
+		VersionId:         stringOrNil(o.versionID),
+		IfMatch:           stringOrNil(o.ifMatch),
+		IfUnmodifiedSince: timeOrNil(o.ifUnmodifiedSince),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source object info: %w", err)
+	}
+	source := versionedCopySource(c.bucket, srcKey, o.versionID)
+
+	if aws.ToInt64(head.ContentLength) <= maxCopyObjectSize {
+		sse, kmsKeyID := c.copyEncryption(head)
+		_, err = c.client.CopyObject(ctx, &s3.CopyObjectInput{
+			Bucket:               aws.String(c.bucket),
+			Key:                  aws.String(dstKey),
+			CopySource:           aws.String(source),
+			CopySourceIfMatch:    head.ETag,
+			ServerSideEncryption: sse,
+			SSEKMSKeyId:          kmsKeyID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy file in S3: %w", err)
+		}
+		return head, nil
+	}
+	tags, err := c.objectTags(ctx, srcKey, head.VersionId)
+	if err != nil {
+		return nil, err
+	}
+	return head, c.multipartCopy(ctx, source, dstKey, head, tags)
+}
+
+func (c *Client) MoveFile(ctx context.Context, srcKey, dstKey string) error {
+	if srcKey == dstKey {
+		return nil
+	}
+	if err := c.CopyFile(ctx, srcKey, dstKey); err != nil {
+		return err
+	}
+	return c.deleteObject(ctx, srcKey, &deleteOptions{})
+}
+
+// multipartCopy copies the object at source, a value built by copySource,
+// to dstKey with UploadPartCopy. Unlike CopyObject, a multipart upload does
+// not carry over the source's headers and tags, so they are set from head
+// and tags.
+func (c *Client) multipartCopy(ctx context.Context, source, dstKey string, head *s3.HeadObjectOutput, tags map[string]string) error {
+	sse, kmsKeyID := c.copyEncryption(head)
+	return c.multipartCopyEncrypted(ctx, source, dstKey, head, tags, sse, kmsKeyID)
+}
+
+// multipartCopyEncrypted is multipartCopy with the encryption of the copy
+// given explicitly.
+func (c *Client) multipartCopyEncrypted(ctx context.Context, source, dstKey string, head *s3.HeadObjectOutput, tags map[string]string, sse types.ServerSideEncryption, kmsKeyID *string) error {
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:                  aws.String(c.bucket),
+		Key:                     aws.String(dstKey),
+		ContentType:             head.ContentType,
+		CacheControl:            head.CacheControl,
+		ContentDisposition:      head.ContentDisposition,
+		ContentEncoding:         head.ContentEncoding,
+		ContentLanguage:         head.ContentLanguage,
+		Expires:                 head.Expires,
+		WebsiteRedirectLocation: head.WebsiteRedirectLocation,
+		Metadata:                head.Metadata,
+		Tagging:                 encodeTags(tags),
+		StorageClass:            head.StorageClass,
+		ServerSideEncryption:    sse,
+		SSEKMSKeyId:             kmsKeyID,
+	}
+	if source == copySource(c.bucket, dstKey) {
+		// An object rewritten in place keeps its object lock; other
+		// destinations may not have object lock enabled.
+		input.ObjectLockMode = head.ObjectLockMode
+		input.ObjectLockRetainUntilDate = head.ObjectLockRetainUntilDate
+		input.ObjectLockLegalHoldStatus = head.ObjectLockLegalHoldStatus
+	}
+	created, err := c.client.CreateMultipartUpload(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to create multipart upload: %w", err)
+	}
+	uploadID := aws.ToString(created.UploadId)
+
+	parts, err := c.copyParts(ctx, source, dstKey, uploadID, aws.ToInt64(head.ContentLength), aws.ToString(head.ETag))
+	if err != nil {
+		c.abortMultipartUpload(dstKey, uploadID)
+		return err
+	}
+
+	_, err = c.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(c.bucket),
+		Key:             aws.String(dstKey),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		c.abortMultipartUpload(dstKey, uploadID)
+		return fmt.Errorf("failed to complete multipart copy: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) copyParts(ctx context.Context, source, dstKey, uploadID string, size int64, etag string) ([]types.CompletedPart, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		once     sync.Once
+		parts    []types.CompletedPart
+		firstErr error
+	)
+	sem := make(chan struct{}, defaultConcurrency)
+
+	partNumber := int32(1)
+	for offset := int64(0); offset < size && ctx.Err() == nil; offset += copyPartSize {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(partNumber int32, offset int64) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			end := min(offset+copyPartSize, size) - 1
+			output, err := c.client.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+				Bucket:            aws.String(c.bucket),
+				Key:               aws.String(dstKey),
+				UploadId:          aws.String(uploadID),
+				PartNumber:        aws.Int32(partNumber),
+				CopySource:        aws.String(source),
+				CopySourceRange:   aws.String(fmt.Sprintf("bytes=%d-%d", offset, end)),
+				CopySourceIfMatch: stringOrNil(etag),
+			})
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("failed to copy part %d: %w", partNumber, err)
+					cancel()
+				})
+				return
+			}
+
+			mu.Lock()
+			parts = append(parts, types.CompletedPart{
+				ETag:       output.CopyPartResult.ETag,
+				PartNumber: aws.Int32(partNumber),
+			})
+			mu.Unlock()
+		}(partNumber, offset)
+		partNumber++
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(parts, func(i, j int) bool {
+		return aws.ToInt32(parts[i].PartNumber) < aws.ToInt32(parts[j].PartNumber)
+	})
+	return parts, nil
+}
+
+// copySource builds the URL-encoded "bucket/key" value expected by the
+// x-amz-copy-source header, keeping the slashes between key segments. Access
+// points take "<ARN>/object/key" instead.
+func copySource(bucket, key string) string {
+	if arn.IsARN(bucket) {
+		return bucket + "/object/" + escapeKey(key)
+	}
+	return bucket + "/" + escapeKey(key)
+}
+
+// versionedCopySource is copySource pinned to versionID, if set.
+func versionedCopySource(bucket, key, versionID string) string {
+	if versionID == "" {
+		return copySource(bucket, key)
+	}
+	return copySource(bucket, key) + "?versionId=" + url.QueryEscape(versionID)
+}
@@ -0,0 +1,101 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// CORSRule lets browsers on AllowedOrigins call the bucket directly, e.g.
+// to upload with a presigned URL or POST policy. Origins and headers may
+// contain one "*" wildcard.
+type CORSRule struct {
+	ID             string
+	AllowedOrigins []string
+	// AllowedMethods are GET, PUT, POST, DELETE and HEAD.
+	AllowedMethods []string
+	// AllowedHeaders are the request headers a preflight may ask for, e.g.
+	// "Content-Type" or "*".
+	AllowedHeaders []string
+	// ExposeHeaders are the response headers scripts may read, e.g. "ETag"
+	// to complete multipart uploads from the browser.
+	ExposeHeaders []string
+	// MaxAge is how long browsers may cache the preflight response.
+	MaxAge time.Duration
+}
+
+// GetCORS returns the bucket CORS rules, or none if the bucket has no CORS
+// configuration.
+func (c *Client) GetCORS(ctx context.Context) ([]CORSRule, error) {
+	output, err := c.client.GetBucketCors(ctx, &s3.GetBucketCorsInput{
+		Bucket: aws.String(c.bucket),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchCORSConfiguration" {
+			return []CORSRule{}, nil
+		}
+		return nil, fmt.Errorf("failed to get bucket CORS: %w", err)
+	}
+	rules := make([]CORSRule, 0, len(output.CORSRules))
+	for _, rule := range output.CORSRules {
+		rules = append(rules, CORSRule{
+			ID:             aws.ToString(rule.ID),
+			AllowedOrigins: rule.AllowedOrigins,
+			AllowedMethods: rule.AllowedMethods,
+			AllowedHeaders: rule.AllowedHeaders,
+			ExposeHeaders:  rule.ExposeHeaders,
+			MaxAge:         time.Duration(aws.ToInt32(rule.MaxAgeSeconds)) * time.Second,
+		})
+	}
+	return rules, nil
+}
+
+// SetCORS replaces the bucket CORS configuration with rules; no rules
+// removes it.
+func (c *Client) SetCORS(ctx context.Context, rules []CORSRule) error {
+	if len(rules) == 0 {
+		return c.DeleteCORS(ctx)
+	}
+	sdkRules := make([]types.CORSRule, 0, len(rules))
+	for _, rule := range rules {
+		if len(rule.AllowedOrigins) == 0 || len(rule.AllowedMethods) == 0 {
+			return errors.New("CORS rule needs allowed origins and methods")
+		}
+		sdkRule := types.CORSRule{
+			ID:             stringOrNil(rule.ID),
+			AllowedOrigins: rule.AllowedOrigins,
+			AllowedMethods: rule.AllowedMethods,
+			AllowedHeaders: rule.AllowedHeaders,
+			ExposeHeaders:  rule.ExposeHeaders,
+		}
+		if rule.MaxAge > 0 {
+			sdkRule.MaxAgeSeconds = aws.Int32(int32(rule.MaxAge / time.Second))
+		}
+		sdkRules = append(sdkRules, sdkRule)
+	}
+	_, err := c.client.PutBucketCors(ctx, &s3.PutBucketCorsInput{
+		Bucket:            aws.String(c.bucket),
+		CORSConfiguration: &types.CORSConfiguration{CORSRules: sdkRules},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set bucket CORS: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) DeleteCORS(ctx context.Context) error {
+	_, err := c.client.DeleteBucketCors(ctx, &s3.DeleteBucketCorsInput{
+		Bucket: aws.String(c.bucket),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete bucket CORS: %w", err)
+	}
+	return nil
+}
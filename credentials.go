@@ -0,0 +1,132 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+const defaultRoleSessionName = "go-s3"
+
+type AssumeRoleConfig struct {
+	RoleARN     string
+	ExternalID  string
+	SessionName string
+	// Duration of the role session. Zero uses the STS default of one hour.
+	Duration time.Duration
+}
+
+// assumeRoleProvider wraps the base credentials of awsCfg in an STS
+// AssumeRole provider. The credentials cache refreshes the session shortly
+// before it expires.
+func assumeRoleProvider(awsCfg aws.Config, cfg *AssumeRoleConfig) aws.CredentialsProvider {
+	provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(awsCfg), cfg.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = cfg.SessionName
+		if o.RoleSessionName == "" {
+			o.RoleSessionName = defaultRoleSessionName
+		}
+		if cfg.ExternalID != "" {
+			o.ExternalID = aws.String(cfg.ExternalID)
+		}
+		if cfg.Duration > 0 {
+			o.Duration = cfg.Duration
+		}
+	})
+	return aws.NewCredentialsCache(provider)
+}
+
+// IdentityTokenFunc returns an OIDC token, e.g. a GitHub Actions ID token
+// fetched at call time.
+type IdentityTokenFunc func() ([]byte, error)
+
+func (f IdentityTokenFunc) GetIdentityToken() ([]byte, error) { return f() }
+
+type WebIdentityConfig struct {
+	RoleARN string
+	// TokenFile is re-read on every refresh, which matches how Kubernetes
+	// rotates projected service account tokens (IRSA).
+	TokenFile string
+	// TokenProvider is used instead of TokenFile when set.
+	TokenProvider IdentityTokenFunc
+	SessionName   string
+	Duration      time.Duration
+}
+
+func webIdentityProvider(awsCfg aws.Config, cfg *WebIdentityConfig) aws.CredentialsProvider {
+	var token stscreds.IdentityTokenRetriever = stscreds.IdentityTokenFile(cfg.TokenFile)
+	if cfg.TokenProvider != nil {
+		token = cfg.TokenProvider
+	}
+	provider := stscreds.NewWebIdentityRoleProvider(sts.NewFromConfig(awsCfg), cfg.RoleARN, token, func(o *stscreds.WebIdentityRoleOptions) {
+		o.RoleSessionName = cfg.SessionName
+		if o.RoleSessionName == "" {
+			o.RoleSessionName = defaultRoleSessionName
+		}
+		if cfg.Duration > 0 {
+			o.Duration = cfg.Duration
+		}
+	})
+	return aws.NewCredentialsCache(provider)
+}
+
+// rotatingCredentials lets SetCredentials swap the provider of a live client
+// without rebuilding the SDK client and its connection pool. Requests
+// already being signed keep the credentials they retrieved.
+type rotatingCredentials struct {
+	mu       sync.RWMutex
+	provider aws.CredentialsProvider
+}
+
+func (r *rotatingCredentials) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	r.mu.RLock()
+	provider := r.provider
+	r.mu.RUnlock()
+	if provider == nil {
+		return aws.Credentials{}, errors.New("S3 credentials not configured")
+	}
+	return provider.Retrieve(ctx)
+}
+
+func (r *rotatingCredentials) set(provider aws.CredentialsProvider) {
+	r.mu.Lock()
+	r.provider = provider
+	r.mu.Unlock()
+}
+
+// cachedCredentials wraps provider in a credentials cache unless it already
+// is one.
+func cachedCredentials(provider aws.CredentialsProvider) aws.CredentialsProvider {
+	if _, ok := provider.(*aws.CredentialsCache); ok {
+		return provider
+	}
+	return aws.NewCredentialsCache(provider)
+}
+
+// SetCredentials replaces the keys of the client, e.g. after the Vault AWS
+// secrets engine issued new ones. Clients derived with ForBucket share the
+// credentials and see the change too. A configured AssumeRole or
+// WebIdentity exchange is replaced as well.
+func (c *Client) SetCredentials(accessKeyID, secretAccessKey, sessionToken string) error {
+	if accessKeyID == "" || secretAccessKey == "" {
+		return errors.New("S3 credentials not configured")
+	}
+	c.credentials.set(credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, sessionToken))
+	return nil
+}
+
+// SetCredentialsProvider replaces the credentials provider of the client,
+// like SetCredentials. The provider is cached and refreshed before its
+// credentials expire.
+func (c *Client) SetCredentialsProvider(provider aws.CredentialsProvider) error {
+	if provider == nil {
+		return errors.New("credentials provider is required")
+	}
+	c.credentials.set(cachedCredentials(provider))
+	return nil
+}
@@ -0,0 +1,142 @@
+package s3
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// dedupRefsTag holds the number of uploads sharing a deduplicated object.
+const dedupRefsTag = "go-s3-refs"
+
+// DedupResult describes an upload made with UploadDeduplicated.
+type DedupResult struct {
+	// Key is the prefix followed by the hex SHA-256 of the content.
+	Key string
+	// Existing is set when identical content was already stored and only
+	// its reference count was incremented.
+	Existing   bool
+	References int
+}
+
+// UploadDeduplicated stores r under a key derived from its SHA-256, so
+// identical content is stored once. Every upload of the same content
+// increments a reference count kept in the object's tags, and
+// ReleaseDeduplicated decrements it. Tags are updated with a read and a
+// write, so counts can drift when the same content is uploaded and released
+// concurrently.
+//
+// The body is hashed before the upload starts: seekable bodies are read
+// twice, others are spooled to a temporary file.
+func (c *Client) UploadDeduplicated(ctx context.Context, prefix string, r io.Reader, opts ...UploadOption) (*DedupResult, error) {
+	body, sum, cleanup, err := hashBody(r)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	key := prefix + hex.EncodeToString(sum)
+	refs, err := c.addReference(ctx, key, 1)
+	if err == nil {
+		return &DedupResult{Key: key, Existing: true, References: refs}, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	// WithIfNoneMatch turns a concurrent upload of the same content into
+	// ErrPreconditionFailed, which is counted as one more reference.
+	opts = append(opts, WithTags(map[string]string{dedupRefsTag: "1"}), WithIfNoneMatch())
+	err = c.UploadLarge(ctx, key, body, opts...)
+	if errors.Is(err, ErrPreconditionFailed) {
+		refs, err := c.addReference(ctx, key, 1)
+		if err != nil {
+			return nil, err
+		}
+		return &DedupResult{Key: key, Existing: true, References: refs}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &DedupResult{Key: key, References: 1}, nil
+}
+
+// ReleaseDeduplicated drops one reference to an object stored with
+// UploadDeduplicated and deletes it once no references are left. It reports
+// whether the object was deleted.
+func (c *Client) ReleaseDeduplicated(ctx context.Context, key string) (bool, error) {
+	refs, err := c.addReference(ctx, key, -1)
+	if err != nil {
+		return false, err
+	}
+	if refs > 0 {
+		return false, nil
+	}
+	if err := c.DeleteFile(ctx, key); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// addReference adds delta to the reference count of key and returns the new
+// count. It returns ErrNotFound when the object does not exist.
+func (c *Client) addReference(ctx context.Context, key string, delta int) (int, error) {
+	tags, err := c.GetTags(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	// Objects uploaded before they were deduplicated count as one reference.
+	refs := 1
+	if value, ok := tags[dedupRefsTag]; ok {
+		if refs, err = strconv.Atoi(value); err != nil {
+			return 0, fmt.Errorf("invalid reference count %q on %s", value, key)
+		}
+	}
+	refs = max(refs+delta, 0)
+	tags[dedupRefsTag] = strconv.Itoa(refs)
+	if err := c.SetTags(ctx, key, tags); err != nil {
+		return 0, err
+	}
+	return refs, nil
+}
+
+// hashBody returns the SHA-256 of r and a reader positioned at the start of
+// the same content.
+func hashBody(r io.Reader) (io.Reader, []byte, func(), error) {
+	hash := sha256.New()
+	if seeker, ok := r.(io.ReadSeeker); ok {
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err == nil {
+			if _, err := io.Copy(hash, seeker); err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to read upload body: %w", err)
+			}
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to rewind upload body: %w", err)
+			}
+			return seeker, hash.Sum(nil), func() {}, nil
+		}
+	}
+
+	spool, err := os.CreateTemp("", "go-s3-dedup-*")
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create spool file: %w", err)
+	}
+	cleanup := func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}
+	if _, err := io.Copy(io.MultiWriter(spool, hash), r); err != nil {
+		cleanup()
+		return nil, nil, nil, fmt.Errorf("failed to read upload body: %w", err)
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, nil, fmt.Errorf("failed to rewind spool file: %w", err)
+	}
+	return spool, hash.Sum(nil), cleanup, nil
+}
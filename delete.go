@@ -0,0 +1,112 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const maxDeleteKeys = 1000
+
+type DeleteError struct {
+	Key     string
+	Code    string
+	Message string
+}
+
+func (e DeleteError) Error() string {
+	return fmt.Sprintf("failed to delete %s: %s: %s", e.Key, e.Code, e.Message)
+}
+
+type DeleteResult struct {
+	Deleted []string
+	Failed  []DeleteError
+}
+
+func (r *DeleteResult) merge(other *DeleteResult) {
+	r.Deleted = append(r.Deleted, other.Deleted...)
+	r.Failed = append(r.Failed, other.Failed...)
+}
+
+// DeleteFiles removes keys with the DeleteObjects batch API, 1000 keys per
+// request. Keys S3 refused to delete are reported in DeleteResult.Failed; the
+// returned error is reserved for failed requests.
+func (c *Client) DeleteFiles(ctx context.Context, keys []string) (*DeleteResult, error) {
+	result := &DeleteResult{}
+	for start := 0; start < len(keys); start += maxDeleteKeys {
+		end := min(start+maxDeleteKeys, len(keys))
+		batch, err := c.deleteBatch(ctx, keys[start:end])
+		if err != nil {
+			return result, err
+		}
+		result.merge(batch)
+	}
+	return result, nil
+}
+
+func (c *Client) DeletePrefix(ctx context.Context, prefix string) (*DeleteResult, error) {
+	result := &DeleteResult{}
+	paginator := s3.NewListObjectsV2Paginator(c.client, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(c.bucket),
+		Prefix:  aws.String(prefix),
+		MaxKeys: aws.Int32(maxDeleteKeys),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return result, fmt.Errorf("failed to list objects: %w", err)
+		}
+		if len(output.Contents) == 0 {
+			continue
+		}
+		keys := make([]string, 0, len(output.Contents))
+		for _, obj := range output.Contents {
+			keys = append(keys, aws.ToString(obj.Key))
+		}
+		batch, err := c.deleteBatch(ctx, keys)
+		if err != nil {
+			return result, err
+		}
+		result.merge(batch)
+	}
+	return result, nil
+}
+
+func (c *Client) deleteBatch(ctx context.Context, keys []string) (*DeleteResult, error) {
+	objects := make([]types.ObjectIdentifier, 0, len(keys))
+	for _, key := range keys {
+		objects = append(objects, types.ObjectIdentifier{Key: aws.String(key)})
+	}
+	return c.deleteObjects(ctx, objects)
+}
+
+// deleteObjects sends a single DeleteObjects request for at most 1000
+// objects, which may name specific versions.
+func (c *Client) deleteObjects(ctx context.Context, objects []types.ObjectIdentifier) (*DeleteResult, error) {
+	output, err := c.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+		Bucket: aws.String(c.bucket),
+		Delete: &types.Delete{
+			Objects: objects,
+			Quiet:   aws.Bool(false),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete files from S3: %w", err)
+	}
+
+	result := &DeleteResult{}
+	for _, deleted := range output.Deleted {
+		result.Deleted = append(result.Deleted, aws.ToString(deleted.Key))
+	}
+	for _, failed := range output.Errors {
+		result.Failed = append(result.Failed, DeleteError{
+			Key:     aws.ToString(failed.Key),
+			Code:    aws.ToString(failed.Code),
+			Message: aws.ToString(failed.Message),
+		})
+	}
+	return result, nil
+}
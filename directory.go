@@ -0,0 +1,287 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type TransferOption func(*transferOptions)
+
+type transferOptions struct {
+	concurrency int
+	include     []string
+	exclude     []string
+	upload      []UploadOption
+	download    []DownloadOption
+}
+
+func newTransferOptions(opts []TransferOption) *transferOptions {
+	o := &transferOptions{concurrency: defaultConcurrency}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func WithTransferConcurrency(n int) TransferOption {
+	return func(o *transferOptions) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// WithInclude limits the transfer to entries matching one of the path.Match
+// patterns. Patterns are matched against the slash-separated path relative
+// to the directory or prefix; patterns without a slash also match the base
+// name, so "*.jpg" selects JPEGs at any depth.
+func WithInclude(patterns ...string) TransferOption {
+	return func(o *transferOptions) { o.include = append(o.include, patterns...) }
+}
+
+// WithExclude skips entries matching one of the patterns, matched like
+// WithInclude. Exclusion wins over inclusion.
+func WithExclude(patterns ...string) TransferOption {
+	return func(o *transferOptions) { o.exclude = append(o.exclude, patterns...) }
+}
+
+// WithTransferUploadOptions applies opts to every upload of
+// UploadDirectory. The content type is guessed from the file extension
+// unless set here.
+func WithTransferUploadOptions(opts ...UploadOption) TransferOption {
+	return func(o *transferOptions) { o.upload = append(o.upload, opts...) }
+}
+
+// WithTransferDownloadOptions applies opts to every download of
+// DownloadPrefix.
+func WithTransferDownloadOptions(opts ...DownloadOption) TransferOption {
+	return func(o *transferOptions) { o.download = append(o.download, opts...) }
+}
+
+func (o *transferOptions) selected(rel string) (bool, error) {
+	matches := func(patterns []string) (bool, error) {
+		for _, pattern := range patterns {
+			name := rel
+			if !strings.Contains(pattern, "/") {
+				name = path.Base(rel)
+			}
+			ok, err := path.Match(pattern, name)
+			if err != nil {
+				return false, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+			if ok {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	if len(o.include) > 0 {
+		ok, err := matches(o.include)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	excluded, err := matches(o.exclude)
+	return !excluded, err
+}
+
+type TransferError struct {
+	// Path is the object key for uploads and the local path for downloads.
+	Path string
+	Err  error
+}
+
+func (e TransferError) Error() string {
+	return fmt.Sprintf("failed to transfer %s: %v", e.Path, e.Err)
+}
+
+func (e TransferError) Unwrap() error { return e.Err }
+
+type TransferResult struct {
+	// Transferred lists the uploaded keys or downloaded paths.
+	Transferred []string
+	// Skipped lists entries left out by the include and exclude patterns.
+	Skipped []string
+	Failed  []TransferError
+	// Bytes is the total size of the transferred entries.
+	Bytes int64
+}
+
+type transferJob struct {
+	path string
+	key  string
+	size int64
+}
+
+// UploadDirectory uploads the regular files under localDir to prefix,
+// keeping their relative paths. A prefix without a trailing "/" gets one.
+// Unlike Sync it uploads every selected file and never deletes objects.
+func (c *Client) UploadDirectory(ctx context.Context, localDir, prefix string, opts ...TransferOption) (*TransferResult, error) {
+	o := newTransferOptions(opts)
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	result := &TransferResult{}
+	var jobs []transferJob
+	err := filepath.WalkDir(localDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(localDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		key := prefix + rel
+		ok, err := o.selected(rel)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			result.Skipped = append(result.Skipped, key)
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		jobs = append(jobs, transferJob{path: p, key: key, size: info.Size()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", localDir, err)
+	}
+
+	c.runTransfers(ctx, jobs, o, result, func(job transferJob) (string, error) {
+		return job.key, c.uploadTransferFile(ctx, job, o)
+	})
+	return result, ctx.Err()
+}
+
+func (c *Client) uploadTransferFile(ctx context.Context, job transferJob, o *transferOptions) error {
+	f, err := os.Open(job.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var opts []UploadOption
+	if contentType := mime.TypeByExtension(path.Ext(job.key)); contentType != "" {
+		opts = append(opts, WithContentType(contentType))
+	}
+	return c.UploadLarge(ctx, job.key, f, append(opts, o.upload...)...)
+}
+
+// DownloadPrefix downloads the objects under prefix into localDir, creating
+// subdirectories from the "/" in their keys. As in UploadDirectory, prefix
+// names a directory, so "photos" does not match "photos-old/". Existing
+// files are overwritten.
+// Directory markers are skipped, and keys that would resolve outside
+// localDir fail.
+func (c *Client) DownloadPrefix(ctx context.Context, prefix, localDir string, opts ...TransferOption) (*TransferResult, error) {
+	o := newTransferOptions(opts)
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	result := &TransferResult{}
+	var jobs []transferJob
+	err := c.ListAll(ctx, prefix, func(obj ObjectInfo) error {
+		rel := strings.TrimPrefix(obj.Key[len(prefix):], "/")
+		if rel == "" || strings.HasSuffix(rel, "/") {
+			return nil
+		}
+		target := filepath.Join(localDir, filepath.FromSlash(rel))
+		ok, err := o.selected(rel)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			result.Skipped = append(result.Skipped, target)
+			return nil
+		}
+		if !filepath.IsLocal(filepath.FromSlash(rel)) {
+			result.Failed = append(result.Failed, TransferError{Path: obj.Key, Err: errors.New("key resolves outside the target directory")})
+			return nil
+		}
+		jobs = append(jobs, transferJob{path: target, key: obj.Key, size: obj.Size})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.runTransfers(ctx, jobs, o, result, func(job transferJob) (string, error) {
+		return job.path, c.downloadTransferFile(ctx, job, o)
+	})
+	return result, ctx.Err()
+}
+
+func (c *Client) downloadTransferFile(ctx context.Context, job transferJob, o *transferOptions) error {
+	if err := os.MkdirAll(filepath.Dir(job.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(job.path)
+	if err != nil {
+		return err
+	}
+	_, err = c.DownloadLarge(ctx, job.key, f, o.download...)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(job.path)
+	}
+	return err
+}
+
+// runTransfers runs fn for every job with o.concurrency workers and records
+// the name it returns in result.
+func (c *Client) runTransfers(ctx context.Context, jobs []transferJob, o *transferOptions, result *TransferResult, fn func(transferJob) (string, error)) {
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	queue := make(chan transferJob)
+	for i := 0; i < o.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				name, err := fn(job)
+
+				mu.Lock()
+				if err != nil {
+					result.Failed = append(result.Failed, TransferError{Path: name, Err: err})
+				} else {
+					result.Transferred = append(result.Transferred, name)
+					result.Bytes += job.size
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, job := range jobs {
+		select {
+		case queue <- job:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+	sort.Strings(result.Transferred)
+}
@@ -0,0 +1,300 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+	StorageClass string
+	// URL is a presigned download URL, set only when requested.
+	URL string
+
+	// The fields below are only populated by calls that return object
+	// headers (DownloadFile, GetObjectInfo), not by listings.
+	CacheControl       string
+	ContentDisposition string
+	ContentEncoding    string
+	Metadata           map[string]string
+
+	// ServerSideEncryption is "AES256", "aws:kms" or empty; KMSKeyID is set
+	// for aws:kms objects.
+	ServerSideEncryption string
+	KMSKeyID             string
+	// VersionID is set for objects in versioned buckets.
+	VersionID string
+	// ObjectLockMode and RetainUntil describe the object's retention;
+	// LegalHold is set while it is under a legal hold.
+	ObjectLockMode string
+	RetainUntil    time.Time
+	LegalHold      bool
+	// RequestCharged reports that the request was billed to this account
+	// by a requester-pays bucket.
+	RequestCharged bool
+}
+
+func (c *Client) DownloadFile(ctx context.Context, key string, opts ...DownloadOption) (io.ReadCloser, *ObjectInfo, error) {
+	o := newDownloadOptions(opts)
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	}
+	o.applyGet(input)
+	var optFns []func(*s3.Options)
+	if o.validateChecksum {
+		input.ChecksumMode = types.ChecksumModeEnabled
+		optFns = append(optFns, s3.WithAPIOptions(withoutSDKChecksumValidation))
+	}
+	output, err := c.client.GetObject(ctx, input, optFns...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download file from S3: %w", err)
+	}
+	body := output.Body
+	if o.validateChecksum {
+		body = verifyChecksum(key, output, body)
+	}
+	progress := newProgressTracker(o.progress, aws.ToInt64(output.ContentLength))
+	body = progress.readCloser(body)
+	if o.decompress {
+		if body, err = decompress(body, aws.ToString(output.ContentEncoding)); err != nil {
+			return nil, nil, err
+		}
+	}
+	return body, objectInfoFromGet(key, output), nil
+}
+
+// DownloadRange reads length bytes starting at offset. A non-positive length
+// reads until the end of the object.
+func (c *Client) DownloadRange(ctx context.Context, key string, offset, length int64, opts ...DownloadOption) (io.ReadCloser, *ObjectInfo, error) {
+	if offset < 0 {
+		return nil, nil, errors.New("offset must not be negative")
+	}
+	o := newDownloadOptions(opts)
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+		Range:  aws.String(byteRange(offset, length)),
+	}
+	o.applyGet(input)
+	output, err := c.client.GetObject(ctx, input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download range from S3: %w", err)
+	}
+	progress := newProgressTracker(o.progress, aws.ToInt64(output.ContentLength))
+	return progress.readCloser(output.Body), objectInfoFromGet(key, output), nil
+}
+
+// DownloadCheckpoint tracks the progress of a resumable download. Passing the
+// same checkpoint to a subsequent DownloadResumable call continues from Offset
+// and fails if the object has changed since the first attempt.
+type DownloadCheckpoint struct {
+	ETag   string
+	Size   int64
+	Offset int64
+}
+
+// DownloadResumable downloads key into w from cp.Offset. The version and
+// conditions in opts apply to every attempt; a WithDownloadIfMatch ETag
+// other than the one in the checkpoint fails with ErrPreconditionFailed.
+func (c *Client) DownloadResumable(ctx context.Context, key string, w io.WriterAt, cp *DownloadCheckpoint, opts ...DownloadOption) error {
+	if cp == nil {
+		return errors.New("download checkpoint is required")
+	}
+	if cp.ETag != "" && cp.Offset >= cp.Size {
+		return nil
+	}
+	o := newDownloadOptions(opts)
+	if o.ifMatch != "" && cp.ETag != "" && o.ifMatch != cp.ETag {
+		return fmt.Errorf("%w: If-Match %s, checkpoint ETag %s", ErrPreconditionFailed, o.ifMatch, cp.ETag)
+	}
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	}
+	o.applyGet(input)
+	if cp.Offset > 0 {
+		input.Range = aws.String(byteRange(cp.Offset, 0))
+	}
+	if cp.ETag != "" {
+		input.IfMatch = aws.String(cp.ETag)
+	}
+
+	output, err := c.client.GetObject(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to download file from S3: %w", err)
+	}
+	defer output.Body.Close()
+
+	etag := aws.ToString(output.ETag)
+	if cp.ETag == "" {
+		cp.ETag = etag
+		cp.Size = objectSize(output)
+	} else if etag != cp.ETag {
+		return fmt.Errorf("object %q changed during download: etag %s, expected %s", key, etag, cp.ETag)
+	}
+
+	progress := newProgressTracker(o.progress, cp.Size)
+	progress.add(cp.Offset)
+	n, err := io.Copy(io.NewOffsetWriter(w, cp.Offset), progress.reader(output.Body))
+	cp.Offset += n
+	progress.finish()
+	if err != nil {
+		return fmt.Errorf("failed to read object body: %w", err)
+	}
+	if cp.Offset < cp.Size {
+		return fmt.Errorf("download of %q stopped at %d of %d bytes: %w", key, cp.Offset, cp.Size, io.ErrUnexpectedEOF)
+	}
+	return nil
+}
+
+func byteRange(offset, length int64) string {
+	if length <= 0 {
+		return fmt.Sprintf("bytes=%d-", offset)
+	}
+	return fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+}
+
+func objectInfoFromGet(key string, output *s3.GetObjectOutput) *ObjectInfo {
+	return &ObjectInfo{
+		Key:          key,
+		Size:         objectSize(output),
+		ContentType:  aws.ToString(output.ContentType),
+		ETag:         aws.ToString(output.ETag),
+		LastModified: aws.ToTime(output.LastModified),
+		StorageClass: string(output.StorageClass),
+
+		CacheControl:       aws.ToString(output.CacheControl),
+		ContentDisposition: aws.ToString(output.ContentDisposition),
+		ContentEncoding:    aws.ToString(output.ContentEncoding),
+		Metadata:           output.Metadata,
+
+		ServerSideEncryption: string(output.ServerSideEncryption),
+		KMSKeyID:             aws.ToString(output.SSEKMSKeyId),
+		VersionID:            aws.ToString(output.VersionId),
+
+		ObjectLockMode: string(output.ObjectLockMode),
+		RetainUntil:    aws.ToTime(output.ObjectLockRetainUntilDate),
+		LegalHold:      output.ObjectLockLegalHoldStatus == types.ObjectLockLegalHoldStatusOn,
+		RequestCharged: output.RequestCharged == types.RequestChargedRequester,
+	}
+}
+
+// objectSize returns the full object size, which for ranged responses is only
+// available from the Content-Range header.
+func objectSize(output *s3.GetObjectOutput) int64 {
+	if cr := aws.ToString(output.ContentRange); cr != "" {
+		if i := strings.LastIndexByte(cr, '/'); i >= 0 {
+			if size, err := strconv.ParseInt(cr[i+1:], 10, 64); err == nil {
+				return size
+			}
+		}
+	}
+	return aws.ToInt64(output.ContentLength)
+}
+
+// DownloadLarge fetches the object in concurrent ranged GETs and writes each
+// part at its offset in w. Every part is pinned to the ETag seen at the start
+// so a concurrent overwrite cannot produce a mixed file.
+func (c *Client) DownloadLarge(ctx context.Context, key string, w io.WriterAt, opts ...DownloadOption) (int64, error) {
+	o := newDownloadOptions(opts)
+
+	headInput := &s3.HeadObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	}
+	o.applyHead(headInput)
+	head, err := c.client.HeadObject(ctx, headInput)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get object info: %w", err)
+	}
+	size := aws.ToInt64(head.ContentLength)
+	etag := aws.ToString(head.ETag)
+	versionID := aws.ToString(head.VersionId)
+	if size == 0 {
+		return 0, nil
+	}
+
+	progress := newProgressTracker(o.progress, size)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	offsets := make(chan int64)
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	workers := o.concurrency
+	if parts := int((size + o.partSize - 1) / o.partSize); parts < workers {
+		workers = parts
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for offset := range offsets {
+				if err := c.downloadPart(ctx, key, etag, versionID, w, offset, o.partSize, progress); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for offset := int64(0); offset < size; offset += o.partSize {
+		select {
+		case offsets <- offset:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(offsets)
+	wg.Wait()
+
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	progress.finish()
+	return size, nil
+}
+
+func (c *Client) downloadPart(ctx context.Context, key, etag, versionID string, w io.WriterAt, offset, length int64, progress *progressTracker) error {
+	output, err := c.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:    aws.String(c.bucket),
+		Key:       aws.String(key),
+		Range:     aws.String(byteRange(offset, length)),
+		IfMatch:   stringOrNil(etag),
+		VersionId: stringOrNil(versionID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to download part at offset %d: %w", offset, err)
+	}
+	defer output.Body.Close()
+
+	if _, err := io.Copy(io.NewOffsetWriter(w, offset), progress.reader(output.Body)); err != nil {
+		return fmt.Errorf("failed to read part at offset %d: %w", offset, err)
+	}
+	return nil
+}
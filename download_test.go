@@ -0,0 +1,44 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type bufferAt []byte
+
+func (b bufferAt) WriteAt(p []byte, off int64) (int, error) { return copy(b[off:], p), nil }
+
+func TestDownloadResumableConditions(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryClient("bucket")
+	putMemory(t, m, "a.txt", "hello")
+	info, err := m.GetObjectInfo(ctx, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cp := &DownloadCheckpoint{}
+	err = m.DownloadResumable(ctx, "a.txt", make(bufferAt, 5), cp, WithDownloadIfModifiedSince(time.Now().Add(time.Hour)))
+	if err == nil {
+		t.Fatal("If-Modified-Since in the future: want an error")
+	}
+	if cp.ETag != "" {
+		t.Fatalf("checkpoint ETag = %q after a failed condition, want empty", cp.ETag)
+	}
+
+	cp = &DownloadCheckpoint{ETag: info.ETag, Size: 5, Offset: 2}
+	if err := m.DownloadResumable(ctx, "a.txt", make(bufferAt, 5), cp, WithDownloadIfMatch(`"other"`)); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("conflicting If-Match: err = %v, want ErrPreconditionFailed", err)
+	}
+
+	buf := make(bufferAt, 5)
+	if err := m.DownloadResumable(ctx, "a.txt", buf, cp, WithDownloadIfMatch(info.ETag)); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf[2:]) != "llo" || cp.Offset != 5 {
+		t.Fatalf("resumed %q up to %d, want %q up to 5", buf[2:], cp.Offset, "llo")
+	}
+}
@@ -0,0 +1,60 @@
+package s3
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// WithSSES3 encrypts the object with S3-managed keys (AES256).
+func WithSSES3() UploadOption {
+	return func(o *uploadOptions) {
+		o.sse = types.ServerSideEncryptionAes256
+		o.kmsKeyID = ""
+	}
+}
+
+// WithSSEKMS encrypts the object with aws:kms. An empty keyID uses the AWS
+// managed key for S3.
+func WithSSEKMS(keyID string) UploadOption {
+	return func(o *uploadOptions) {
+		o.sse = types.ServerSideEncryptionAwsKms
+		o.kmsKeyID = keyID
+	}
+}
+
+// newUploadOptions resolves opts on top of the client's encryption defaults.
+func (c *Client) newUploadOptions(opts []UploadOption) *uploadOptions {
+	o := newUploadOptions(nil)
+	o.sse = c.sse
+	o.kmsKeyID = c.kmsKeyID
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// copyEncryption picks the encryption of a copy destination: the client
+// default when configured, otherwise that of the source, since CopyObject
+// would fall back to the bucket default instead of keeping it.
+func (c *Client) copyEncryption(head *s3.HeadObjectOutput) (types.ServerSideEncryption, *string) {
+	if c.sse != "" {
+		return c.sse, stringOrNil(c.kmsKeyID)
+	}
+	if head.ServerSideEncryption == types.ServerSideEncryptionAwsKms {
+		return head.ServerSideEncryption, head.SSEKMSKeyId
+	}
+	return head.ServerSideEncryption, nil
+}
+
+// encryptionFields returns the POST policy fields enforcing the client's
+// default encryption.
+func (c *Client) encryptionFields() map[string]string {
+	if c.sse == "" {
+		return nil
+	}
+	fields := map[string]string{"x-amz-server-side-encryption": string(c.sse)}
+	if c.kmsKeyID != "" {
+		fields["x-amz-server-side-encryption-aws-kms-key-id"] = c.kmsKeyID
+	}
+	return fields
+}
@@ -0,0 +1,307 @@
+package s3
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Object metadata written by EncryptedClient.
+const (
+	cseMetaAlgorithm = "cse-alg"
+	cseMetaKey       = "cse-key"
+	cseMetaNonce     = "cse-nonce"
+
+	cseAlgorithm = "AES256-GCM-64K"
+	cseChunkSize = 64 * 1024
+	cseNonceSize = 12
+	cseKeySize   = 32
+)
+
+// ErrNotEncrypted is returned by EncryptedClient.Download for objects that
+// were not uploaded through an EncryptedClient.
+var ErrNotEncrypted = errors.New("object is not client-side encrypted")
+
+// KeyProvider issues and unwraps the per-object data keys of an
+// EncryptedClient. Wrapped keys are stored in object metadata, so they must
+// be safe to disclose to anyone with read access to the bucket.
+type KeyProvider interface {
+	// GenerateDataKey returns a new 256-bit key and its wrapped form.
+	GenerateDataKey(ctx context.Context) (plaintext, wrapped []byte, err error)
+	// DecryptDataKey unwraps a key returned by GenerateDataKey.
+	DecryptDataKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// EncryptedClient encrypts objects with AES-GCM before they leave the
+// process and decrypts them on download. Every object gets its own data key,
+// wrapped by the KeyProvider and kept in the object's metadata.
+//
+// The payload is sealed in 64 KiB chunks so large objects stream in both
+// directions; the chunk index and a final-chunk flag are part of each nonce,
+// which makes reordered or truncated ciphertext fail to decrypt.
+type EncryptedClient struct {
+	client S3Client
+	keys   KeyProvider
+}
+
+func NewEncryptedClient(client S3Client, keys KeyProvider) *EncryptedClient {
+	return &EncryptedClient{client: client, keys: keys}
+}
+
+// Upload encrypts r and stores it at key. Content type, metadata and the
+// other upload options apply to the stored object as usual.
+func (e *EncryptedClient) Upload(ctx context.Context, key string, r io.Reader, opts ...UploadOption) error {
+	plaintext, wrapped, err := e.keys.GenerateDataKey(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to generate data key: %w", err)
+	}
+	aead, err := newChunkAEAD(plaintext)
+	if err != nil {
+		return err
+	}
+	prefix := make([]byte, cseNonceSize-5)
+	if _, err := rand.Read(prefix); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	opts = append(opts, WithMetadata(map[string]string{
+		cseMetaAlgorithm: cseAlgorithm,
+		cseMetaKey:       base64.StdEncoding.EncodeToString(wrapped),
+		cseMetaNonce:     base64.StdEncoding.EncodeToString(prefix),
+	}))
+	return e.client.UploadLarge(ctx, key, &encryptReader{
+		src:    bufio.NewReaderSize(r, cseChunkSize),
+		aead:   aead,
+		prefix: prefix,
+	}, opts...)
+}
+
+// Download returns the decrypted body of key. ObjectInfo.Size is the
+// plaintext size and the encryption metadata is removed from Metadata.
+// Authentication failures surface as read errors on the body.
+func (e *EncryptedClient) Download(ctx context.Context, key string, opts ...DownloadOption) (io.ReadCloser, *ObjectInfo, error) {
+	body, info, err := e.client.DownloadFile(ctx, key, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	meta := info.Metadata
+	if meta[cseMetaAlgorithm] != cseAlgorithm {
+		body.Close()
+		return nil, nil, fmt.Errorf("%w: %s", ErrNotEncrypted, key)
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(meta[cseMetaKey])
+	if err != nil {
+		body.Close()
+		return nil, nil, fmt.Errorf("invalid wrapped data key: %w", err)
+	}
+	prefix, err := base64.StdEncoding.DecodeString(meta[cseMetaNonce])
+	if err != nil || len(prefix) != cseNonceSize-5 {
+		body.Close()
+		return nil, nil, errors.New("invalid encryption nonce")
+	}
+	plaintext, err := e.keys.DecryptDataKey(ctx, wrapped)
+	if err != nil {
+		body.Close()
+		return nil, nil, fmt.Errorf("failed to decrypt data key: %w", err)
+	}
+	aead, err := newChunkAEAD(plaintext)
+	if err != nil {
+		body.Close()
+		return nil, nil, err
+	}
+
+	info.Size = plaintextSize(info.Size, aead.Overhead())
+	info.Metadata = make(map[string]string, len(meta))
+	for k, v := range meta {
+		switch k {
+		case cseMetaAlgorithm, cseMetaKey, cseMetaNonce:
+		default:
+			info.Metadata[k] = v
+		}
+	}
+	return &decryptReader{
+		src:    bufio.NewReaderSize(body, cseChunkSize+aead.Overhead()),
+		closer: body,
+		aead:   aead,
+		prefix: prefix,
+	}, info, nil
+}
+
+func newChunkAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != cseKeySize {
+		return nil, fmt.Errorf("data key must be %d bytes, got %d", cseKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce is prefix || big-endian chunk index || final flag.
+func chunkNonce(prefix []byte, index uint32, final bool) []byte {
+	nonce := make([]byte, cseNonceSize)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[len(prefix):], index)
+	if final {
+		nonce[cseNonceSize-1] = 1
+	}
+	return nonce
+}
+
+func plaintextSize(size int64, overhead int) int64 {
+	sealed := int64(cseChunkSize + overhead)
+	chunks := (size + sealed - 1) / sealed
+	if chunks == 0 {
+		chunks = 1
+	}
+	return size - chunks*int64(overhead)
+}
+
+type encryptReader struct {
+	src    *bufio.Reader
+	aead   cipher.AEAD
+	prefix []byte
+	index  uint32
+	buf    []byte
+	chunk  []byte
+	out    []byte
+	done   bool
+}
+
+func (r *encryptReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.seal(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+func (r *encryptReader) seal() error {
+	if r.buf == nil {
+		r.buf = make([]byte, cseChunkSize)
+	}
+	n, err := io.ReadFull(r.src, r.buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+	// The chunk is final when the source is exhausted; peek to find out so
+	// an input of exactly N chunks doesn't need a trailing empty one.
+	final := err != nil
+	if !final {
+		if _, err := r.src.Peek(1); err == io.EOF {
+			final = true
+		} else if err != nil {
+			return err
+		}
+	}
+	r.chunk = r.aead.Seal(r.chunk[:0], chunkNonce(r.prefix, r.index, final), r.buf[:n], nil)
+	r.out = r.chunk
+	r.index++
+	r.done = final
+	return nil
+}
+
+type decryptReader struct {
+	src    *bufio.Reader
+	closer io.Closer
+	aead   cipher.AEAD
+	prefix []byte
+	index  uint32
+	buf    []byte
+	chunk  []byte
+	out    []byte
+	done   bool
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+func (r *decryptReader) open() error {
+	if r.buf == nil {
+		r.buf = make([]byte, cseChunkSize+r.aead.Overhead())
+	}
+	n, err := io.ReadFull(r.src, r.buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+	final := err != nil
+	if !final {
+		if _, err := r.src.Peek(1); err == io.EOF {
+			final = true
+		} else if err != nil {
+			return err
+		}
+	}
+	chunk, err := r.aead.Open(r.chunk[:0], chunkNonce(r.prefix, r.index, final), r.buf[:n], nil)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt chunk %d: %w", r.index, err)
+	}
+	r.chunk = chunk
+	r.out = chunk
+	r.index++
+	r.done = final
+	return nil
+}
+
+func (r *decryptReader) Close() error {
+	return r.closer.Close()
+}
+
+// StaticKeyProvider wraps data keys with a fixed 256-bit master key using
+// AES-GCM. Rotating the master key requires re-encrypting existing objects.
+type StaticKeyProvider struct {
+	aead cipher.AEAD
+}
+
+func NewStaticKeyProvider(masterKey []byte) (*StaticKeyProvider, error) {
+	aead, err := newChunkAEAD(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	return &StaticKeyProvider{aead: aead}, nil
+}
+
+func (p *StaticKeyProvider) GenerateDataKey(ctx context.Context) ([]byte, []byte, error) {
+	key := make([]byte, cseKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	return key, p.aead.Seal(nonce, nonce, key, nil), nil
+}
+
+func (p *StaticKeyProvider) DecryptDataKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < p.aead.NonceSize() {
+		return nil, errors.New("wrapped key is too short")
+	}
+	nonce, sealed := wrapped[:p.aead.NonceSize()], wrapped[p.aead.NonceSize():]
+	return p.aead.Open(nil, nonce, sealed, nil)
+}
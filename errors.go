@@ -0,0 +1,143 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// Errors returned by S3 requests can be matched with errors.Is against the
+// values below; errors.As still reaches the underlying SDK error types.
+var (
+	ErrNotFound           = errors.New("object not found")
+	ErrBucketNotFound     = errors.New("bucket not found")
+	ErrAccessDenied       = errors.New("access denied")
+	ErrThrottled          = errors.New("request throttled")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrNotModified        = errors.New("not modified")
+	// ErrAlreadyExists is returned by UploadIfAbsent when the key is taken.
+	ErrAlreadyExists = errors.New("object already exists")
+)
+
+// apiError attaches one of the sentinel errors to an SDK error.
+type apiError struct {
+	err  error
+	kind error
+}
+
+func (e *apiError) Error() string   { return e.err.Error() }
+func (e *apiError) Unwrap() []error { return []error{e.err, e.kind} }
+
+// wrapAPIError returns err with its sentinel attached, or err itself when it
+// does not map to one.
+func wrapAPIError(err error) error {
+	if kind := classifyError(err); kind != nil {
+		return &apiError{err: err, kind: kind}
+	}
+	return err
+}
+
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NoSuchBucket":
+			return ErrBucketNotFound
+		case "AccessDenied", "AllAccessDisabled", "InvalidAccessKeyId", "SignatureDoesNotMatch", "ExpiredToken", "InvalidToken":
+			return ErrAccessDenied
+		case "SlowDown", "Throttling", "ThrottlingException", "RequestLimitExceeded", "TooManyRequestsException":
+			return ErrThrottled
+		case "PreconditionFailed":
+			return ErrPreconditionFailed
+		case "NotModified":
+			return ErrNotModified
+		}
+	}
+	if isNotFound(err) {
+		return ErrNotFound
+	}
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) {
+		switch respErr.HTTPStatusCode() {
+		case http.StatusForbidden:
+			return ErrAccessDenied
+		case http.StatusTooManyRequests:
+			return ErrThrottled
+		case http.StatusPreconditionFailed:
+			return ErrPreconditionFailed
+		case http.StatusNotModified:
+			return ErrNotModified
+		}
+	}
+	return nil
+}
+
+// isNotFound reports whether err means the object does not exist. HeadObject
+// responses carry no body, so S3 reports them as a bare 404 "NotFound" rather
+// than NoSuchKey.
+func isNotFound(err error) bool {
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return true
+	}
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return true
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NoSuchKey", "NotFound":
+			return true
+		}
+	}
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotFound {
+		var apiErr smithy.APIError
+		return !errors.As(err, &apiErr) || apiErr.ErrorCode() != "NoSuchBucket"
+	}
+	return false
+}
+
+// addErrorMapping attaches the sentinel errors to every failed request.
+func addErrorMapping(stack *middleware.Stack) error {
+	return stack.Initialize.Add(errorMappingMiddleware{}, middleware.Before)
+}
+
+type errorMappingMiddleware struct{}
+
+func (errorMappingMiddleware) ID() string { return "go-s3.ErrorMapping" }
+
+func (errorMappingMiddleware) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	out, metadata, err := next.HandleInitialize(ctx, in)
+	if err != nil {
+		err = wrapAPIError(err)
+	}
+	return out, metadata, err
+}
+
+// isEndpointFailure reports whether err means the endpoint itself is failing
+// (unreachable, 5xx, throttling, circuit open) rather than rejecting this one
+// request.
+func isEndpointFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, ErrThrottled) || errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var sendErr *smithyhttp.RequestSendError
+	if errors.As(err, &sendErr) {
+		return true
+	}
+	var respErr *smithyhttp.ResponseError
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() >= http.StatusInternalServerError
+}
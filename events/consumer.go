@@ -0,0 +1,231 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultConcurrency       = 4
+	defaultVisibilityTimeout = 30 * time.Second
+	defaultWaitTime          = 20 * time.Second
+	maxReceiveMessages       = 10
+	receiveRetryDelay        = time.Second
+	deleteTimeout            = 10 * time.Second
+)
+
+// Message is a message received from the queue.
+type Message struct {
+	ID            string
+	ReceiptHandle string
+	Body          string
+}
+
+// Queue is the subset of SQS the consumer needs. Adapting *sqs.Client takes
+// a few lines, see the README.
+type Queue interface {
+	// Receive long-polls for up to max messages for at most wait and hides
+	// them for visibility.
+	Receive(ctx context.Context, max int, wait, visibility time.Duration) ([]Message, error)
+	Delete(ctx context.Context, receiptHandle string) error
+	// ExtendVisibility hides a message for another timeout from now.
+	ExtendVisibility(ctx context.Context, receiptHandle string, timeout time.Duration) error
+}
+
+// Handler processes one event. Returning an error leaves the message on the
+// queue, so the event is redelivered after the visibility timeout and
+// handlers must be idempotent.
+type Handler func(ctx context.Context, event Event) error
+
+type options struct {
+	concurrency       int
+	visibilityTimeout time.Duration
+	waitTime          time.Duration
+	logger            *slog.Logger
+}
+
+type Option func(*options)
+
+// WithConcurrency sets how many messages are processed at once, 4 by
+// default.
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// WithVisibilityTimeout sets how long a received message stays hidden from
+// other consumers, 30 seconds by default. It is extended while a handler
+// runs, so it only bounds how fast a crashed consumer's messages reappear.
+func WithVisibilityTimeout(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.visibilityTimeout = d
+		}
+	}
+}
+
+// WithWaitTime sets the long-polling wait of each receive, 20 seconds (the
+// SQS maximum) by default.
+func WithWaitTime(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.waitTime = d
+		}
+	}
+}
+
+// WithLogger sets the logger for failed receives and handlers, defaulting to
+// slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		if logger != nil {
+			o.logger = logger
+		}
+	}
+}
+
+type route struct {
+	prefix  string
+	handler Handler
+}
+
+// Consumer delivers the events of a queue to handlers with at-least-once
+// semantics: a message is deleted only after every matching handler
+// succeeded for every event in it.
+type Consumer struct {
+	queue  Queue
+	routes []route
+	o      *options
+}
+
+func NewConsumer(queue Queue, opts ...Option) *Consumer {
+	o := &options{
+		concurrency:       defaultConcurrency,
+		visibilityTimeout: defaultVisibilityTimeout,
+		waitTime:          defaultWaitTime,
+		logger:            slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.logger = o.logger.With(slog.String("component", "go-s3/events"))
+	return &Consumer{queue: queue, o: o}
+}
+
+// Handle registers h for events whose name starts with prefix, e.g.
+// "ObjectCreated:" or "ObjectRemoved:Delete"; an empty prefix matches every
+// event. Register handlers before calling Run.
+func (c *Consumer) Handle(prefix string, h Handler) {
+	c.routes = append(c.routes, route{prefix: strings.TrimPrefix(prefix, "s3:"), handler: h})
+}
+
+// Run receives and processes messages until ctx is cancelled, then waits for
+// the handlers in flight and returns ctx.Err(). Receive errors are logged and
+// retried.
+func (c *Consumer) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	slots := make(chan struct{}, c.o.concurrency)
+
+	for {
+		messages, err := c.queue.Receive(ctx, min(c.o.concurrency, maxReceiveMessages), c.o.waitTime, c.o.visibilityTimeout)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			c.o.logger.ErrorContext(ctx, "failed to receive messages", slog.Any("error", err))
+			select {
+			case <-time.After(receiveRetryDelay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			continue
+		}
+
+		for _, message := range messages {
+			// Messages not started here become visible again after the
+			// visibility timeout.
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			wg.Add(1)
+			go func(message Message) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				c.process(ctx, message)
+			}(message)
+		}
+	}
+}
+
+func (c *Consumer) process(ctx context.Context, message Message) {
+	logger := c.o.logger.With(slog.String("message_id", message.ID))
+	events, err := Parse([]byte(message.Body))
+	if err != nil {
+		// Left on the queue for its redrive policy to move it to a
+		// dead-letter queue.
+		logger.ErrorContext(ctx, "failed to parse message", slog.Any("error", err))
+		return
+	}
+
+	stop := c.keepInvisible(ctx, message, logger)
+	for _, event := range events {
+		for _, r := range c.routes {
+			if !strings.HasPrefix(event.Name, r.prefix) {
+				continue
+			}
+			if err := r.handler(ctx, event); err != nil {
+				stop()
+				logger.ErrorContext(ctx, "event handler failed",
+					slog.String("event", event.Name),
+					slog.String("key", event.Key),
+					slog.Any("error", err),
+				)
+				return
+			}
+		}
+	}
+	stop()
+
+	// A fresh context: once handled, the message must be deleted even if
+	// ctx was cancelled meanwhile.
+	deleteCtx, cancel := context.WithTimeout(context.Background(), deleteTimeout)
+	defer cancel()
+	if err := c.queue.Delete(deleteCtx, message.ReceiptHandle); err != nil {
+		logger.ErrorContext(ctx, "failed to delete message", slog.Any("error", err))
+	}
+}
+
+// keepInvisible extends the visibility timeout of message at half its
+// length until the returned function is called.
+func (c *Consumer) keepInvisible(ctx context.Context, message Message, logger *slog.Logger) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(c.o.visibilityTimeout / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.queue.ExtendVisibility(ctx, message.ReceiptHandle, c.o.visibilityTimeout); err != nil && ctx.Err() == nil {
+					logger.WarnContext(ctx, "failed to extend visibility timeout", slog.Any("error", err))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
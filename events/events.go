@@ -0,0 +1,100 @@
+// Package events consumes S3 event notifications (ObjectCreated,
+// ObjectRemoved, ...) delivered to a queue, either directly or through an
+// SNS topic, and hands them to registered handlers.
+//
+//	consumer := events.NewConsumer(queue)
+//	consumer.Handle("ObjectCreated:", func(ctx context.Context, e events.Event) error {
+//		return thumbnails.Generate(ctx, e.Key)
+//	})
+//	err := consumer.Run(ctx)
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Event is a single record of an S3 event notification.
+type Event struct {
+	// Name is the event type without the "s3:" prefix, e.g.
+	// "ObjectCreated:Put" or "ObjectRemoved:DeleteMarkerCreated".
+	Name   string
+	Time   time.Time
+	Region string
+	Bucket string
+	// Key is URL-decoded.
+	Key       string
+	Size      int64
+	ETag      string
+	VersionID string
+	// Sequencer orders events for the same key; compare equal-length values
+	// as strings.
+	Sequencer string
+}
+
+func (e Event) IsCreated() bool { return strings.HasPrefix(e.Name, "ObjectCreated:") }
+func (e Event) IsRemoved() bool { return strings.HasPrefix(e.Name, "ObjectRemoved:") }
+
+type notification struct {
+	Records []struct {
+		EventName string    `json:"eventName"`
+		EventTime time.Time `json:"eventTime"`
+		AWSRegion string    `json:"awsRegion"`
+		S3        struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key       string `json:"key"`
+				Size      int64  `json:"size"`
+				ETag      string `json:"eTag"`
+				VersionID string `json:"versionId"`
+				Sequencer string `json:"sequencer"`
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+	// Set for the s3:TestEvent sent when notifications are configured.
+	Event string `json:"Event"`
+	// Set when the notification was delivered through SNS.
+	Type    string `json:"Type"`
+	Message string `json:"Message"`
+}
+
+// Parse decodes the body of a queue message into its events. SNS envelopes
+// are unwrapped, and the s3:TestEvent yields no events.
+func Parse(body []byte) ([]Event, error) {
+	var n notification
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, fmt.Errorf("failed to parse S3 event: %w", err)
+	}
+	if n.Type == "Notification" && n.Message != "" {
+		return Parse([]byte(n.Message))
+	}
+	if n.Event == "s3:TestEvent" {
+		return nil, nil
+	}
+
+	events := make([]Event, 0, len(n.Records))
+	for _, record := range n.Records {
+		// Keys are form-encoded: spaces arrive as "+".
+		key, err := url.QueryUnescape(record.S3.Object.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode S3 event key %q: %w", record.S3.Object.Key, err)
+		}
+		events = append(events, Event{
+			Name:      strings.TrimPrefix(record.EventName, "s3:"),
+			Time:      record.EventTime,
+			Region:    record.AWSRegion,
+			Bucket:    record.S3.Bucket.Name,
+			Key:       key,
+			Size:      record.S3.Object.Size,
+			ETag:      record.S3.Object.ETag,
+			VersionID: record.S3.Object.VersionID,
+			Sequencer: record.S3.Object.Sequencer,
+		})
+	}
+	return events, nil
+}
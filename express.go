@@ -0,0 +1,47 @@
+package s3
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const directoryBucketSuffix = "--x-s3"
+
+// IsDirectoryBucket reports whether bucket names an S3 Express One Zone
+// directory bucket, bucket_base_name--az-id--x-s3. The SDK signs requests
+// to such buckets with session credentials from CreateSession, cached and
+// refreshed before they expire.
+func IsDirectoryBucket(bucket string) bool {
+	return strings.HasSuffix(bucket, directoryBucketSuffix)
+}
+
+// directoryBucketZone returns the Availability Zone ID of a directory
+// bucket, e.g. "usw2-az1".
+func directoryBucketZone(bucket string) string {
+	name := strings.TrimSuffix(bucket, directoryBucketSuffix)
+	i := strings.LastIndex(name, "--")
+	if i <= 0 {
+		return ""
+	}
+	return name[i+2:]
+}
+
+// validateDirectoryBucket rejects settings directory buckets do not
+// support.
+func validateDirectoryBucket(cfg *Config, pathStyle bool) error {
+	if !IsDirectoryBucket(cfg.BucketName) {
+		return nil
+	}
+	switch {
+	case directoryBucketZone(cfg.BucketName) == "":
+		return fmt.Errorf("directory bucket name %q has no availability zone ID", cfg.BucketName)
+	case cfg.Accelerate:
+		return errors.New("directory buckets do not support transfer acceleration")
+	case pathStyle && cfg.Endpoint == "":
+		return errors.New("directory buckets require virtual-hosted-style addressing")
+	case len(cfg.SSECustomerKey) > 0:
+		return errors.New("directory buckets do not support SSE-C")
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFallbackFailureThreshold = 3
+	defaultFallbackCooldown         = 30 * time.Second
+)
+
+type FallbackOption func(*fallbackOptions)
+
+type fallbackOptions struct {
+	failureThreshold int
+	cooldown         time.Duration
+}
+
+// WithFallbackFailureThreshold sets after how many consecutive endpoint
+// failures a client is marked unhealthy, 3 by default.
+func WithFallbackFailureThreshold(n int) FallbackOption {
+	return func(o *fallbackOptions) {
+		if n > 0 {
+			o.failureThreshold = n
+		}
+	}
+}
+
+// WithFallbackCooldown sets how long an unhealthy client is tried last
+// before it gets another chance first, 30 seconds by default.
+func WithFallbackCooldown(d time.Duration) FallbackOption {
+	return func(o *fallbackOptions) {
+		if d > 0 {
+			o.cooldown = d
+		}
+	}
+}
+
+// EndpointHealth is the state FallbackClient tracks for one of its clients.
+type EndpointHealth struct {
+	Endpoint            string
+	Bucket              string
+	Healthy             bool
+	ConsecutiveFailures int
+	LastError           error
+	LastFailure         time.Time
+}
+
+type fallbackEndpoint struct {
+	client      S3Client
+	failures    int
+	lastError   error
+	lastFailure time.Time
+}
+
+// FallbackClient reads objects from the first of several clients that has
+// them, e.g. the new bucket and then the old one during a migration, or a
+// replica when the primary endpoint is down. A read moves on to the next
+// client when the object or bucket is not found or the endpoint fails
+// (unreachable, 5xx, throttled); other errors, like access denied, are
+// returned as they are. Clients that keep failing are tried last until a
+// cool-down passes. An object no reachable client has is reported as not
+// found even if another client was down.
+//
+// Writes, listings and presigned URLs always use the first client.
+type FallbackClient struct {
+	o *fallbackOptions
+
+	mu        sync.Mutex
+	endpoints []*fallbackEndpoint
+}
+
+var _ S3Client = (*FallbackClient)(nil)
+
+// NewFallbackClient returns a client reading from clients in order; the
+// first one is the primary.
+func NewFallbackClient(clients []S3Client, opts ...FallbackOption) (*FallbackClient, error) {
+	if len(clients) == 0 {
+		return nil, errors.New("no clients to fall back between")
+	}
+	o := &fallbackOptions{failureThreshold: defaultFallbackFailureThreshold, cooldown: defaultFallbackCooldown}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	f := &FallbackClient{o: o}
+	for _, client := range clients {
+		f.endpoints = append(f.endpoints, &fallbackEndpoint{client: client})
+	}
+	return f, nil
+}
+
+func (f *FallbackClient) Health() []EndpointHealth {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	health := make([]EndpointHealth, 0, len(f.endpoints))
+	for _, e := range f.endpoints {
+		health = append(health, EndpointHealth{
+			Endpoint:            e.client.Endpoint(),
+			Bucket:              e.client.Bucket(),
+			Healthy:             f.healthy(e, time.Now()),
+			ConsecutiveFailures: e.failures,
+			LastError:           e.lastError,
+			LastFailure:         e.lastFailure,
+		})
+	}
+	return health
+}
+
+func (f *FallbackClient) healthy(e *fallbackEndpoint, now time.Time) bool {
+	return e.failures < f.o.failureThreshold || now.Sub(e.lastFailure) >= f.o.cooldown
+}
+
+// order returns the healthy endpoints in configuration order, followed by
+// the unhealthy ones.
+func (f *FallbackClient) order() []*fallbackEndpoint {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	order := make([]*fallbackEndpoint, 0, len(f.endpoints))
+	var unhealthy []*fallbackEndpoint
+	for _, e := range f.endpoints {
+		if f.healthy(e, now) {
+			order = append(order, e)
+		} else {
+			unhealthy = append(unhealthy, e)
+		}
+	}
+	return append(order, unhealthy...)
+}
+
+func (f *FallbackClient) record(e *fallbackEndpoint, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if isEndpointFailure(err) {
+		e.failures++
+		e.lastError = err
+		e.lastFailure = time.Now()
+		return
+	}
+	e.failures = 0
+}
+
+// read calls fn with each client until one succeeds or fails with an error
+// that is not worth a fallback. When every client failed, a not-found answer
+// is preferred over endpoint failures.
+func (f *FallbackClient) read(ctx context.Context, fn func(S3Client) error) error {
+	var err, notFound error
+	for _, e := range f.order() {
+		err = fn(e.client)
+		f.record(e, err)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		switch {
+		case errors.Is(err, ErrNotFound), errors.Is(err, ErrBucketNotFound):
+			if notFound == nil {
+				notFound = err
+			}
+		case !isEndpointFailure(err):
+			return err
+		}
+	}
+	if notFound != nil {
+		return notFound
+	}
+	return err
+}
+
+func (f *FallbackClient) primary() S3Client { return f.endpoints[0].client }
+
+func (f *FallbackClient) UploadFile(ctx context.Context, objectID string, key string, body io.Reader, contentType string, opts ...PutOption) (*UploadResult, error) {
+	return f.primary().UploadFile(ctx, objectID, key, body, contentType, opts...)
+}
+
+func (f *FallbackClient) UploadLarge(ctx context.Context, key string, r io.Reader, opts ...UploadOption) error {
+	return f.primary().UploadLarge(ctx, key, r, opts...)
+}
+
+func (f *FallbackClient) DownloadFile(ctx context.Context, key string, opts ...DownloadOption) (body io.ReadCloser, info *ObjectInfo, err error) {
+	err = f.read(ctx, func(c S3Client) error {
+		body, info, err = c.DownloadFile(ctx, key, opts...)
+		return err
+	})
+	return body, info, err
+}
+
+func (f *FallbackClient) DownloadRange(ctx context.Context, key string, offset, length int64, opts ...DownloadOption) (body io.ReadCloser, info *ObjectInfo, err error) {
+	err = f.read(ctx, func(c S3Client) error {
+		body, info, err = c.DownloadRange(ctx, key, offset, length, opts...)
+		return err
+	})
+	return body, info, err
+}
+
+// DownloadResumable falls back like the other reads; cp stays valid since a
+// client with another ETag restarts the download.
+func (f *FallbackClient) DownloadResumable(ctx context.Context, key string, w io.WriterAt, cp *DownloadCheckpoint, opts ...DownloadOption) error {
+	return f.read(ctx, func(c S3Client) error {
+		return c.DownloadResumable(ctx, key, w, cp, opts...)
+	})
+}
+
+func (f *FallbackClient) DownloadLarge(ctx context.Context, key string, w io.WriterAt, opts ...DownloadOption) (n int64, err error) {
+	err = f.read(ctx, func(c S3Client) error {
+		n, err = c.DownloadLarge(ctx, key, w, opts...)
+		return err
+	})
+	return n, err
+}
+
+func (f *FallbackClient) DeleteFile(ctx context.Context, key string, opts ...DeleteOption) error {
+	return f.primary().DeleteFile(ctx, key, opts...)
+}
+
+func (f *FallbackClient) DeleteFiles(ctx context.Context, keys []string) (*DeleteResult, error) {
+	return f.primary().DeleteFiles(ctx, keys)
+}
+
+func (f *FallbackClient) DeletePrefix(ctx context.Context, prefix string) (*DeleteResult, error) {
+	return f.primary().DeletePrefix(ctx, prefix)
+}
+
+func (f *FallbackClient) CopyFile(ctx context.Context, srcKey, dstKey string, opts ...CopyOption) error {
+	return f.primary().CopyFile(ctx, srcKey, dstKey, opts...)
+}
+
+func (f *FallbackClient) MoveFile(ctx context.Context, srcKey, dstKey string) error {
+	return f.primary().MoveFile(ctx, srcKey, dstKey)
+}
+
+func (f *FallbackClient) RenamePrefix(ctx context.Context, oldPrefix, newPrefix string, opts ...RenameOption) (*RenameResult, error) {
+	return f.primary().RenamePrefix(ctx, oldPrefix, newPrefix, opts...)
+}
+
+// FileExists reports whether any client has key.
+func (f *FallbackClient) FileExists(ctx context.Context, key string) (exists bool, err error) {
+	err = f.read(ctx, func(c S3Client) error {
+		exists, err = c.FileExists(ctx, key)
+		if err == nil && !exists {
+			return ErrNotFound
+		}
+		return err
+	})
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return exists, err
+}
+
+func (f *FallbackClient) GetObjectInfo(ctx context.Context, key string) (info *ObjectInfo, err error) {
+	err = f.read(ctx, func(c S3Client) error {
+		info, err = c.GetObjectInfo(ctx, key)
+		return err
+	})
+	return info, err
+}
+
+func (f *FallbackClient) UpdateMetadata(ctx context.Context, key string, meta map[string]string) error {
+	return f.primary().UpdateMetadata(ctx, key, meta)
+}
+
+func (f *FallbackClient) SetTags(ctx context.Context, key string, tags map[string]string) error {
+	return f.primary().SetTags(ctx, key, tags)
+}
+
+func (f *FallbackClient) GetTags(ctx context.Context, key string) (tags map[string]string, err error) {
+	err = f.read(ctx, func(c S3Client) error {
+		tags, err = c.GetTags(ctx, key)
+		return err
+	})
+	return tags, err
+}
+
+func (f *FallbackClient) DeleteTags(ctx context.Context, key string) error {
+	return f.primary().DeleteTags(ctx, key)
+}
+
+func (f *FallbackClient) List(prefix string) *ListIterator {
+	return f.primary().List(prefix)
+}
+
+func (f *FallbackClient) ListAll(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	return f.primary().ListAll(ctx, prefix, fn)
+}
+
+func (f *FallbackClient) ListObjectsInfo(ctx context.Context, prefix string, opts ...ListOption) ([]ObjectInfo, error) {
+	return f.primary().ListObjectsInfo(ctx, prefix, opts...)
+}
+
+func (f *FallbackClient) ListDirectory(ctx context.Context, prefix string) (*DirectoryListing, error) {
+	return f.primary().ListDirectory(ctx, prefix)
+}
+
+func (f *FallbackClient) GetObjects(ctx context.Context, prefix string) ([]string, error) {
+	return f.primary().GetObjects(ctx, prefix)
+}
+
+func (f *FallbackClient) GetPresignedURL(ctx context.Context, key string, expiration time.Duration, opts ...PresignOption) (string, error) {
+	return f.primary().GetPresignedURL(ctx, key, expiration, opts...)
+}
+
+func (f *FallbackClient) PresignPostPolicy(ctx context.Context, opts PostPolicyOptions) (*PresignedPost, error) {
+	return f.primary().PresignPostPolicy(ctx, opts)
+}
+
+func (f *FallbackClient) KeyFromURL(rawURL string) (string, error) {
+	return f.primary().KeyFromURL(rawURL)
+}
+
+func (f *FallbackClient) FindKeyByPresignedURL(ctx context.Context, presignedURL string, prefix string) (string, error) {
+	return f.primary().FindKeyByPresignedURL(ctx, presignedURL, prefix)
+}
+
+func (f *FallbackClient) Bucket() string   { return f.primary().Bucket() }
+func (f *FallbackClient) Endpoint() string { return f.primary().Endpoint() }
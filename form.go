@@ -0,0 +1,152 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+)
+
+const (
+	defaultMaxFormFileSize = 32 << 20
+	sniffLen               = 512
+)
+
+// ErrFileTooLarge is returned by UploadFromRequest when the file exceeds the
+// size set with WithMaxSize, and by uploads larger than
+// ValidationConfig.MaxSize.
+var ErrFileTooLarge = errors.New("file exceeds the maximum upload size")
+
+// UploadFromRequest uploads the file sent in the multipart form field of r.
+// The body is streamed straight to S3 unless the form has already been
+// parsed. The content type is sniffed from the first bytes unless set with
+// WithContentType, and the key defaults to the client file name (see
+// WithKey). The returned ObjectInfo carries a presigned URL.
+func (c *Client) UploadFromRequest(ctx context.Context, r *http.Request, field string, opts ...PutOption) (*ObjectInfo, error) {
+	o := c.newUploadOptions(opts)
+	if o.maxSize == 0 {
+		o.maxSize = defaultMaxFormFileSize
+	}
+
+	file, filename, err := formFile(r, field)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	key := o.key
+	if key == "" {
+		if key = sanitizeFilename(filename); key == "" {
+			return nil, fmt.Errorf("form file %q has no usable file name", field)
+		}
+	}
+
+	limited := &limitedReader{r: file, remaining: o.maxSize}
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(limited, head)
+	if errors.Is(err, ErrFileTooLarge) {
+		return nil, ErrFileTooLarge
+	}
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("failed to read form file: %w", err)
+	}
+	head = head[:n]
+
+	contentType := o.contentType
+	if contentType == "" {
+		contentType = c.detector(key, head)
+	}
+	counter := &countingReader{r: io.MultiReader(bytes.NewReader(head), limited)}
+	opts = append(opts, WithContentType(contentType))
+	if err := c.UploadLarge(ctx, key, counter, opts...); err != nil {
+		return nil, err
+	}
+
+	url, err := c.GetPresignedURL(ctx, key, c.presignTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectInfo{
+		Key:         key,
+		Size:        counter.n,
+		ContentType: contentType,
+		URL:         url,
+	}, nil
+}
+
+// formFile returns the named file part, reading the multipart stream
+// directly when the form has not been parsed yet.
+func formFile(r *http.Request, field string) (io.ReadCloser, string, error) {
+	if r.MultipartForm != nil {
+		file, header, err := r.FormFile(field)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read form file %q: %w", field, err)
+		}
+		return file, header.Filename, nil
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read multipart form: %w", err)
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, "", fmt.Errorf("failed to read form file %q: %w", field, http.ErrMissingFile)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read multipart form: %w", err)
+		}
+		if part.FormName() == field && part.FileName() != "" {
+			return part, part.FileName(), nil
+		}
+		part.Close()
+	}
+}
+
+// sanitizeFilename keeps only the base name so a client cannot choose an
+// arbitrary key through "../" or absolute paths.
+func sanitizeFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	switch name {
+	case ".", "..", "/":
+		return ""
+	}
+	return name
+}
+
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrFileTooLarge
+	}
+	// Read one byte past the limit to tell "exactly max" from "too large".
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, ErrFileTooLarge
+	}
+	return n, err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
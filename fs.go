@@ -0,0 +1,257 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FS exposes the objects under a prefix as a read-only file system, so
+// http.FileServer, template.ParseFS and other io/fs consumers can read them.
+// Keys are split on "/" into directories; every call uses the context given
+// to NewFS because the fs interfaces take none.
+type FS struct {
+	ctx    context.Context
+	client S3Client
+	prefix string
+}
+
+var (
+	_ fs.ReadDirFS = (*FS)(nil)
+	_ fs.StatFS    = (*FS)(nil)
+)
+
+// NewFS returns a file system rooted at prefix. A trailing "/" is implied.
+func NewFS(ctx context.Context, client S3Client, prefix string) *FS {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return &FS{ctx: ctx, client: client, prefix: prefix}
+}
+
+func (f *FS) key(name string) string {
+	if name == "." {
+		return f.prefix
+	}
+	return f.prefix + name
+}
+
+func (f *FS) Open(name string) (fs.File, error) {
+	info, err := f.stat("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &dir{fs: f, name: name, info: info}, nil
+	}
+	return &file{fs: f, key: f.key(name), info: info}, nil
+}
+
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	return f.stat("stat", name)
+}
+
+func (f *FS) stat(op, name string) (*fileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return &fileInfo{name: ".", dir: true}, nil
+	}
+
+	obj, err := f.client.GetObjectInfo(f.ctx, f.key(name))
+	if err == nil {
+		return &fileInfo{name: path.Base(name), obj: *obj}, nil
+	}
+	if !isNotFound(err) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	// No object with that key; it is a directory if anything lives below it.
+	found := false
+	err = f.client.ListAll(f.ctx, f.key(name)+"/", func(ObjectInfo) error {
+		found = true
+		return ErrStopListing
+	})
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return &fileInfo{name: path.Base(name), dir: true}, nil
+}
+
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	prefix := f.key(name)
+	if name != "." {
+		prefix += "/"
+	}
+	listing, err := f.client.ListDirectory(f.ctx, prefix)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	if name != "." && len(listing.Files) == 0 && len(listing.Folders) == 0 {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(listing.Files)+len(listing.Folders))
+	for _, obj := range listing.Files {
+		base := strings.TrimPrefix(obj.Key, prefix)
+		// Skip directory marker objects such as "photos/".
+		if base == "" || strings.HasSuffix(base, "/") {
+			continue
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(&fileInfo{name: base, obj: obj}))
+	}
+	for _, folder := range listing.Folders {
+		base := strings.TrimSuffix(strings.TrimPrefix(folder, prefix), "/")
+		if base == "" {
+			continue
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(&fileInfo{name: base, dir: true}))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+type fileInfo struct {
+	name string
+	dir  bool
+	obj  ObjectInfo
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.obj.Size }
+func (fi *fileInfo) ModTime() time.Time { return fi.obj.LastModified }
+func (fi *fileInfo) IsDir() bool        { return fi.dir }
+
+func (fi *fileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// Sys returns the *ObjectInfo for files and nil for directories.
+func (fi *fileInfo) Sys() any {
+	if fi.dir {
+		return nil
+	}
+	return &fi.obj
+}
+
+// file reads the object lazily with ranged GETs so Seek is cheap, which
+// http.FileServer relies on for content sniffing and Range requests.
+type file struct {
+	fs     *FS
+	key    string
+	info   *fileInfo
+	offset int64
+	body   io.ReadCloser
+	closed bool
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+func (f *file) Read(p []byte) (int, error) {
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if f.offset >= f.info.Size() {
+		return 0, io.EOF
+	}
+	if f.body == nil {
+		body, _, err := f.fs.client.DownloadRange(f.fs.ctx, f.key, f.offset, 0)
+		if err != nil {
+			return 0, &fs.PathError{Op: "read", Path: f.info.name, Err: err}
+		}
+		f.body = body
+	}
+	n, err := f.body.Read(p)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.Size()
+	default:
+		return 0, &fs.PathError{Op: "seek", Path: f.info.name, Err: fs.ErrInvalid}
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.info.name, Err: fs.ErrInvalid}
+	}
+	if offset != f.offset && f.body != nil {
+		f.body.Close()
+		f.body = nil
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *file) Close() error {
+	if f.closed {
+		return fs.ErrClosed
+	}
+	f.closed = true
+	if f.body != nil {
+		return f.body.Close()
+	}
+	return nil
+}
+
+type dir struct {
+	fs      *FS
+	name    string
+	info    *fileInfo
+	entries []fs.DirEntry
+	read    bool
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) { return d.info, nil }
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+func (d *dir) Close() error { return nil }
+
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		entries, err := d.fs.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = entries
+		d.read = true
+	}
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
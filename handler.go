@@ -0,0 +1,78 @@
+package s3
+
+import (
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// NewObjectHandler returns an http.Handler that streams the object at
+// prefix + request path. Content-Type, ETag and Last-Modified come from the
+// object; Range, If-None-Match and If-Modified-Since are handled by
+// http.ServeContent on top of ranged GETs, so only the requested bytes are
+// fetched from S3.
+func (c *Client) NewObjectHandler(prefix string) http.Handler {
+	return &objectHandler{client: c, prefix: prefix}
+}
+
+type objectHandler struct {
+	client *Client
+	prefix string
+}
+
+func (h *objectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	// The path is cleaned here as well, since a handler mounted without a
+	// ServeMux, e.g. behind http.StripPrefix, gets it as sent, and ".."
+	// segments must not leave the prefix.
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if strings.HasSuffix(r.URL.Path, "/") || !fs.ValidPath(name) {
+		http.NotFound(w, r)
+		return
+	}
+	key := h.prefix + name
+
+	ctx := r.Context()
+	info, err := h.client.GetObjectInfo(ctx, key)
+	if err != nil {
+		if isNotFound(err) {
+			http.NotFound(w, r)
+			return
+		}
+		h.client.logger.ErrorContext(ctx, "failed to serve object", slog.String("key", key), slog.Any("error", err))
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+
+	header := w.Header()
+	if info.ContentType != "" {
+		header.Set("Content-Type", info.ContentType)
+	}
+	if info.ETag != "" {
+		header.Set("ETag", info.ETag)
+	}
+	if info.CacheControl != "" {
+		header.Set("Cache-Control", info.CacheControl)
+	}
+	if info.ContentDisposition != "" {
+		header.Set("Content-Disposition", info.ContentDisposition)
+	}
+	if info.ContentEncoding != "" {
+		header.Set("Content-Encoding", info.ContentEncoding)
+	}
+
+	body := &file{
+		fs:   &FS{ctx: ctx, client: h.client},
+		key:  key,
+		info: &fileInfo{name: path.Base(key), obj: *info},
+	}
+	defer body.Close()
+	http.ServeContent(w, r, body.info.name, info.LastModified, body)
+}
@@ -0,0 +1,154 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// Operation is an S3 API call passing through the middlewares added with
+// Use.
+type Operation struct {
+	// Name is the S3 API operation, e.g. "PutObject" or "ListObjectsV2".
+	Name string
+	// Bucket and Key are read from Input; changing them rewrites the
+	// request.
+	Bucket string
+	Key    string
+	// Input is the SDK input, e.g. *s3.PutObjectInput, and may be modified.
+	Input any
+	// Header holds extra HTTP headers sent, and signed, with the request.
+	Header http.Header
+}
+
+// OperationHandler performs an operation and returns the SDK output, e.g.
+// *s3.GetObjectOutput, whose Body a middleware may wrap.
+type OperationHandler func(ctx context.Context, op *Operation) (any, error)
+
+// Middleware wraps every S3 API call of the client, including each request
+// of multipart uploads and batch helpers. It may inspect or change op, call
+// next or fail without calling it; a result returned without calling next
+// must have the type next would return. Presigned URLs do not go through
+// middlewares.
+type Middleware func(next OperationHandler) OperationHandler
+
+// Use appends middlewares to the client. The first middleware added is the
+// outermost. Clients derived with ForBucket share the chain.
+func (c *Client) Use(mw ...Middleware) {
+	c.middlewares.mu.Lock()
+	defer c.middlewares.mu.Unlock()
+	c.middlewares.list = append(c.middlewares.list, mw...)
+}
+
+type middlewareChain struct {
+	mu   sync.RWMutex
+	list []Middleware
+}
+
+func (c *middlewareChain) wrap(h OperationHandler) OperationHandler {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for i := len(c.list) - 1; i >= 0; i-- {
+		h = c.list[i](h)
+	}
+	return h
+}
+
+func (c *middlewareChain) empty() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.list) == 0
+}
+
+type operationHeaderKey struct{}
+
+func addMiddlewareChain(chain *middlewareChain) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		if isPresignStack(stack) {
+			return nil
+		}
+		// Before the SDK's input validation, so rewritten inputs are
+		// validated too. The operation name is not in ctx that early; the
+		// stack ID is the same.
+		if err := stack.Initialize.Add(&chainMiddleware{chain: chain, operation: stack.ID()}, middleware.Before); err != nil {
+			return err
+		}
+		return stack.Build.Add(&operationHeaderMiddleware{}, middleware.After)
+	}
+}
+
+type chainMiddleware struct {
+	chain     *middlewareChain
+	operation string
+}
+
+func (*chainMiddleware) ID() string { return "go-s3.Middlewares" }
+
+func (m *chainMiddleware) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	if m.chain.empty() {
+		return next.HandleInitialize(ctx, in)
+	}
+
+	bucket, key := operationTarget(in.Parameters)
+	op := &Operation{
+		Name:   m.operation,
+		Bucket: bucket,
+		Key:    key,
+		Input:  in.Parameters,
+		Header: http.Header{},
+	}
+	var metadata middleware.Metadata
+	handler := m.chain.wrap(func(ctx context.Context, op *Operation) (any, error) {
+		setOperationTarget(op.Input, op.Bucket, op.Key)
+		in.Parameters = op.Input
+		if len(op.Header) > 0 {
+			ctx = middleware.WithStackValue(ctx, operationHeaderKey{}, op.Header)
+		}
+		out, md, err := next.HandleInitialize(ctx, in)
+		metadata = md
+		return out.Result, err
+	})
+	result, err := handler(ctx, op)
+	return middleware.InitializeOutput{Result: result}, metadata, err
+}
+
+// operationHeaderMiddleware adds Operation.Header to the request before it
+// is signed.
+type operationHeaderMiddleware struct{}
+
+func (*operationHeaderMiddleware) ID() string { return "go-s3.OperationHeader" }
+
+func (*operationHeaderMiddleware) HandleBuild(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+	header, _ := middleware.GetStackValue(ctx, operationHeaderKey{}).(http.Header)
+	if req, ok := in.Request.(*smithyhttp.Request); ok {
+		for name, values := range header {
+			for _, value := range values {
+				req.Header.Add(name, value)
+			}
+		}
+	}
+	return next.HandleBuild(ctx, in)
+}
+
+// setOperationTarget writes bucket and key back into the Bucket and Key
+// fields of an S3 input when they changed.
+func setOperationTarget(params any, bucket, key string) {
+	v := reflect.ValueOf(params)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+	v = v.Elem()
+	for name, value := range map[string]string{"Bucket": bucket, "Key": key} {
+		f := v.FieldByName(name)
+		if !f.IsValid() || f.Kind() != reflect.Pointer || f.Type().Elem().Kind() != reflect.String {
+			continue
+		}
+		if stringField(v, name) != value {
+			f.Set(reflect.ValueOf(&value))
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// S3Client is the object API implemented by *Client. Depend on it instead of
+// *Client to swap in s3mock or MemoryClient in tests.
+type S3Client interface {
+	UploadFile(ctx context.Context, objectID string, key string, body io.Reader, contentType string, opts ...PutOption) (*UploadResult, error)
+	UploadLarge(ctx context.Context, key string, r io.Reader, opts ...UploadOption) error
+
+	DownloadFile(ctx context.Context, key string, opts ...DownloadOption) (io.ReadCloser, *ObjectInfo, error)
+	DownloadRange(ctx context.Context, key string, offset, length int64, opts ...DownloadOption) (io.ReadCloser, *ObjectInfo, error)
+	DownloadResumable(ctx context.Context, key string, w io.WriterAt, cp *DownloadCheckpoint, opts ...DownloadOption) error
+	DownloadLarge(ctx context.Context, key string, w io.WriterAt, opts ...DownloadOption) (int64, error)
+
+	DeleteFile(ctx context.Context, key string, opts ...DeleteOption) error
+	DeleteFiles(ctx context.Context, keys []string) (*DeleteResult, error)
+	DeletePrefix(ctx context.Context, prefix string) (*DeleteResult, error)
+
+	CopyFile(ctx context.Context, srcKey, dstKey string, opts ...CopyOption) error
+	MoveFile(ctx context.Context, srcKey, dstKey string) error
+	RenamePrefix(ctx context.Context, oldPrefix, newPrefix string, opts ...RenameOption) (*RenameResult, error)
+
+	FileExists(ctx context.Context, key string) (bool, error)
+	GetObjectInfo(ctx context.Context, key string) (*ObjectInfo, error)
+	UpdateMetadata(ctx context.Context, key string, meta map[string]string) error
+	SetTags(ctx context.Context, key string, tags map[string]string) error
+	GetTags(ctx context.Context, key string) (map[string]string, error)
+	DeleteTags(ctx context.Context, key string) error
+
+	List(prefix string) *ListIterator
+	ListAll(ctx context.Context, prefix string, fn func(ObjectInfo) error) error
+	ListObjectsInfo(ctx context.Context, prefix string, opts ...ListOption) ([]ObjectInfo, error)
+	ListDirectory(ctx context.Context, prefix string) (*DirectoryListing, error)
+	GetObjects(ctx context.Context, prefix string) ([]string, error)
+
+	GetPresignedURL(ctx context.Context, key string, expiration time.Duration, opts ...PresignOption) (string, error)
+	PresignPostPolicy(ctx context.Context, opts PostPolicyOptions) (*PresignedPost, error)
+	KeyFromURL(rawURL string) (string, error)
+	FindKeyByPresignedURL(ctx context.Context, presignedURL string, prefix string) (string, error)
+
+	Bucket() string
+	Endpoint() string
+}
+
+var _ S3Client = (*Client)(nil)
@@ -0,0 +1,117 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrLeaseHeld is returned by AcquireLease while another owner holds an
+	// unexpired lease.
+	ErrLeaseHeld = errors.New("lease held by another owner")
+	// ErrLeaseLost is returned by Renew and Release when the lease expired
+	// and was taken over, or was deleted.
+	ErrLeaseLost = errors.New("lease lost")
+)
+
+const leaseAcquireAttempts = 3
+
+// Lease is a lock on a key shared by workers through the bucket. It is held
+// until Release or until it expires without a Renew. Expiry is judged by
+// the clocks of the workers, so the TTL should be well above their skew.
+type Lease struct {
+	Key     string
+	Owner   string
+	Expires time.Time
+
+	client *Client
+	ttl    time.Duration
+
+	mu   sync.Mutex
+	etag string
+}
+
+type leaseRecord struct {
+	Owner   string    `json:"owner"`
+	Expires time.Time `json:"expires"`
+}
+
+// AcquireLease takes the lease stored at key for owner, creating the lease
+// object if needed or taking over one that expired. It fails with
+// ErrLeaseHeld while another owner's lease is valid; it does not wait.
+func (c *Client) AcquireLease(ctx context.Context, key, owner string, ttl time.Duration) (*Lease, error) {
+	if ttl <= 0 {
+		return nil, errors.New("lease TTL must be positive")
+	}
+	lease := &Lease{Key: key, Owner: owner, client: c, ttl: ttl}
+	for attempt := 0; attempt < leaseAcquireAttempts; attempt++ {
+		var current leaseRecord
+		info, err := c.GetJSON(ctx, key, &current)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			err = lease.write(ctx, "")
+		case err != nil:
+			return nil, fmt.Errorf("failed to read lease: %w", err)
+		case current.Owner != owner && time.Now().Before(current.Expires):
+			return nil, fmt.Errorf("%w: %s until %s", ErrLeaseHeld, current.Owner, current.Expires.Format(time.RFC3339))
+		default:
+			err = lease.write(ctx, info.ETag)
+		}
+		if err == nil {
+			return lease, nil
+		}
+		// Another worker wrote the lease between the read and the write;
+		// read it again to see who holds it now.
+		if !errors.Is(err, ErrPreconditionFailed) {
+			return nil, fmt.Errorf("failed to write lease: %w", err)
+		}
+	}
+	return nil, ErrLeaseHeld
+}
+
+// write stores the lease with a new expiry if the lease object still has
+// etag, or does not exist when etag is empty.
+func (l *Lease) write(ctx context.Context, etag string) error {
+	expires := time.Now().Add(l.ttl).UTC()
+	data, err := JSONCodec.Marshal(leaseRecord{Owner: l.Owner, Expires: expires})
+	if err != nil {
+		return fmt.Errorf("failed to encode lease: %w", err)
+	}
+	result, err := l.client.CompareAndSwap(ctx, l.Key, etag, bytes.NewReader(data), WithContentType(JSONCodec.ContentType()))
+	if err != nil {
+		return err
+	}
+	l.etag = result.ETag
+	l.Expires = expires
+	return nil
+}
+
+// Renew extends the lease by its TTL. It fails with ErrLeaseLost if the
+// lease was taken over, after which the caller must stop the guarded work.
+func (l *Lease) Renew(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.write(ctx, l.etag); err != nil {
+		if errors.Is(err, ErrPreconditionFailed) || errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("%w: %s", ErrLeaseLost, l.Key)
+		}
+		return fmt.Errorf("failed to renew lease: %w", err)
+	}
+	return nil
+}
+
+// Release deletes the lease object unless another owner took it over, so
+// the next AcquireLease succeeds at once.
+func (l *Lease) Release(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	err := l.client.deleteObject(ctx, l.Key, &deleteOptions{ifMatch: l.etag})
+	if errors.Is(err, ErrPreconditionFailed) || errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrLeaseLost, l.Key)
+	}
+	return err
+}
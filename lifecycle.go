@@ -0,0 +1,218 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// LifecycleRule is one bucket lifecycle rule. Prefix and Tags select the
+// objects it applies to (both empty means the whole bucket); zero-valued
+// actions are left out of the rule.
+type LifecycleRule struct {
+	ID       string
+	Prefix   string
+	Tags     map[string]string
+	Disabled bool
+
+	// ExpireAfterDays or ExpireAt deletes current versions (or adds a delete
+	// marker in versioned buckets).
+	ExpireAfterDays int32
+	ExpireAt        time.Time
+	// ExpiredObjectDeleteMarker removes delete markers with no noncurrent
+	// versions left behind them.
+	ExpiredObjectDeleteMarker bool
+	Transitions               []LifecycleTransition
+
+	// NoncurrentExpireAfterDays deletes versions that many days after they
+	// became noncurrent, keeping the NoncurrentNewerVersions newest ones.
+	NoncurrentExpireAfterDays int32
+	NoncurrentNewerVersions   int32
+	NoncurrentTransitions     []LifecycleTransition
+
+	// AbortIncompleteUploadsAfterDays cleans up multipart uploads that were
+	// never completed or aborted.
+	AbortIncompleteUploadsAfterDays int32
+}
+
+// LifecycleTransition moves objects to StorageClass Days after creation, or
+// for noncurrent transitions, after they became noncurrent.
+type LifecycleTransition struct {
+	Days         int32
+	StorageClass types.TransitionStorageClass
+}
+
+// GetLifecycleRules returns the bucket lifecycle rules, or none if the bucket
+// has no lifecycle configuration.
+func (c *Client) GetLifecycleRules(ctx context.Context) ([]LifecycleRule, error) {
+	output, err := c.client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(c.bucket),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchLifecycleConfiguration" {
+			return []LifecycleRule{}, nil
+		}
+		return nil, fmt.Errorf("failed to get bucket lifecycle: %w", err)
+	}
+	rules := make([]LifecycleRule, 0, len(output.Rules))
+	for _, rule := range output.Rules {
+		rules = append(rules, lifecycleRuleFromSDK(rule))
+	}
+	return rules, nil
+}
+
+// SetLifecycleRules replaces the bucket lifecycle configuration with rules.
+func (c *Client) SetLifecycleRules(ctx context.Context, rules []LifecycleRule) error {
+	if len(rules) == 0 {
+		return c.DeleteLifecycleRules(ctx)
+	}
+	sdkRules := make([]types.LifecycleRule, 0, len(rules))
+	for _, rule := range rules {
+		sdkRules = append(sdkRules, rule.toSDK())
+	}
+	_, err := c.client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(c.bucket),
+		LifecycleConfiguration: &types.BucketLifecycleConfiguration{Rules: sdkRules},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set bucket lifecycle: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) DeleteLifecycleRules(ctx context.Context) error {
+	_, err := c.client.DeleteBucketLifecycle(ctx, &s3.DeleteBucketLifecycleInput{
+		Bucket: aws.String(c.bucket),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete bucket lifecycle: %w", err)
+	}
+	return nil
+}
+
+func (r LifecycleRule) toSDK() types.LifecycleRule {
+	rule := types.LifecycleRule{
+		ID:     stringOrNil(r.ID),
+		Status: types.ExpirationStatusEnabled,
+		Filter: r.filter(),
+	}
+	if r.Disabled {
+		rule.Status = types.ExpirationStatusDisabled
+	}
+
+	if r.ExpireAfterDays > 0 || !r.ExpireAt.IsZero() || r.ExpiredObjectDeleteMarker {
+		rule.Expiration = &types.LifecycleExpiration{}
+		switch {
+		case r.ExpireAfterDays > 0:
+			rule.Expiration.Days = aws.Int32(r.ExpireAfterDays)
+		case !r.ExpireAt.IsZero():
+			rule.Expiration.Date = aws.Time(r.ExpireAt)
+		default:
+			rule.Expiration.ExpiredObjectDeleteMarker = aws.Bool(true)
+		}
+	}
+	for _, t := range r.Transitions {
+		rule.Transitions = append(rule.Transitions, types.Transition{
+			Days:         aws.Int32(t.Days),
+			StorageClass: t.StorageClass,
+		})
+	}
+
+	if r.NoncurrentExpireAfterDays > 0 {
+		rule.NoncurrentVersionExpiration = &types.NoncurrentVersionExpiration{
+			NoncurrentDays: aws.Int32(r.NoncurrentExpireAfterDays),
+		}
+		if r.NoncurrentNewerVersions > 0 {
+			rule.NoncurrentVersionExpiration.NewerNoncurrentVersions = aws.Int32(r.NoncurrentNewerVersions)
+		}
+	}
+	for _, t := range r.NoncurrentTransitions {
+		rule.NoncurrentVersionTransitions = append(rule.NoncurrentVersionTransitions, types.NoncurrentVersionTransition{
+			NoncurrentDays: aws.Int32(t.Days),
+			StorageClass:   t.StorageClass,
+		})
+	}
+
+	if r.AbortIncompleteUploadsAfterDays > 0 {
+		rule.AbortIncompleteMultipartUpload = &types.AbortIncompleteMultipartUpload{
+			DaysAfterInitiation: aws.Int32(r.AbortIncompleteUploadsAfterDays),
+		}
+	}
+	return rule
+}
+
+func (r LifecycleRule) filter() types.LifecycleRuleFilter {
+	if len(r.Tags) == 0 {
+		return &types.LifecycleRuleFilterMemberPrefix{Value: r.Prefix}
+	}
+	keys := make([]string, 0, len(r.Tags))
+	for k := range r.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	tags := make([]types.Tag, 0, len(keys))
+	for _, k := range keys {
+		tags = append(tags, types.Tag{Key: aws.String(k), Value: aws.String(r.Tags[k])})
+	}
+	if len(tags) == 1 && r.Prefix == "" {
+		return &types.LifecycleRuleFilterMemberTag{Value: tags[0]}
+	}
+	return &types.LifecycleRuleFilterMemberAnd{Value: types.LifecycleRuleAndOperator{
+		Prefix: stringOrNil(r.Prefix),
+		Tags:   tags,
+	}}
+}
+
+func lifecycleRuleFromSDK(rule types.LifecycleRule) LifecycleRule {
+	r := LifecycleRule{
+		ID:       aws.ToString(rule.ID),
+		Prefix:   aws.ToString(rule.Prefix),
+		Disabled: rule.Status == types.ExpirationStatusDisabled,
+	}
+	switch f := rule.Filter.(type) {
+	case *types.LifecycleRuleFilterMemberPrefix:
+		r.Prefix = f.Value
+	case *types.LifecycleRuleFilterMemberTag:
+		r.Tags = map[string]string{aws.ToString(f.Value.Key): aws.ToString(f.Value.Value)}
+	case *types.LifecycleRuleFilterMemberAnd:
+		r.Prefix = aws.ToString(f.Value.Prefix)
+		r.Tags = make(map[string]string, len(f.Value.Tags))
+		for _, tag := range f.Value.Tags {
+			r.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+	}
+
+	if e := rule.Expiration; e != nil {
+		r.ExpireAfterDays = aws.ToInt32(e.Days)
+		r.ExpireAt = aws.ToTime(e.Date)
+		r.ExpiredObjectDeleteMarker = aws.ToBool(e.ExpiredObjectDeleteMarker)
+	}
+	for _, t := range rule.Transitions {
+		r.Transitions = append(r.Transitions, LifecycleTransition{
+			Days:         aws.ToInt32(t.Days),
+			StorageClass: t.StorageClass,
+		})
+	}
+	if e := rule.NoncurrentVersionExpiration; e != nil {
+		r.NoncurrentExpireAfterDays = aws.ToInt32(e.NoncurrentDays)
+		r.NoncurrentNewerVersions = aws.ToInt32(e.NewerNoncurrentVersions)
+	}
+	for _, t := range rule.NoncurrentVersionTransitions {
+		r.NoncurrentTransitions = append(r.NoncurrentTransitions, LifecycleTransition{
+			Days:         aws.ToInt32(t.NoncurrentDays),
+			StorageClass: t.StorageClass,
+		})
+	}
+	if a := rule.AbortIncompleteMultipartUpload; a != nil {
+		r.AbortIncompleteUploadsAfterDays = aws.ToInt32(a.DaysAfterInitiation)
+	}
+	return r
+}
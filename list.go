@@ -0,0 +1,175 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ErrStopListing lets ListAll callbacks end the walk early without an error.
+var ErrStopListing = errors.New("stop listing")
+
+// ListIterator walks every object under a prefix, fetching pages from
+// ListObjectsV2 on demand.
+//
+//	it := client.List("prefix/")
+//	for it.Next(ctx) {
+//		obj := it.Object()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type ListIterator struct {
+	fetch   func(ctx context.Context) (page []ObjectInfo, more bool, err error)
+	more    bool
+	page    []ObjectInfo
+	current ObjectInfo
+	err     error
+}
+
+// NewListIterator returns an iterator over a fixed set of objects, for fakes
+// and wrappers implementing S3Client.
+func NewListIterator(objects []ObjectInfo) *ListIterator {
+	return &ListIterator{page: objects}
+}
+
+func (c *Client) List(prefix string) *ListIterator {
+	paginator := s3.NewListObjectsV2Paginator(c.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(c.bucket),
+		Prefix: aws.String(prefix),
+	})
+	return &ListIterator{
+		more: true,
+		fetch: func(ctx context.Context) ([]ObjectInfo, bool, error) {
+			output, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to list objects: %w", err)
+			}
+			page := make([]ObjectInfo, 0, len(output.Contents))
+			for _, obj := range output.Contents {
+				page = append(page, objectInfoFromListing(obj))
+			}
+			return page, paginator.HasMorePages(), nil
+		},
+	}
+}
+
+func (it *ListIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	for len(it.page) == 0 {
+		if !it.more {
+			return false
+		}
+		it.page, it.more, it.err = it.fetch(ctx)
+		if it.err != nil {
+			return false
+		}
+	}
+	it.current = it.page[0]
+	it.page = it.page[1:]
+	return true
+}
+
+func (it *ListIterator) Object() ObjectInfo { return it.current }
+func (it *ListIterator) Err() error         { return it.err }
+
+// ListAll calls fn for every object under prefix. Returning ErrStopListing
+// from fn stops the walk and ListAll returns nil.
+func (c *Client) ListAll(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	it := c.List(prefix)
+	for it.Next(ctx) {
+		if err := fn(it.Object()); err != nil {
+			if errors.Is(err, ErrStopListing) {
+				return nil
+			}
+			return err
+		}
+	}
+	return it.Err()
+}
+
+func objectInfoFromListing(obj types.Object) ObjectInfo {
+	return ObjectInfo{
+		Key:          aws.ToString(obj.Key),
+		Size:         aws.ToInt64(obj.Size),
+		ETag:         aws.ToString(obj.ETag),
+		LastModified: aws.ToTime(obj.LastModified),
+		StorageClass: string(obj.StorageClass),
+	}
+}
+
+type ListOption func(*listOptions)
+
+type listOptions struct {
+	presignExpiration time.Duration
+}
+
+// WithPresignedURLs fills ObjectInfo.URL with a presigned URL valid for
+// expiration.
+func WithPresignedURLs(expiration time.Duration) ListOption {
+	return func(o *listOptions) { o.presignExpiration = expiration }
+}
+
+func (c *Client) ListObjectsInfo(ctx context.Context, prefix string, opts ...ListOption) ([]ObjectInfo, error) {
+	o := &listOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	objects := []ObjectInfo{}
+	err := c.ListAll(ctx, prefix, func(obj ObjectInfo) error {
+		if o.presignExpiration > 0 {
+			url, err := c.GetPresignedURL(ctx, obj.Key, o.presignExpiration)
+			if err != nil {
+				return err
+			}
+			obj.URL = url
+		}
+		objects = append(objects, obj)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+type DirectoryListing struct {
+	Prefix  string
+	Files   []ObjectInfo
+	Folders []string
+}
+
+// ListDirectory lists the immediate children of prefix using "/" as the
+// delimiter. Folders holds the common prefixes, each ending with "/".
+func (c *Client) ListDirectory(ctx context.Context, prefix string) (*DirectoryListing, error) {
+	listing := &DirectoryListing{
+		Prefix:  prefix,
+		Files:   []ObjectInfo{},
+		Folders: []string{},
+	}
+
+	paginator := s3.NewListObjectsV2Paginator(c.client, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(c.bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+		for _, obj := range output.Contents {
+			listing.Files = append(listing.Files, objectInfoFromListing(obj))
+		}
+		for _, cp := range output.CommonPrefixes {
+			listing.Folders = append(listing.Folders, aws.ToString(cp.Prefix))
+		}
+	}
+	return listing, nil
+}
@@ -0,0 +1,264 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLogChunkSize     = 8 << 20
+	defaultLogFlushInterval = time.Minute
+	logChunkSuffix          = ".log"
+	// logBufferedChunks is how many chunks are buffered by default while
+	// writing them fails.
+	logBufferedChunks = 4
+)
+
+// ErrLogBufferFull is returned by LogWriter.Write, with nothing written,
+// while so much data waits for a failing upload that p does not fit.
+var ErrLogBufferFull = errors.New("log buffer full")
+
+type LogWriterOption func(*LogWriter)
+
+// WithLogChunkSize sets how many bytes are buffered before a chunk is
+// written, 8 MiB by default. A single Write is never split, so chunks may
+// exceed it by the size of the last write.
+func WithLogChunkSize(n int) LogWriterOption {
+	return func(w *LogWriter) {
+		if n > 0 {
+			w.chunkSize = n
+		}
+	}
+}
+
+// WithLogMaxBuffered caps the bytes buffered while chunks cannot be written,
+// four chunks by default. A Write that does not fit fails with
+// ErrLogBufferFull, unless the buffer is empty.
+func WithLogMaxBuffered(n int) LogWriterOption {
+	return func(w *LogWriter) {
+		if n > 0 {
+			w.maxBuffered = n
+		}
+	}
+}
+
+// WithLogFlushInterval sets how often buffered data is written regardless of
+// its size, every minute by default.
+func WithLogFlushInterval(d time.Duration) LogWriterOption {
+	return func(w *LogWriter) {
+		if d > 0 {
+			w.flushInterval = d
+		}
+	}
+}
+
+// LogWriter appends to a log stored as immutable chunk objects under a
+// prefix, named prefix/YYYYMMDDTHHMMSS.nnnnnnnnnZ-<random>.log so that key
+// order is write order. ReadLog stitches the chunks back into one stream.
+type LogWriter struct {
+	client        *Client
+	prefix        string
+	chunkSize     int
+	maxBuffered   int
+	flushInterval time.Duration
+
+	// flushMu keeps one upload at a time, so chunks are written in order.
+	flushMu sync.Mutex
+	mu      sync.Mutex
+	buf     []byte
+	// uploading is the size of the chunk being written, which counts
+	// against maxBuffered since it is buffered again if the write fails.
+	uploading int
+
+	flushNow chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// LogWriter starts a writer flushing in the background; Close it to write
+// the last chunk.
+func (c *Client) LogWriter(prefix string, opts ...LogWriterOption) *LogWriter {
+	w := &LogWriter{
+		client:        c,
+		prefix:        prefix,
+		chunkSize:     defaultLogChunkSize,
+		flushInterval: defaultLogFlushInterval,
+		flushNow:      make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if w.maxBuffered == 0 {
+		w.maxBuffered = w.chunkSize * logBufferedChunks
+	}
+	if w.prefix != "" && !strings.HasSuffix(w.prefix, "/") {
+		w.prefix += "/"
+	}
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(w.flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-w.flushNow:
+			case <-w.stop:
+				return
+			}
+			if err := w.Flush(context.Background()); err != nil {
+				w.client.logger.Error("failed to flush log chunk", slog.Any("error", err))
+			}
+		}
+	}()
+	return w
+}
+
+// Write buffers p and never waits for an upload: once the buffer reaches
+// the chunk size, the chunk is written in the background, where failures
+// are logged and retried. While too much data waits, Write fails with
+// ErrLogBufferFull and buffers nothing.
+func (w *LogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	buffered := w.uploading + len(w.buf)
+	if buffered > 0 && buffered+len(p) > w.maxBuffered {
+		w.mu.Unlock()
+		return 0, ErrLogBufferFull
+	}
+	w.buf = append(w.buf, p...)
+	full := len(w.buf) >= w.chunkSize
+	w.mu.Unlock()
+
+	if full {
+		select {
+		case w.flushNow <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes the buffered data as a new chunk. Writes go on while it is
+// uploaded; on failure the data is buffered again and retried with the next
+// flush.
+func (w *LogWriter) Flush(ctx context.Context) error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	data := w.buf
+	w.buf = nil
+	w.uploading = len(data)
+	w.mu.Unlock()
+	if len(data) == 0 {
+		return nil
+	}
+
+	err := w.write(ctx, data)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.uploading = 0
+	if err != nil {
+		w.buf = append(data, w.buf...)
+	}
+	return err
+}
+
+func (w *LogWriter) write(ctx context.Context, data []byte) error {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("failed to name log chunk: %w", err)
+	}
+	key := w.prefix + time.Now().UTC().Format("20060102T150405.000000000Z") + "-" + hex.EncodeToString(suffix) + logChunkSuffix
+	if err := w.client.UploadLarge(ctx, key, bytes.NewReader(data), WithContentType("text/plain; charset=utf-8")); err != nil {
+		return fmt.Errorf("failed to write log chunk: %w", err)
+	}
+	return nil
+}
+
+// Close stops the background flushing and writes the remaining data.
+func (w *LogWriter) Close() error {
+	select {
+	case <-w.stop:
+	default:
+		close(w.stop)
+	}
+	<-w.done
+	return w.Flush(context.Background())
+}
+
+// ReadLog returns the chunks under prefix written by LogWriter as one
+// stream, in write order. Chunks flushed after the call are not included.
+func (c *Client) ReadLog(ctx context.Context, prefix string) (io.ReadCloser, error) {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	var keys []string
+	err := c.ListAll(ctx, prefix, func(obj ObjectInfo) error {
+		if strings.HasSuffix(obj.Key, logChunkSuffix) && !strings.Contains(obj.Key[len(prefix):], "/") {
+			keys = append(keys, obj.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &logReader{client: c, ctx: ctx, keys: keys}, nil
+}
+
+// logReader opens one chunk at a time so only a single response body is
+// held open.
+type logReader struct {
+	client *Client
+	ctx    context.Context
+	keys   []string
+	body   io.ReadCloser
+}
+
+func (r *logReader) Read(p []byte) (int, error) {
+	for {
+		if r.body == nil {
+			if len(r.keys) == 0 {
+				return 0, io.EOF
+			}
+			body, _, err := r.client.DownloadFile(r.ctx, r.keys[0])
+			if err != nil {
+				return 0, err
+			}
+			r.body = body
+			r.keys = r.keys[1:]
+		}
+		n, err := r.body.Read(p)
+		if err == io.EOF {
+			r.body.Close()
+			r.body = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *logReader) Close() error {
+	r.keys = nil
+	if r.body != nil {
+		err := r.body.Close()
+		r.body = nil
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,617 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+const memoryEndpoint = "http://s3.memory.local"
+
+// MemoryClient is an in-memory S3Client for tests. Objects live in a map,
+// listings honour prefixes and delimiters, and presigned URLs are
+// deterministic strings that KeyFromURL understands. Errors wrap the same
+// SDK error types and sentinel errors (ErrNotFound, ...) as Client.
+type MemoryClient struct {
+	bucket     string
+	presignTTL time.Duration
+	keyBuilder KeyBuilder
+
+	mu      sync.RWMutex
+	objects map[string]*memoryObject
+}
+
+type memoryObject struct {
+	data []byte
+	info ObjectInfo
+	tags map[string]string
+}
+
+var _ S3Client = (*MemoryClient)(nil)
+
+func NewMemoryClient(bucket string) *MemoryClient {
+	return &MemoryClient{
+		bucket:     bucket,
+		presignTTL: defaultPresignTTL,
+		keyBuilder: defaultKeyBuilder,
+		objects:    make(map[string]*memoryObject),
+	}
+}
+
+func (m *MemoryClient) UploadFile(ctx context.Context, objectID string, key string, body io.Reader, contentType string, opts ...PutOption) (*UploadResult, error) {
+	objectKey := m.keyBuilder(objectID, key)
+	o := newUploadOptions(opts)
+	if o.contentType == "" {
+		o.contentType = contentType
+	}
+	info, err := m.put(objectKey, body, o)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload file to S3: %w", err)
+	}
+	result := m.uploadResult(info)
+	if o.presignExpiration > 0 {
+		if result.URL, err = m.GetPresignedURL(ctx, objectKey, o.presignExpiration); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (m *MemoryClient) UploadLarge(ctx context.Context, key string, r io.Reader, opts ...UploadOption) error {
+	_, err := m.uploadLarge(ctx, key, r, opts...)
+	return err
+}
+
+func (m *MemoryClient) uploadLarge(ctx context.Context, key string, r io.Reader, opts ...UploadOption) (*UploadResult, error) {
+	info, err := m.put(key, r, newUploadOptions(opts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload file to S3: %w", err)
+	}
+	return m.uploadResult(info), nil
+}
+
+func (m *MemoryClient) uploadResult(info ObjectInfo) *UploadResult {
+	return &UploadResult{
+		Key:       info.Key,
+		ETag:      info.ETag,
+		VersionID: info.VersionID,
+		Size:      info.Size,
+		Location:  m.ObjectURL(info.Key),
+	}
+}
+
+// ObjectURL returns the unsigned URL of key on the fake endpoint.
+func (m *MemoryClient) ObjectURL(key string) string {
+	return memoryEndpoint + "/" + m.bucket + "/" + escapeKey(key)
+}
+
+func (m *MemoryClient) put(key string, r io.Reader, o *uploadOptions) (ObjectInfo, error) {
+	progress := newProgressTracker(o.progress, readerSize(r))
+	data, err := io.ReadAll(progress.reader(r))
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	progress.finish()
+	if o.contentType == "" {
+		o.contentType = DetectContentType(key, data[:min(len(data), sniffLen)])
+	}
+
+	sum := md5.Sum(data)
+	obj := &memoryObject{
+		data: data,
+		info: ObjectInfo{
+			Key:                key,
+			Size:               int64(len(data)),
+			ContentType:        o.contentType,
+			ETag:               `"` + hex.EncodeToString(sum[:]) + `"`,
+			LastModified:       time.Now().UTC(),
+			StorageClass:       string(o.storageClass),
+			CacheControl:       o.cacheControl,
+			ContentDisposition: o.contentDisposition,
+			ContentEncoding:    o.contentEncoding,
+			Metadata:           copyMap(o.metadata),
+
+			ServerSideEncryption: string(o.sse),
+			KMSKeyID:             o.kmsKeyID,
+		},
+		tags: copyMap(o.tags),
+	}
+	if obj.info.StorageClass == "" {
+		obj.info.StorageClass = string(types.StorageClassStandard)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if current, ok := m.objects[key]; ok && o.ifNoneMatch {
+		return ObjectInfo{}, errPreconditionFailed
+	} else if o.ifMatch != "" && (!ok || current.info.ETag != o.ifMatch) {
+		return ObjectInfo{}, errPreconditionFailed
+	}
+	m.objects[key] = obj
+	return obj.info, nil
+}
+
+func (m *MemoryClient) get(key string) (*memoryObject, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	obj, ok := m.objects[key]
+	if !ok {
+		return nil, wrapAPIError(&types.NoSuchKey{Message: aws.String("The specified key does not exist.")})
+	}
+	return obj, nil
+}
+
+func (m *MemoryClient) DownloadFile(ctx context.Context, key string, opts ...DownloadOption) (io.ReadCloser, *ObjectInfo, error) {
+	obj, err := m.get(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download file from S3: %w", err)
+	}
+	o := newDownloadOptions(opts)
+	if err := checkRead(obj, o); err != nil {
+		return nil, nil, fmt.Errorf("failed to download file from S3: %w", err)
+	}
+	return m.reader(obj.data, o), obj.objectInfo(), nil
+}
+
+func (m *MemoryClient) DownloadRange(ctx context.Context, key string, offset, length int64, opts ...DownloadOption) (io.ReadCloser, *ObjectInfo, error) {
+	if offset < 0 {
+		return nil, nil, errors.New("offset must not be negative")
+	}
+	obj, err := m.get(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download range from S3: %w", err)
+	}
+	o := newDownloadOptions(opts)
+	if err := checkRead(obj, o); err != nil {
+		return nil, nil, fmt.Errorf("failed to download range from S3: %w", err)
+	}
+	size := int64(len(obj.data))
+	if offset >= size && size > 0 {
+		return nil, nil, fmt.Errorf("failed to download range from S3: range %s not satisfiable", byteRange(offset, length))
+	}
+	end := size
+	if length > 0 && offset+length < size {
+		end = offset + length
+	}
+	return m.reader(obj.data[offset:end], o), obj.objectInfo(), nil
+}
+
+func (m *MemoryClient) reader(data []byte, o *downloadOptions) io.ReadCloser {
+	progress := newProgressTracker(o.progress, int64(len(data)))
+	return progress.readCloser(io.NopCloser(bytes.NewReader(data)))
+}
+
+func (m *MemoryClient) DownloadResumable(ctx context.Context, key string, w io.WriterAt, cp *DownloadCheckpoint, opts ...DownloadOption) error {
+	if cp == nil {
+		return errors.New("download checkpoint is required")
+	}
+	o := newDownloadOptions(opts)
+	if o.ifMatch != "" && cp.ETag != "" && o.ifMatch != cp.ETag {
+		return fmt.Errorf("%w: If-Match %s, checkpoint ETag %s", ErrPreconditionFailed, o.ifMatch, cp.ETag)
+	}
+	obj, err := m.get(key)
+	if err != nil {
+		return fmt.Errorf("failed to download file from S3: %w", err)
+	}
+	if err := checkRead(obj, o); err != nil {
+		return fmt.Errorf("failed to download file from S3: %w", err)
+	}
+	if cp.ETag == "" {
+		cp.ETag = obj.info.ETag
+		cp.Size = obj.info.Size
+	} else if cp.ETag != obj.info.ETag {
+		return fmt.Errorf("object %q changed during download: etag %s, expected %s", key, obj.info.ETag, cp.ETag)
+	}
+	if cp.Offset >= cp.Size {
+		return nil
+	}
+	n, err := io.Copy(io.NewOffsetWriter(w, cp.Offset), m.reader(obj.data[cp.Offset:], o))
+	cp.Offset += n
+	if err != nil {
+		return fmt.Errorf("failed to read object body: %w", err)
+	}
+	return nil
+}
+
+func (m *MemoryClient) DownloadLarge(ctx context.Context, key string, w io.WriterAt, opts ...DownloadOption) (int64, error) {
+	obj, err := m.get(key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get object info: %w", err)
+	}
+	o := newDownloadOptions(opts)
+	if err := checkRead(obj, o); err != nil {
+		return 0, fmt.Errorf("failed to get object info: %w", err)
+	}
+	n, err := io.Copy(io.NewOffsetWriter(w, 0), m.reader(obj.data, o))
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (m *MemoryClient) DeleteFile(ctx context.Context, key string, opts ...DeleteOption) error {
+	o := &deleteOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if o.ifMatch != "" {
+		if obj, ok := m.objects[key]; !ok || obj.info.ETag != o.ifMatch {
+			return fmt.Errorf("failed to delete file from S3: %w", errPreconditionFailed)
+		}
+	}
+	delete(m.objects, key)
+	return nil
+}
+
+func (m *MemoryClient) DeleteFiles(ctx context.Context, keys []string) (*DeleteResult, error) {
+	result := &DeleteResult{}
+	m.mu.Lock()
+	for _, key := range keys {
+		delete(m.objects, key)
+		result.Deleted = append(result.Deleted, key)
+	}
+	m.mu.Unlock()
+	return result, nil
+}
+
+func (m *MemoryClient) DeletePrefix(ctx context.Context, prefix string) (*DeleteResult, error) {
+	return m.DeleteFiles(ctx, m.keys(prefix))
+}
+
+func (m *MemoryClient) CopyFile(ctx context.Context, srcKey, dstKey string, opts ...CopyOption) error {
+	o := &copyOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	src, ok := m.objects[srcKey]
+	if !ok {
+		return fmt.Errorf("failed to get source object info: %w", wrapAPIError(&types.NotFound{}))
+	}
+	if err := checkRead(src, &downloadOptions{ifMatch: o.ifMatch, ifUnmodifiedSince: o.ifUnmodifiedSince}); err != nil {
+		return fmt.Errorf("failed to get source object info: %w", err)
+	}
+	dst := *src
+	dst.info.Key = dstKey
+	dst.info.LastModified = time.Now().UTC()
+	dst.info.Metadata = copyMap(src.info.Metadata)
+	dst.tags = copyMap(src.tags)
+	m.objects[dstKey] = &dst
+	return nil
+}
+
+func (m *MemoryClient) MoveFile(ctx context.Context, srcKey, dstKey string) error {
+	if srcKey == dstKey {
+		return nil
+	}
+	if err := m.CopyFile(ctx, srcKey, dstKey); err != nil {
+		return err
+	}
+	return m.DeleteFile(ctx, srcKey)
+}
+
+func (m *MemoryClient) RenamePrefix(ctx context.Context, oldPrefix, newPrefix string, opts ...RenameOption) (*RenameResult, error) {
+	o := &renameOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	result := &RenameResult{DryRun: o.dryRun}
+	if oldPrefix == newPrefix {
+		return result, nil
+	}
+	if strings.HasPrefix(newPrefix, oldPrefix) {
+		return nil, fmt.Errorf("new prefix %q must not be nested in old prefix %q", newPrefix, oldPrefix)
+	}
+
+	keys := m.keys(oldPrefix)
+	for i, key := range keys {
+		renamed := RenamedObject{From: key, To: newPrefix + strings.TrimPrefix(key, oldPrefix)}
+		if !o.dryRun {
+			if err := m.MoveFile(ctx, renamed.From, renamed.To); err != nil {
+				result.Failed = append(result.Failed, RenameError{RenamedObject: renamed, Err: err})
+				continue
+			}
+			if o.progress != nil {
+				o.progress(i+1, len(keys))
+			}
+		}
+		result.Renamed = append(result.Renamed, renamed)
+	}
+	return result, nil
+}
+
+func (m *MemoryClient) FileExists(ctx context.Context, key string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.objects[key]
+	return ok, nil
+}
+
+func (m *MemoryClient) GetObjectInfo(ctx context.Context, key string) (*ObjectInfo, error) {
+	obj, err := m.get(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object info: %w", err)
+	}
+	return obj.objectInfo(), nil
+}
+
+func (m *MemoryClient) UpdateMetadata(ctx context.Context, key string, meta map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	obj, ok := m.objects[key]
+	if !ok {
+		return fmt.Errorf("failed to get object info: %w", wrapAPIError(&types.NotFound{}))
+	}
+	obj.info.Metadata = copyMap(meta)
+	obj.info.LastModified = time.Now().UTC()
+	return nil
+}
+
+func (m *MemoryClient) SetTags(ctx context.Context, key string, tags map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	obj, ok := m.objects[key]
+	if !ok {
+		return fmt.Errorf("failed to set object tags: %w", wrapAPIError(&types.NoSuchKey{}))
+	}
+	obj.tags = copyMap(tags)
+	return nil
+}
+
+func (m *MemoryClient) GetTags(ctx context.Context, key string) (map[string]string, error) {
+	obj, err := m.get(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object tags: %w", err)
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tags := copyMap(obj.tags)
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	return tags, nil
+}
+
+func (m *MemoryClient) DeleteTags(ctx context.Context, key string) error {
+	return m.SetTags(ctx, key, nil)
+}
+
+func (m *MemoryClient) List(prefix string) *ListIterator {
+	return NewListIterator(m.infos(prefix))
+}
+
+func (m *MemoryClient) ListAll(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	for _, info := range m.infos(prefix) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(info); err != nil {
+			if errors.Is(err, ErrStopListing) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MemoryClient) ListObjectsInfo(ctx context.Context, prefix string, opts ...ListOption) ([]ObjectInfo, error) {
+	o := &listOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	objects := m.infos(prefix)
+	if o.presignExpiration > 0 {
+		for i := range objects {
+			objects[i].URL, _ = m.GetPresignedURL(ctx, objects[i].Key, o.presignExpiration)
+		}
+	}
+	return objects, nil
+}
+
+func (m *MemoryClient) ListDirectory(ctx context.Context, prefix string) (*DirectoryListing, error) {
+	listing := &DirectoryListing{
+		Prefix:  prefix,
+		Files:   []ObjectInfo{},
+		Folders: []string{},
+	}
+	seen := make(map[string]bool)
+	for _, info := range m.infos(prefix) {
+		rest := strings.TrimPrefix(info.Key, prefix)
+		if i := strings.Index(rest, "/"); i >= 0 {
+			folder := prefix + rest[:i+1]
+			if !seen[folder] {
+				seen[folder] = true
+				listing.Folders = append(listing.Folders, folder)
+			}
+			continue
+		}
+		listing.Files = append(listing.Files, info)
+	}
+	return listing, nil
+}
+
+func (m *MemoryClient) GetObjects(ctx context.Context, prefix string) ([]string, error) {
+	urls := []string{}
+	for _, key := range m.keys(prefix) {
+		url, err := m.GetPresignedURL(ctx, key, m.presignTTL)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	return urls, nil
+}
+
+// GetPresignedURL returns memoryEndpoint/bucket/key with the expiration and
+// any response overrides as query parameters. No signature is included.
+func (m *MemoryClient) GetPresignedURL(ctx context.Context, key string, expiration time.Duration, opts ...PresignOption) (string, error) {
+	input := &s3.GetObjectInput{}
+	for _, opt := range opts {
+		opt(input)
+	}
+	query := url.Values{}
+	query.Set("X-Amz-Expires", fmt.Sprint(int64(expiration/time.Second)))
+	if input.ResponseContentDisposition != nil {
+		query.Set("response-content-disposition", *input.ResponseContentDisposition)
+	}
+	if input.ResponseContentType != nil {
+		query.Set("response-content-type", *input.ResponseContentType)
+	}
+	if input.ResponseCacheControl != nil {
+		query.Set("response-cache-control", *input.ResponseCacheControl)
+	}
+	u := url.URL{
+		Scheme:   "http",
+		Host:     strings.TrimPrefix(memoryEndpoint, "http://"),
+		Path:     "/" + m.bucket + "/" + key,
+		RawQuery: query.Encode(),
+	}
+	return u.String(), nil
+}
+
+func (m *MemoryClient) PresignPostPolicy(ctx context.Context, opts PostPolicyOptions) (*PresignedPost, error) {
+	if opts.Key == "" && opts.KeyPrefix == "" {
+		return nil, errors.New("either key or key prefix is required")
+	}
+	if opts.Expires <= 0 {
+		opts.Expires = m.presignTTL
+	}
+	fields := copyMap(opts.Fields)
+	if fields == nil {
+		fields = map[string]string{}
+	}
+	if opts.Key != "" {
+		fields["key"] = opts.Key
+	}
+	if opts.ContentType != "" {
+		fields["Content-Type"] = opts.ContentType
+	}
+	return &PresignedPost{
+		URL:     memoryEndpoint + "/" + m.bucket,
+		Fields:  fields,
+		Expires: time.Now().UTC().Add(opts.Expires),
+	}, nil
+}
+
+func (m *MemoryClient) KeyFromURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse URL: %w", err)
+	}
+	if u.Scheme+"://"+u.Host != memoryEndpoint {
+		return "", fmt.Errorf("URL host %q does not match the client endpoint", u.Host)
+	}
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if bucket != m.bucket {
+		return "", fmt.Errorf("URL points to bucket %q, expected %q", bucket, m.bucket)
+	}
+	if key == "" {
+		return "", errors.New("URL does not contain an object key")
+	}
+	return key, nil
+}
+
+func (m *MemoryClient) FindKeyByPresignedURL(ctx context.Context, presignedURL string, prefix string) (string, error) {
+	key, err := m.KeyFromURL(presignedURL)
+	if err != nil || !strings.HasPrefix(key, prefix) {
+		return "", fmt.Errorf("object not found for the given presigned URL")
+	}
+	return key, nil
+}
+
+func (m *MemoryClient) Bucket() string   { return m.bucket }
+func (m *MemoryClient) Endpoint() string { return memoryEndpoint }
+
+func (m *MemoryClient) keys(prefix string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys := make([]string, 0, len(m.objects))
+	for key := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (m *MemoryClient) infos(prefix string) []ObjectInfo {
+	keys := m.keys(prefix)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	infos := make([]ObjectInfo, 0, len(keys))
+	for _, key := range keys {
+		if obj, ok := m.objects[key]; ok {
+			infos = append(infos, ObjectInfo{
+				Key:          obj.info.Key,
+				Size:         obj.info.Size,
+				ETag:         obj.info.ETag,
+				LastModified: obj.info.LastModified,
+				StorageClass: obj.info.StorageClass,
+			})
+		}
+	}
+	return infos
+}
+
+func (obj *memoryObject) objectInfo() *ObjectInfo {
+	info := obj.info
+	info.Metadata = copyMap(obj.info.Metadata)
+	return &info
+}
+
+var (
+	errPreconditionFailed = wrapAPIError(&smithy.GenericAPIError{Code: "PreconditionFailed", Message: "At least one of the pre-conditions you specified did not hold"})
+	errNotModified        = wrapAPIError(&smithy.GenericAPIError{Code: "NotModified", Message: "Not Modified"})
+)
+
+// checkRead evaluates GET/HEAD preconditions with S3's precedence rules:
+// If-Match overrides If-Unmodified-Since and If-None-Match overrides
+// If-Modified-Since.
+func checkRead(obj *memoryObject, o *downloadOptions) error {
+	modified := obj.info.LastModified
+	if o.ifMatch != "" {
+		if o.ifMatch != obj.info.ETag {
+			return errPreconditionFailed
+		}
+	} else if !o.ifUnmodifiedSince.IsZero() && modified.After(o.ifUnmodifiedSince) {
+		return errPreconditionFailed
+	}
+	if o.ifNoneMatch != "" {
+		if o.ifNoneMatch == obj.info.ETag {
+			return errNotModified
+		}
+	} else if !o.ifModifiedSince.IsZero() && !modified.After(o.ifModifiedSince) {
+		return errNotModified
+	}
+	return nil
+}
+
+func copyMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,130 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func (c *Client) GetObjectInfo(ctx context.Context, key string) (*ObjectInfo, error) {
+	head, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object info: %w", err)
+	}
+	return objectInfoFromHead(key, head), nil
+}
+
+// UpdateMetadata replaces the user metadata of key. S3 objects are immutable,
+// so this copies the object onto itself with MetadataDirective=REPLACE and
+// carries over the system headers that REPLACE would otherwise reset.
+func (c *Client) UpdateMetadata(ctx context.Context, key string, meta map[string]string) error {
+	head, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get object info: %w", err)
+	}
+
+	if aws.ToInt64(head.ContentLength) > maxCopyObjectSize {
+		updated := *head
+		updated.Metadata = meta
+		tags, err := c.objectTags(ctx, key, head.VersionId)
+		if err != nil {
+			return err
+		}
+		return c.multipartCopy(ctx, copySource(c.bucket, key), key, &updated, tags)
+	}
+
+	sse, kmsKeyID := c.copyEncryption(head)
+	_, err = c.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:               aws.String(c.bucket),
+		Key:                  aws.String(key),
+		CopySource:           aws.String(copySource(c.bucket, key)),
+		CopySourceIfMatch:    head.ETag,
+		MetadataDirective:    types.MetadataDirectiveReplace,
+		Metadata:             meta,
+		ContentType:          head.ContentType,
+		CacheControl:         head.CacheControl,
+		ContentDisposition:   head.ContentDisposition,
+		ContentEncoding:      head.ContentEncoding,
+		ContentLanguage:      head.ContentLanguage,
+		Expires:              head.Expires,
+		StorageClass:         head.StorageClass,
+		ServerSideEncryption: sse,
+		SSEKMSKeyId:          kmsKeyID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update object metadata: %w", err)
+	}
+	return nil
+}
+
+// ChangeStorageClass moves key to another storage class by copying the
+// object onto itself; metadata, headers and tags are kept. Archived objects
+// (GLACIER, DEEP_ARCHIVE) must be restored before they can be copied.
+func (c *Client) ChangeStorageClass(ctx context.Context, key string, class types.StorageClass) error {
+	head, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get object info: %w", err)
+	}
+
+	if aws.ToInt64(head.ContentLength) > maxCopyObjectSize {
+		updated := *head
+		updated.StorageClass = class
+		tags, err := c.objectTags(ctx, key, head.VersionId)
+		if err != nil {
+			return err
+		}
+		return c.multipartCopy(ctx, copySource(c.bucket, key), key, &updated, tags)
+	}
+
+	sse, kmsKeyID := c.copyEncryption(head)
+	_, err = c.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:               aws.String(c.bucket),
+		Key:                  aws.String(key),
+		CopySource:           aws.String(copySource(c.bucket, key)),
+		CopySourceIfMatch:    head.ETag,
+		StorageClass:         class,
+		ServerSideEncryption: sse,
+		SSEKMSKeyId:          kmsKeyID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to change storage class: %w", err)
+	}
+	return nil
+}
+
+func objectInfoFromHead(key string, head *s3.HeadObjectOutput) *ObjectInfo {
+	return &ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(head.ContentLength),
+		ContentType:  aws.ToString(head.ContentType),
+		ETag:         aws.ToString(head.ETag),
+		LastModified: aws.ToTime(head.LastModified),
+		StorageClass: string(head.StorageClass),
+
+		CacheControl:       aws.ToString(head.CacheControl),
+		ContentDisposition: aws.ToString(head.ContentDisposition),
+		ContentEncoding:    aws.ToString(head.ContentEncoding),
+		Metadata:           head.Metadata,
+
+		ServerSideEncryption: string(head.ServerSideEncryption),
+		KMSKeyID:             aws.ToString(head.SSEKMSKeyId),
+		VersionID:            aws.ToString(head.VersionId),
+
+		ObjectLockMode: string(head.ObjectLockMode),
+		RetainUntil:    aws.ToTime(head.ObjectLockRetainUntilDate),
+		LegalHold:      head.ObjectLockLegalHoldStatus == types.ObjectLockLegalHoldStatusOn,
+		RequestCharged: head.RequestCharged == types.RequestChargedRequester,
+	}
+}
@@ -0,0 +1,87 @@
+package s3
+
+import (
+	"context"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// RequestMetrics describes a single S3 API call, including all its retries.
+type RequestMetrics struct {
+	Operation  string
+	Bucket     string
+	Duration   time.Duration
+	StatusCode int
+	Err        error
+	// BytesSent is the request body size. BytesReceived is the response
+	// Content-Length, so for GetObject it is the size of the body the server
+	// sent, whether or not the caller reads all of it.
+	BytesSent     int64
+	BytesReceived int64
+}
+
+// MetricsRecorder receives a sample per S3 API call. Implementations must be
+// safe for concurrent use; a Prometheus adapter typically feeds a counter
+// vector, an error counter vector and a latency histogram labelled by
+// Operation, plus byte counters.
+type MetricsRecorder interface {
+	RecordRequest(ctx context.Context, m RequestMetrics)
+}
+
+func addMetrics(recorder MetricsRecorder) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		if isPresignStack(stack) {
+			return nil
+		}
+		if err := stack.Initialize.Add(&metricsMiddleware{recorder: recorder}, middleware.After); err != nil {
+			return err
+		}
+		return stack.Build.Add(&metricsSizeMiddleware{}, middleware.After)
+	}
+}
+
+type metricsSampleKey struct{}
+
+type metricsMiddleware struct {
+	recorder MetricsRecorder
+}
+
+func (*metricsMiddleware) ID() string { return "go-s3.Metrics" }
+
+func (m *metricsMiddleware) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	bucket, _ := operationTarget(in.Parameters)
+	sample := &RequestMetrics{
+		Operation: awsmiddleware.GetOperationName(ctx),
+		Bucket:    bucket,
+	}
+	ctx = middleware.WithStackValue(ctx, metricsSampleKey{}, sample)
+
+	start := time.Now()
+	out, metadata, err := next.HandleInitialize(ctx, in)
+	sample.Duration = time.Since(start)
+	sample.Err = err
+	if resp, ok := awsmiddleware.GetRawResponse(metadata).(*smithyhttp.Response); ok {
+		sample.StatusCode = resp.StatusCode
+		if resp.ContentLength > 0 {
+			sample.BytesReceived = resp.ContentLength
+		}
+	}
+
+	m.recorder.RecordRequest(ctx, *sample)
+	return out, metadata, err
+}
+
+type metricsSizeMiddleware struct{}
+
+func (*metricsSizeMiddleware) ID() string { return "go-s3.MetricsSize" }
+
+func (*metricsSizeMiddleware) HandleBuild(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+	sample, _ := middleware.GetStackValue(ctx, metricsSampleKey{}).(*RequestMetrics)
+	if req, ok := in.Request.(*smithyhttp.Request); ok && sample != nil && req.ContentLength > 0 {
+		sample.BytesSent = req.ContentLength
+	}
+	return next.HandleBuild(ctx, in)
+}
@@ -0,0 +1,232 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+type MirrorOption func(*mirrorOptions)
+
+type mirrorOptions struct {
+	concurrency int
+}
+
+func WithMirrorConcurrency(n int) MirrorOption {
+	return func(o *mirrorOptions) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+type MirrorError struct {
+	Key string
+	Err error
+}
+
+func (e MirrorError) Error() string {
+	return fmt.Sprintf("failed to mirror %s: %v", e.Key, e.Err)
+}
+
+func (e MirrorError) Unwrap() error { return e.Err }
+
+type MirrorResult struct {
+	Copied []RenamedObject
+	// Skipped counts objects already present in the destination.
+	Skipped int
+	Failed  []MirrorError
+}
+
+// MirrorPrefix copies every object under srcPrefix to dstPrefix in the bucket
+// of dst. When both clients talk to the same endpoint and region the copy is
+// server-side, which requires dst's credentials to be able to read the
+// source; otherwise objects are streamed from one client to the other with
+// their headers and metadata. Each copy records the source ETag in its
+// metadata, as ReplicatedClient does. Objects whose copy already exists with
+// the same size and ETag, or with the same size and the source ETag
+// recorded, are skipped, so an interrupted run resumes where it stopped;
+// the ETags of the copy need not match under other encryption, part sizes
+// or providers.
+func (c *Client) MirrorPrefix(ctx context.Context, dst *Client, srcPrefix, dstPrefix string, opts ...MirrorOption) (*MirrorResult, error) {
+	o := &mirrorOptions{concurrency: defaultConcurrency}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	existing := make(map[string]ObjectInfo)
+	err := dst.ListAll(ctx, dstPrefix, func(obj ObjectInfo) error {
+		existing[obj.Key] = obj
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MirrorResult{}
+	var objects []mirrorJob
+	err = c.ListAll(ctx, srcPrefix, func(obj ObjectInfo) error {
+		copied, ok := existing[dstPrefix+strings.TrimPrefix(obj.Key, srcPrefix)]
+		switch {
+		case ok && obj.Size == copied.Size && obj.ETag == copied.ETag:
+			result.Skipped++
+		default:
+			objects = append(objects, mirrorJob{obj: obj, check: ok && obj.Size == copied.Size})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	serverSide := c.endpoint == dst.endpoint && c.region == dst.region
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	jobs := make(chan mirrorJob)
+	for i := 0; i < o.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				obj := job.obj
+				copied := RenamedObject{
+					From: obj.Key,
+					To:   dstPrefix + strings.TrimPrefix(obj.Key, srcPrefix),
+				}
+				if job.check && dst.copiedFrom(ctx, copied.To, obj.ETag) {
+					mu.Lock()
+					result.Skipped++
+					mu.Unlock()
+					continue
+				}
+				var err error
+				if serverSide {
+					err = c.mirrorServerSide(ctx, dst, obj.Key, copied.To)
+				} else {
+					err = c.mirrorStreamed(ctx, dst, obj.Key, copied.To)
+				}
+
+				mu.Lock()
+				if err != nil {
+					result.Failed = append(result.Failed, MirrorError{Key: obj.Key, Err: err})
+				} else {
+					result.Copied = append(result.Copied, copied)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, job := range objects {
+		select {
+		case jobs <- job:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return result, ctx.Err()
+}
+
+// mirrorServerSide copies the object with a request from dst, so the copy
+// gets dst's default encryption.
+func (c *Client) mirrorServerSide(ctx context.Context, dst *Client, srcKey, dstKey string) error {
+	head, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(srcKey),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get source object info: %w", err)
+	}
+
+	source := copySource(c.bucket, srcKey)
+	meta := withSourceETag(head.Metadata, aws.ToString(head.ETag))
+	if aws.ToInt64(head.ContentLength) > maxCopyObjectSize {
+		tags, err := c.objectTags(ctx, srcKey, head.VersionId)
+		if err != nil {
+			return err
+		}
+		marked := *head
+		marked.Metadata = meta
+		return dst.multipartCopy(ctx, source, dstKey, &marked, tags)
+	}
+	sse, kmsKeyID := dst.copyEncryption(head)
+	// REPLACE to add the source ETag; the headers it would reset are
+	// carried over from head.
+	_, err = dst.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:                  aws.String(dst.bucket),
+		Key:                     aws.String(dstKey),
+		CopySource:              aws.String(source),
+		CopySourceIfMatch:       head.ETag,
+		MetadataDirective:       types.MetadataDirectiveReplace,
+		Metadata:                meta,
+		ContentType:             head.ContentType,
+		CacheControl:            head.CacheControl,
+		ContentDisposition:      head.ContentDisposition,
+		ContentEncoding:         head.ContentEncoding,
+		ContentLanguage:         head.ContentLanguage,
+		Expires:                 head.Expires,
+		WebsiteRedirectLocation: head.WebsiteRedirectLocation,
+		ServerSideEncryption:    sse,
+		SSEKMSKeyId:             kmsKeyID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy file in S3: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) mirrorStreamed(ctx context.Context, dst *Client, srcKey, dstKey string) error {
+	body, info, err := c.DownloadFile(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	return dst.UploadLarge(ctx, dstKey, body,
+		WithContentType(info.ContentType),
+		WithCacheControl(info.CacheControl),
+		WithContentDisposition(info.ContentDisposition),
+		WithContentEncoding(info.ContentEncoding),
+		WithMetadata(withSourceETag(info.Metadata, info.ETag)),
+	)
+}
+
+type mirrorJob struct {
+	obj ObjectInfo
+	// check is set when a copy of the same size exists under another
+	// ETag, so it may still be a copy of this source version.
+	check bool
+}
+
+// withSourceETag returns meta with etag recorded as the source ETag.
+func withSourceETag(meta map[string]string, etag string) map[string]string {
+	marked := make(map[string]string, len(meta)+1)
+	for k, v := range meta {
+		if !strings.EqualFold(k, replicationSourceETag) {
+			marked[k] = v
+		}
+	}
+	marked[replicationSourceETag] = etag
+	return marked
+}
+
+// copiedFrom reports whether the object at key records sourceETag as the
+// ETag it was copied from. A failed HEAD counts as a mismatch.
+func (c *Client) copiedFrom(ctx context.Context, key, sourceETag string) bool {
+	info, err := c.GetObjectInfo(ctx, key)
+	if err != nil {
+		return false
+	}
+	return metadataValue(info.Metadata, replicationSourceETag) == sourceETag
+}
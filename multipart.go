@@ -0,0 +1,272 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	minPartSize        = 5 * 1024 * 1024
+	maxPartSize        = 5 * 1024 * 1024 * 1024
+	defaultPartSize    = 8 * 1024 * 1024
+	defaultConcurrency = 4
+	maxUploadParts     = 10000
+	abortTimeout       = 30 * time.Second
+)
+
+// UploadLarge uploads r using the multipart API. Bodies up to the multipart
+// threshold, one part by default, are sent with a plain PutObject. The body
+// is streamed through pooled buffers, so an upload of any length holds at
+// most the threshold plus one part per concurrent request in memory. On
+// failure the multipart upload is aborted so no orphaned parts are left
+// behind.
+func (c *Client) UploadLarge(ctx context.Context, key string, r io.Reader, opts ...UploadOption) error {
+	_, err := c.uploadLarge(ctx, key, r, opts...)
+	return err
+}
+
+// uploadLarge is UploadLarge returning what S3 reports for the stored object.
+func (c *Client) uploadLarge(ctx context.Context, key string, r io.Reader, opts ...UploadOption) (*UploadResult, error) {
+	o := c.newUploadOptions(opts)
+	if o.partSize < minPartSize || o.partSize > maxPartSize {
+		return nil, fmt.Errorf("part size must be between %d and %d bytes", minPartSize, maxPartSize)
+	}
+	threshold := o.multipartThreshold
+	if threshold <= 0 {
+		threshold = o.partSize
+	}
+	if threshold > maxPartSize {
+		return nil, fmt.Errorf("multipart threshold must not exceed %d bytes", maxPartSize)
+	}
+	if o.contentType == "" {
+		var err error
+		if o.contentType, r, err = c.sniffContentType(key, r); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.validation.checkContentType(o.contentType); err != nil {
+		return nil, err
+	}
+	validated := c.validation.body(r)
+	if validated != nil {
+		defer validated.close()
+		r = validated
+	}
+	body, err := o.compress(r, o.contentType)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	counted := &countingReader{r: body}
+	r = counted
+
+	progress := newProgressTracker(o.progress, readerSize(r))
+
+	first := getBuffer(threshold)
+	defer putBuffer(first)
+	n, err := io.ReadFull(r, first)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		input := &s3.PutObjectInput{
+			Bucket: aws.String(c.bucket),
+			Key:    aws.String(key),
+			Body:   bytes.NewReader(first[:n]),
+		}
+		o.applyPut(input)
+		if err := validated.verdict(); err != nil {
+			return nil, err
+		}
+		output, err := c.client.PutObject(ctx, input, append(progress.apiOptions(), o.conditionalWrite()...)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload file to S3: %w", err)
+		}
+		progress.finish()
+		return c.uploadResult(key, output.ETag, output.VersionId, int64(n)), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload body: %w", err)
+	}
+
+	createInput := &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	}
+	o.applyCreateMultipart(createInput)
+	created, err := c.client.CreateMultipartUpload(ctx, createInput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create multipart upload: %w", err)
+	}
+	uploadID := aws.ToString(created.UploadId)
+
+	parts, err := c.uploadParts(ctx, key, uploadID, io.MultiReader(bytes.NewReader(first), r), o, progress)
+	if err != nil {
+		c.abortMultipartUpload(key, uploadID)
+		return nil, err
+	}
+	if err := validated.verdict(); err != nil {
+		c.abortMultipartUpload(key, uploadID)
+		return nil, err
+	}
+
+	completed, err := c.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(c.bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	}, o.conditionalWrite()...)
+	if err != nil {
+		c.abortMultipartUpload(key, uploadID)
+		return nil, fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	progress.finish()
+	return c.uploadResult(key, completed.ETag, completed.VersionId, counted.n), nil
+}
+
+func (c *Client) uploadParts(ctx context.Context, key, uploadID string, r io.Reader, o *uploadOptions, progress *progressTracker) ([]types.CompletedPart, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		parts    []types.CompletedPart
+		firstErr error
+	)
+	setErr := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	buffers := make(chan []byte, o.concurrency)
+	allocated := 0
+	nextBuffer := func() []byte {
+		select {
+		case buf := <-buffers:
+			return buf
+		default:
+		}
+		if allocated < o.concurrency {
+			allocated++
+			return getBuffer(o.partSize)
+		}
+		select {
+		case buf := <-buffers:
+			return buf
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	for partNumber := int32(1); ; partNumber++ {
+		buf := nextBuffer()
+		if buf == nil {
+			break
+		}
+		n, err := io.ReadFull(r, buf)
+		if errors.Is(err, io.EOF) {
+			buffers <- buf
+			break
+		}
+		last := errors.Is(err, io.ErrUnexpectedEOF)
+		if err != nil && !last {
+			buffers <- buf
+			setErr(fmt.Errorf("failed to read upload body: %w", err))
+			break
+		}
+		if partNumber > maxUploadParts {
+			buffers <- buf
+			setErr(fmt.Errorf("upload exceeds %d parts, increase the part size", maxUploadParts))
+			break
+		}
+
+		wg.Add(1)
+		go func(partNumber int32, buf []byte, data []byte) {
+			defer wg.Done()
+			defer func() { buffers <- buf }()
+
+			output, err := c.client.UploadPart(ctx, &s3.UploadPartInput{
+				Bucket:            aws.String(c.bucket),
+				Key:               aws.String(key),
+				UploadId:          aws.String(uploadID),
+				PartNumber:        aws.Int32(partNumber),
+				Body:              bytes.NewReader(data),
+				ContentLength:     aws.Int64(int64(len(data))),
+				ChecksumAlgorithm: o.checksumAlgorithm(),
+			}, progress.apiOptions()...)
+			if err != nil {
+				setErr(fmt.Errorf("failed to upload part %d: %w", partNumber, err))
+				return
+			}
+
+			part := types.CompletedPart{
+				ETag:       output.ETag,
+				PartNumber: aws.Int32(partNumber),
+			}
+			partChecksum(&part, output)
+			mu.Lock()
+			parts = append(parts, part)
+			mu.Unlock()
+		}(partNumber, buf, buf[:n])
+
+		if last {
+			break
+		}
+	}
+
+	wg.Wait()
+	for len(buffers) > 0 {
+		putBuffer(<-buffers)
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(parts, func(i, j int) bool {
+		return aws.ToInt32(parts[i].PartNumber) < aws.ToInt32(parts[j].PartNumber)
+	})
+	return parts, nil
+}
+
+// abortMultipartUpload uses a fresh context because the caller's context is
+// often the reason the upload failed in the first place.
+func (c *Client) abortMultipartUpload(key, uploadID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), abortTimeout)
+	defer cancel()
+
+	_, err := c.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(c.bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	if err != nil {
+		c.logger.ErrorContext(ctx, "failed to abort multipart upload",
+			slog.String("key", key),
+			slog.String("upload_id", uploadID),
+			slog.Any("error", err),
+		)
+	}
+}
+
+func stringOrNil(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return aws.String(s)
+}
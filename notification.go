@@ -0,0 +1,138 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	EventObjectCreated types.Event = "s3:ObjectCreated:*"
+	EventObjectRemoved types.Event = "s3:ObjectRemoved:*"
+)
+
+// NotificationRule sends the events of objects matching Prefix and Suffix to
+// ARN.
+type NotificationRule struct {
+	ID     string
+	ARN    string
+	Events []types.Event
+	Prefix string
+	Suffix string
+}
+
+// BucketNotification is the event notification configuration of a bucket:
+// rules per destination kind. MinIO webhook, AMQP, Kafka, ... targets are
+// queues with an ARN from MinIOTargetARN.
+type BucketNotification struct {
+	Queues  []NotificationRule
+	Topics  []NotificationRule
+	Lambdas []NotificationRule
+	// EventBridge sends every event to Amazon EventBridge as well.
+	EventBridge bool
+}
+
+// MinIOTargetARN returns the ARN of a MinIO notification target, e.g.
+// MinIOTargetARN("", "primary", "webhook") for the target configured as
+// notify_webhook:primary. Region is empty unless the server sets one.
+func MinIOTargetARN(region, targetID, targetType string) string {
+	return fmt.Sprintf("arn:minio:sqs:%s:%s:%s", region, targetID, targetType)
+}
+
+func (c *Client) GetBucketNotification(ctx context.Context) (*BucketNotification, error) {
+	output, err := c.client.GetBucketNotificationConfiguration(ctx, &s3.GetBucketNotificationConfigurationInput{
+		Bucket: aws.String(c.bucket),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bucket notification: %w", err)
+	}
+
+	n := &BucketNotification{EventBridge: output.EventBridgeConfiguration != nil}
+	for _, q := range output.QueueConfigurations {
+		n.Queues = append(n.Queues, notificationRuleFromSDK(q.Id, q.QueueArn, q.Events, q.Filter))
+	}
+	for _, t := range output.TopicConfigurations {
+		n.Topics = append(n.Topics, notificationRuleFromSDK(t.Id, t.TopicArn, t.Events, t.Filter))
+	}
+	for _, l := range output.LambdaFunctionConfigurations {
+		n.Lambdas = append(n.Lambdas, notificationRuleFromSDK(l.Id, l.LambdaFunctionArn, l.Events, l.Filter))
+	}
+	return n, nil
+}
+
+// PutBucketNotification replaces the notification configuration of the
+// bucket; an empty BucketNotification turns notifications off. S3 sends a
+// test event to each new destination and fails if it cannot deliver it.
+func (c *Client) PutBucketNotification(ctx context.Context, n *BucketNotification) error {
+	config := &types.NotificationConfiguration{}
+	for _, rule := range n.Queues {
+		config.QueueConfigurations = append(config.QueueConfigurations, types.QueueConfiguration{
+			Id:       stringOrNil(rule.ID),
+			QueueArn: aws.String(rule.ARN),
+			Events:   rule.Events,
+			Filter:   rule.filter(),
+		})
+	}
+	for _, rule := range n.Topics {
+		config.TopicConfigurations = append(config.TopicConfigurations, types.TopicConfiguration{
+			Id:       stringOrNil(rule.ID),
+			TopicArn: aws.String(rule.ARN),
+			Events:   rule.Events,
+			Filter:   rule.filter(),
+		})
+	}
+	for _, rule := range n.Lambdas {
+		config.LambdaFunctionConfigurations = append(config.LambdaFunctionConfigurations, types.LambdaFunctionConfiguration{
+			Id:                stringOrNil(rule.ID),
+			LambdaFunctionArn: aws.String(rule.ARN),
+			Events:            rule.Events,
+			Filter:            rule.filter(),
+		})
+	}
+	if n.EventBridge {
+		config.EventBridgeConfiguration = &types.EventBridgeConfiguration{}
+	}
+
+	_, err := c.client.PutBucketNotificationConfiguration(ctx, &s3.PutBucketNotificationConfigurationInput{
+		Bucket:                    aws.String(c.bucket),
+		NotificationConfiguration: config,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put bucket notification: %w", err)
+	}
+	return nil
+}
+
+func (r NotificationRule) filter() *types.NotificationConfigurationFilter {
+	var rules []types.FilterRule
+	if r.Prefix != "" {
+		rules = append(rules, types.FilterRule{Name: types.FilterRuleNamePrefix, Value: aws.String(r.Prefix)})
+	}
+	if r.Suffix != "" {
+		rules = append(rules, types.FilterRule{Name: types.FilterRuleNameSuffix, Value: aws.String(r.Suffix)})
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	return &types.NotificationConfigurationFilter{Key: &types.S3KeyFilter{FilterRules: rules}}
+}
+
+func notificationRuleFromSDK(id, arn *string, events []types.Event, filter *types.NotificationConfigurationFilter) NotificationRule {
+	rule := NotificationRule{ID: aws.ToString(id), ARN: aws.ToString(arn), Events: events}
+	if filter != nil && filter.Key != nil {
+		for _, f := range filter.Key.FilterRules {
+			// S3 returns the names capitalized ("Prefix").
+			switch strings.ToLower(string(f.Name)) {
+			case string(types.FilterRuleNamePrefix):
+				rule.Prefix = aws.ToString(f.Value)
+			case string(types.FilterRuleNameSuffix):
+				rule.Suffix = aws.ToString(f.Value)
+			}
+		}
+	}
+	return rule
+}
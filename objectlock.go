@@ -0,0 +1,155 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// Retention protects an object version from being deleted or overwritten
+// until RetainUntil. In governance mode users with
+// s3:BypassGovernanceRetention may still shorten or remove it; in
+// compliance mode nobody can, the root account included.
+type Retention struct {
+	Mode        types.ObjectLockRetentionMode
+	RetainUntil time.Time
+}
+
+type ObjectLockOption func(*objectLockOptions)
+
+type objectLockOptions struct {
+	versionID        string
+	bypassGovernance bool
+}
+
+// WithLockVersionID targets a specific version instead of the latest one.
+func WithLockVersionID(versionID string) ObjectLockOption {
+	return func(o *objectLockOptions) { o.versionID = versionID }
+}
+
+// WithBypassGovernance lets SetRetention shorten or remove a governance-mode
+// retention.
+func WithBypassGovernance() ObjectLockOption {
+	return func(o *objectLockOptions) { o.bypassGovernance = true }
+}
+
+func newObjectLockOptions(opts []ObjectLockOption) *objectLockOptions {
+	o := &objectLockOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithRetention applies a retention to the uploaded object. The bucket must
+// have object lock enabled. Object lock uploads carry a CRC32 checksum
+// unless WithChecksum picks another algorithm.
+func WithRetention(mode types.ObjectLockRetentionMode, retainUntil time.Time) UploadOption {
+	return func(o *uploadOptions) { o.retention = &Retention{Mode: mode, RetainUntil: retainUntil} }
+}
+
+// WithLegalHold places a legal hold on the uploaded object.
+func WithLegalHold() UploadOption {
+	return func(o *uploadOptions) { o.legalHold = true }
+}
+
+// WithDeleteBypassGovernance deletes a version under governance-mode
+// retention.
+func WithDeleteBypassGovernance() DeleteOption {
+	return func(o *deleteOptions) { o.bypassGovernance = true }
+}
+
+// SetRetention sets or extends the retention of key. A zero Retention
+// removes a governance-mode retention, which needs WithBypassGovernance.
+func (c *Client) SetRetention(ctx context.Context, key string, retention Retention, opts ...ObjectLockOption) error {
+	o := newObjectLockOptions(opts)
+	input := &s3.PutObjectRetentionInput{
+		Bucket:    aws.String(c.bucket),
+		Key:       aws.String(key),
+		VersionId: stringOrNil(o.versionID),
+		Retention: &types.ObjectLockRetention{
+			Mode:            retention.Mode,
+			RetainUntilDate: timeOrNil(retention.RetainUntil),
+		},
+	}
+	if o.bypassGovernance {
+		input.BypassGovernanceRetention = aws.Bool(true)
+	}
+	if _, err := c.client.PutObjectRetention(ctx, input); err != nil {
+		return fmt.Errorf("failed to set object retention: %w", err)
+	}
+	return nil
+}
+
+// GetRetention returns the retention of key, or nil when it has none.
+func (c *Client) GetRetention(ctx context.Context, key string, opts ...ObjectLockOption) (*Retention, error) {
+	o := newObjectLockOptions(opts)
+	output, err := c.client.GetObjectRetention(ctx, &s3.GetObjectRetentionInput{
+		Bucket:    aws.String(c.bucket),
+		Key:       aws.String(key),
+		VersionId: stringOrNil(o.versionID),
+	})
+	if isNoObjectLockConfiguration(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object retention: %w", err)
+	}
+	if output.Retention == nil || output.Retention.Mode == "" {
+		return nil, nil
+	}
+	return &Retention{
+		Mode:        output.Retention.Mode,
+		RetainUntil: aws.ToTime(output.Retention.RetainUntilDate),
+	}, nil
+}
+
+// SetLegalHold places or lifts a legal hold on key. A held object version
+// cannot be deleted regardless of its retention.
+func (c *Client) SetLegalHold(ctx context.Context, key string, on bool, opts ...ObjectLockOption) error {
+	o := newObjectLockOptions(opts)
+	status := types.ObjectLockLegalHoldStatusOff
+	if on {
+		status = types.ObjectLockLegalHoldStatusOn
+	}
+	_, err := c.client.PutObjectLegalHold(ctx, &s3.PutObjectLegalHoldInput{
+		Bucket:    aws.String(c.bucket),
+		Key:       aws.String(key),
+		VersionId: stringOrNil(o.versionID),
+		LegalHold: &types.ObjectLockLegalHold{Status: status},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set legal hold: %w", err)
+	}
+	return nil
+}
+
+// LegalHold reports whether key is under a legal hold.
+func (c *Client) LegalHold(ctx context.Context, key string, opts ...ObjectLockOption) (bool, error) {
+	o := newObjectLockOptions(opts)
+	output, err := c.client.GetObjectLegalHold(ctx, &s3.GetObjectLegalHoldInput{
+		Bucket:    aws.String(c.bucket),
+		Key:       aws.String(key),
+		VersionId: stringOrNil(o.versionID),
+	})
+	if isNoObjectLockConfiguration(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get legal hold: %w", err)
+	}
+	return output.LegalHold != nil && output.LegalHold.Status == types.ObjectLockLegalHoldStatusOn, nil
+}
+
+// isNoObjectLockConfiguration reports the error S3 returns for objects that
+// never had a retention or legal hold.
+func isNoObjectLockConfiguration(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchObjectLockConfiguration"
+}
@@ -0,0 +1,267 @@
+package s3
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+type UploadOption func(*uploadOptions)
+
+// PutOption is accepted by UploadFile. It shares the option set with
+// UploadLarge so the same object attributes work on both paths; multipart
+// tuning options are ignored for single-request uploads.
+type PutOption = UploadOption
+
+type uploadOptions struct {
+	contentType        string
+	cacheControl       string
+	contentDisposition string
+	contentEncoding    string
+	metadata           map[string]string
+	tags               map[string]string
+	acl                types.ObjectCannedACL
+	storageClass       types.StorageClass
+	partSize           int64
+	concurrency        int
+	multipartThreshold int64
+	progress           ProgressFunc
+	sse                types.ServerSideEncryption
+	kmsKeyID           string
+	checksum           types.ChecksumAlgorithm
+	ifMatch            string
+	ifNoneMatch        bool
+	retention          *Retention
+	legalHold          bool
+	presignExpiration  time.Duration
+
+	compression          Compression
+	compressionThreshold int64
+
+	// Used by UploadFromRequest only.
+	key     string
+	maxSize int64
+}
+
+func newUploadOptions(opts []UploadOption) *uploadOptions {
+	o := &uploadOptions{
+		partSize:             defaultPartSize,
+		concurrency:          defaultConcurrency,
+		compressionThreshold: defaultCompressionThreshold,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func WithContentType(contentType string) UploadOption {
+	return func(o *uploadOptions) { o.contentType = contentType }
+}
+
+func WithCacheControl(value string) UploadOption {
+	return func(o *uploadOptions) { o.cacheControl = value }
+}
+
+func WithContentDisposition(value string) UploadOption {
+	return func(o *uploadOptions) { o.contentDisposition = value }
+}
+
+func WithContentEncoding(value string) UploadOption {
+	return func(o *uploadOptions) { o.contentEncoding = value }
+}
+
+// WithMetadata adds user metadata (x-amz-meta-*). Repeated calls merge keys.
+func WithMetadata(metadata map[string]string) UploadOption {
+	return func(o *uploadOptions) {
+		if o.metadata == nil {
+			o.metadata = make(map[string]string, len(metadata))
+		}
+		for k, v := range metadata {
+			o.metadata[k] = v
+		}
+	}
+}
+
+// WithTags attaches object tags at upload time. Repeated calls merge keys.
+func WithTags(tags map[string]string) UploadOption {
+	return func(o *uploadOptions) {
+		if o.tags == nil {
+			o.tags = make(map[string]string, len(tags))
+		}
+		for k, v := range tags {
+			o.tags[k] = v
+		}
+	}
+}
+
+// WithACL sets a canned ACL on the uploaded object; SetObjectACL changes it
+// later.
+func WithACL(acl types.ObjectCannedACL) UploadOption {
+	return func(o *uploadOptions) { o.acl = acl }
+}
+
+func WithStorageClass(class types.StorageClass) UploadOption {
+	return func(o *uploadOptions) { o.storageClass = class }
+}
+
+func WithPartSize(size int64) UploadOption {
+	return func(o *uploadOptions) { o.partSize = size }
+}
+
+func WithConcurrency(n int) UploadOption {
+	return func(o *uploadOptions) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// WithMultipartThreshold sets how many bytes UploadLarge buffers before it
+// switches from a single PutObject to a multipart upload. It defaults to the
+// part size and may not exceed 5 GiB.
+func WithMultipartThreshold(n int64) UploadOption {
+	return func(o *uploadOptions) {
+		if n > 0 {
+			o.multipartThreshold = n
+		}
+	}
+}
+
+// WithPresignedURL fills UploadResult.URL with a presigned download URL
+// valid for expiration. Only UploadFile returns a result.
+func WithPresignedURL(expiration time.Duration) PutOption {
+	return func(o *uploadOptions) { o.presignExpiration = expiration }
+}
+
+// WithProgress reports upload progress. Callbacks are throttled and may be
+// issued from multiple goroutines, but never concurrently.
+func WithProgress(fn ProgressFunc) UploadOption {
+	return func(o *uploadOptions) { o.progress = fn }
+}
+
+// WithKey sets the object key for UploadFromRequest. Without it the
+// sanitized client file name is used.
+func WithKey(key string) UploadOption {
+	return func(o *uploadOptions) { o.key = key }
+}
+
+// WithMaxSize limits the file size accepted by UploadFromRequest,
+// defaultMaxFormFileSize by default.
+func WithMaxSize(n int64) UploadOption {
+	return func(o *uploadOptions) {
+		if n > 0 {
+			o.maxSize = n
+		}
+	}
+}
+
+func (o *uploadOptions) applyPut(input *s3.PutObjectInput) {
+	if o.contentType != "" {
+		input.ContentType = aws.String(o.contentType)
+	}
+	input.CacheControl = stringOrNil(o.cacheControl)
+	input.ContentDisposition = stringOrNil(o.contentDisposition)
+	input.ContentEncoding = stringOrNil(o.contentEncoding)
+	input.Metadata = o.metadata
+	input.Tagging = encodeTags(o.tags)
+	input.ACL = o.acl
+	input.StorageClass = o.storageClass
+	input.ServerSideEncryption = o.sse
+	input.SSEKMSKeyId = stringOrNil(o.kmsKeyID)
+	input.ChecksumAlgorithm = o.checksumAlgorithm()
+	input.ObjectLockMode, input.ObjectLockRetainUntilDate, input.ObjectLockLegalHoldStatus = o.objectLock()
+}
+
+func (o *uploadOptions) applyCreateMultipart(input *s3.CreateMultipartUploadInput) {
+	if o.contentType != "" {
+		input.ContentType = aws.String(o.contentType)
+	}
+	input.CacheControl = stringOrNil(o.cacheControl)
+	input.ContentDisposition = stringOrNil(o.contentDisposition)
+	input.ContentEncoding = stringOrNil(o.contentEncoding)
+	input.Metadata = o.metadata
+	input.Tagging = encodeTags(o.tags)
+	input.ACL = o.acl
+	input.StorageClass = o.storageClass
+	input.ServerSideEncryption = o.sse
+	input.SSEKMSKeyId = stringOrNil(o.kmsKeyID)
+	input.ChecksumAlgorithm = o.checksumAlgorithm()
+	input.ObjectLockMode, input.ObjectLockRetainUntilDate, input.ObjectLockLegalHoldStatus = o.objectLock()
+}
+
+func (o *uploadOptions) checksumAlgorithm() types.ChecksumAlgorithm {
+	if o.checksum == "" && (o.retention != nil || o.legalHold) {
+		// S3 rejects object lock uploads without an integrity checksum.
+		return types.ChecksumAlgorithmCrc32
+	}
+	return o.checksum
+}
+
+// objectLock returns the object lock fields of the upload request.
+func (o *uploadOptions) objectLock() (types.ObjectLockMode, *time.Time, types.ObjectLockLegalHoldStatus) {
+	var (
+		mode      types.ObjectLockMode
+		until     *time.Time
+		legalHold types.ObjectLockLegalHoldStatus
+	)
+	if o.retention != nil {
+		mode = types.ObjectLockMode(o.retention.Mode)
+		until = timeOrNil(o.retention.RetainUntil)
+	}
+	if o.legalHold {
+		legalHold = types.ObjectLockLegalHoldStatusOn
+	}
+	return mode, until, legalHold
+}
+
+type DownloadOption func(*downloadOptions)
+
+type downloadOptions struct {
+	partSize         int64
+	concurrency      int
+	progress         ProgressFunc
+	validateChecksum bool
+
+	ifMatch           string
+	ifNoneMatch       string
+	ifModifiedSince   time.Time
+	ifUnmodifiedSince time.Time
+
+	versionID  string
+	decompress bool
+}
+
+func newDownloadOptions(opts []DownloadOption) *downloadOptions {
+	o := &downloadOptions{
+		partSize:    defaultPartSize,
+		concurrency: defaultConcurrency,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func WithDownloadPartSize(size int64) DownloadOption {
+	return func(o *downloadOptions) {
+		if size > 0 {
+			o.partSize = size
+		}
+	}
+}
+
+func WithDownloadConcurrency(n int) DownloadOption {
+	return func(o *downloadOptions) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// WithDownloadProgress reports download progress as the body is read.
+func WithDownloadProgress(fn ProgressFunc) DownloadOption {
+	return func(o *downloadOptions) { o.progress = fn }
+}
@@ -0,0 +1,342 @@
+package s3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+const policyVersion = "2012-10-17"
+
+// BucketPolicy is the resource policy document attached to a bucket.
+type BucketPolicy struct {
+	Version   string            `json:"Version"`
+	ID        string            `json:"Id,omitempty"`
+	Statement []PolicyStatement `json:"Statement"`
+}
+
+type PolicyEffect string
+
+const (
+	PolicyAllow PolicyEffect = "Allow"
+	PolicyDeny  PolicyEffect = "Deny"
+)
+
+// PolicyStatement is one statement of a BucketPolicy. Condition maps an
+// operator ("StringLike", "Bool", ...) to condition keys and their values.
+type PolicyStatement struct {
+	Sid       string                             `json:"Sid,omitempty"`
+	Effect    PolicyEffect                       `json:"Effect"`
+	Principal PolicyPrincipal                    `json:"Principal,omitempty"`
+	Action    PolicyValues                       `json:"Action"`
+	Resource  PolicyValues                       `json:"Resource"`
+	Condition map[string]map[string]PolicyValues `json:"Condition,omitempty"`
+}
+
+// PolicyPrincipal maps a principal type ("AWS", "Service", ...) to its
+// identifiers. AnyPrincipal, everyone, is written as "*".
+type PolicyPrincipal map[string]PolicyValues
+
+func AnyPrincipal() PolicyPrincipal { return PolicyPrincipal{"*": nil} }
+
+// AWSPrincipal is an account, user or role given by ARN or account ID.
+func AWSPrincipal(arns ...string) PolicyPrincipal { return PolicyPrincipal{"AWS": arns} }
+
+// ServicePrincipal is an AWS service, e.g. "cloudfront.amazonaws.com".
+func ServicePrincipal(services ...string) PolicyPrincipal {
+	return PolicyPrincipal{"Service": services}
+}
+
+func (p PolicyPrincipal) MarshalJSON() ([]byte, error) {
+	if _, ok := p["*"]; ok {
+		return []byte(`"*"`), nil
+	}
+	return json.Marshal(map[string]PolicyValues(p))
+}
+
+func (p *PolicyPrincipal) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		if s != "*" {
+			return fmt.Errorf("invalid policy principal %q", s)
+		}
+		*p = AnyPrincipal()
+		return nil
+	}
+	var principals map[string]PolicyValues
+	if err := json.Unmarshal(data, &principals); err != nil {
+		return err
+	}
+	*p = principals
+	return nil
+}
+
+// PolicyValues is a list of policy strings. Policies may write a single
+// value without the array, and condition values as booleans or numbers;
+// both are accepted when decoding.
+type PolicyValues []string
+
+func (v *PolicyValues) UnmarshalJSON(data []byte) error {
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	values, ok := raw.([]any)
+	if !ok {
+		values = []any{raw}
+	}
+	*v = make(PolicyValues, 0, len(values))
+	for _, value := range values {
+		switch value := value.(type) {
+		case string:
+			*v = append(*v, value)
+		case bool:
+			*v = append(*v, strconv.FormatBool(value))
+		case float64:
+			*v = append(*v, strconv.FormatFloat(value, 'f', -1, 64))
+		default:
+			return fmt.Errorf("invalid policy value %s", data)
+		}
+	}
+	return nil
+}
+
+// PolicyBuilder assembles a BucketPolicy for one bucket from common
+// statements.
+type PolicyBuilder struct {
+	bucket     string
+	statements []PolicyStatement
+}
+
+func NewPolicyBuilder(bucket string) *PolicyBuilder {
+	return &PolicyBuilder{bucket: bucket}
+}
+
+// PolicyBuilder returns a builder for the client's bucket.
+func (c *Client) PolicyBuilder() *PolicyBuilder {
+	return NewPolicyBuilder(c.bucket)
+}
+
+func (b *PolicyBuilder) bucketARN() string { return "arn:aws:s3:::" + b.bucket }
+
+func (b *PolicyBuilder) objectsARN(prefix string) string {
+	return b.bucketARN() + "/" + prefix + "*"
+}
+
+// AllowPublicRead lets anyone download objects under prefix, "" for the
+// whole bucket. The bucket's public access block must allow public
+// policies.
+func (b *PolicyBuilder) AllowPublicRead(prefix string) *PolicyBuilder {
+	return b.Add(PolicyStatement{
+		Effect:    PolicyAllow,
+		Principal: AnyPrincipal(),
+		Action:    PolicyValues{"s3:GetObject"},
+		Resource:  PolicyValues{b.objectsARN(prefix)},
+	})
+}
+
+// AllowRead lets principal download and list objects under prefix.
+func (b *PolicyBuilder) AllowRead(principal PolicyPrincipal, prefix string) *PolicyBuilder {
+	b.Add(PolicyStatement{
+		Effect:    PolicyAllow,
+		Principal: principal,
+		Action:    PolicyValues{"s3:GetObject"},
+		Resource:  PolicyValues{b.objectsARN(prefix)},
+	})
+	list := PolicyStatement{
+		Effect:    PolicyAllow,
+		Principal: principal,
+		Action:    PolicyValues{"s3:ListBucket"},
+		Resource:  PolicyValues{b.bucketARN()},
+	}
+	if prefix != "" {
+		list.Condition = map[string]map[string]PolicyValues{
+			"StringLike": {"s3:prefix": {prefix + "*"}},
+		}
+	}
+	return b.Add(list)
+}
+
+// AllowWrite lets principal upload and delete objects under prefix.
+func (b *PolicyBuilder) AllowWrite(principal PolicyPrincipal, prefix string) *PolicyBuilder {
+	return b.Add(PolicyStatement{
+		Effect:    PolicyAllow,
+		Principal: principal,
+		Action:    PolicyValues{"s3:PutObject", "s3:DeleteObject", "s3:AbortMultipartUpload"},
+		Resource:  PolicyValues{b.objectsARN(prefix)},
+	})
+}
+
+// DenyInsecureTransport rejects every request not made over TLS.
+func (b *PolicyBuilder) DenyInsecureTransport() *PolicyBuilder {
+	return b.Add(PolicyStatement{
+		Sid:       "DenyInsecureTransport",
+		Effect:    PolicyDeny,
+		Principal: AnyPrincipal(),
+		Action:    PolicyValues{"s3:*"},
+		Resource:  PolicyValues{b.bucketARN(), b.objectsARN("")},
+		Condition: map[string]map[string]PolicyValues{
+			"Bool": {"aws:SecureTransport": {"false"}},
+		},
+	})
+}
+
+// DenyUnencryptedUploads rejects uploads that do not request server-side
+// encryption with sse.
+func (b *PolicyBuilder) DenyUnencryptedUploads(sse types.ServerSideEncryption) *PolicyBuilder {
+	return b.Add(PolicyStatement{
+		Sid:       "DenyUnencryptedUploads",
+		Effect:    PolicyDeny,
+		Principal: AnyPrincipal(),
+		Action:    PolicyValues{"s3:PutObject"},
+		Resource:  PolicyValues{b.objectsARN("")},
+		Condition: map[string]map[string]PolicyValues{
+			"StringNotEquals": {"s3:x-amz-server-side-encryption": {string(sse)}},
+		},
+	})
+}
+
+// Add appends a statement of its own.
+func (b *PolicyBuilder) Add(statement PolicyStatement) *PolicyBuilder {
+	b.statements = append(b.statements, statement)
+	return b
+}
+
+func (b *PolicyBuilder) Build() *BucketPolicy {
+	return &BucketPolicy{Version: policyVersion, Statement: append([]PolicyStatement(nil), b.statements...)}
+}
+
+// GetBucketPolicy returns the bucket policy, or nil if the bucket has none.
+func (c *Client) GetBucketPolicy(ctx context.Context) (*BucketPolicy, error) {
+	output, err := c.client.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{
+		Bucket: aws.String(c.bucket),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchBucketPolicy" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get bucket policy: %w", err)
+	}
+	policy := &BucketPolicy{}
+	if err := json.Unmarshal([]byte(aws.ToString(output.Policy)), policy); err != nil {
+		return nil, fmt.Errorf("failed to decode bucket policy: %w", err)
+	}
+	return policy, nil
+}
+
+// PutBucketPolicy replaces the bucket policy. An empty Version defaults to
+// 2012-10-17.
+func (c *Client) PutBucketPolicy(ctx context.Context, policy *BucketPolicy) error {
+	if policy == nil || len(policy.Statement) == 0 {
+		return errors.New("bucket policy has no statements")
+	}
+	if policy.Version == "" {
+		p := *policy
+		p.Version = policyVersion
+		policy = &p
+	}
+	document, err := json.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("failed to encode bucket policy: %w", err)
+	}
+	_, err = c.client.PutBucketPolicy(ctx, &s3.PutBucketPolicyInput{
+		Bucket: aws.String(c.bucket),
+		Policy: aws.String(string(document)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put bucket policy: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) DeleteBucketPolicy(ctx context.Context) error {
+	_, err := c.client.DeleteBucketPolicy(ctx, &s3.DeleteBucketPolicyInput{
+		Bucket: aws.String(c.bucket),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete bucket policy: %w", err)
+	}
+	return nil
+}
+
+// PublicAccessBlock is the bucket's Block Public Access configuration.
+type PublicAccessBlock struct {
+	// BlockPublicACLs rejects requests setting public ACLs.
+	BlockPublicACLs bool
+	// IgnorePublicACLs ignores public ACLs already set.
+	IgnorePublicACLs bool
+	// BlockPublicPolicy rejects bucket policies granting public access.
+	BlockPublicPolicy bool
+	// RestrictPublicBuckets limits access under a public policy to AWS
+	// services and the bucket owner's account.
+	RestrictPublicBuckets bool
+}
+
+// BlockAllPublicAccess is the recommended setting for private buckets.
+func BlockAllPublicAccess() PublicAccessBlock {
+	return PublicAccessBlock{
+		BlockPublicACLs:       true,
+		IgnorePublicACLs:      true,
+		BlockPublicPolicy:     true,
+		RestrictPublicBuckets: true,
+	}
+}
+
+// GetPublicAccessBlock returns the bucket's configuration, all false when
+// it has none.
+func (c *Client) GetPublicAccessBlock(ctx context.Context) (PublicAccessBlock, error) {
+	output, err := c.client.GetPublicAccessBlock(ctx, &s3.GetPublicAccessBlockInput{
+		Bucket: aws.String(c.bucket),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchPublicAccessBlockConfiguration" {
+			return PublicAccessBlock{}, nil
+		}
+		return PublicAccessBlock{}, fmt.Errorf("failed to get public access block: %w", err)
+	}
+	config := output.PublicAccessBlockConfiguration
+	if config == nil {
+		return PublicAccessBlock{}, nil
+	}
+	return PublicAccessBlock{
+		BlockPublicACLs:       aws.ToBool(config.BlockPublicAcls),
+		IgnorePublicACLs:      aws.ToBool(config.IgnorePublicAcls),
+		BlockPublicPolicy:     aws.ToBool(config.BlockPublicPolicy),
+		RestrictPublicBuckets: aws.ToBool(config.RestrictPublicBuckets),
+	}, nil
+}
+
+func (c *Client) PutPublicAccessBlock(ctx context.Context, block PublicAccessBlock) error {
+	_, err := c.client.PutPublicAccessBlock(ctx, &s3.PutPublicAccessBlockInput{
+		Bucket: aws.String(c.bucket),
+		PublicAccessBlockConfiguration: &types.PublicAccessBlockConfiguration{
+			BlockPublicAcls:       aws.Bool(block.BlockPublicACLs),
+			IgnorePublicAcls:      aws.Bool(block.IgnorePublicACLs),
+			BlockPublicPolicy:     aws.Bool(block.BlockPublicPolicy),
+			RestrictPublicBuckets: aws.Bool(block.RestrictPublicBuckets),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put public access block: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) DeletePublicAccessBlock(ctx context.Context) error {
+	_, err := c.client.DeletePublicAccessBlock(ctx, &s3.DeletePublicAccessBlockInput{
+		Bucket: aws.String(c.bucket),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete public access block: %w", err)
+	}
+	return nil
+}
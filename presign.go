@@ -0,0 +1,35 @@
+package s3
+
+import (
+	"fmt"
+	"mime"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// PresignOption sets response header overrides that S3 applies when the
+// presigned URL is downloaded.
+type PresignOption func(*s3.GetObjectInput)
+
+func WithResponseContentDisposition(value string) PresignOption {
+	return func(input *s3.GetObjectInput) { input.ResponseContentDisposition = aws.String(value) }
+}
+
+func WithResponseContentType(value string) PresignOption {
+	return func(input *s3.GetObjectInput) { input.ResponseContentType = aws.String(value) }
+}
+
+func WithResponseCacheControl(value string) PresignOption {
+	return func(input *s3.GetObjectInput) { input.ResponseCacheControl = aws.String(value) }
+}
+
+// WithAttachment makes browsers save the download as filename. Non-ASCII names
+// are encoded per RFC 6266.
+func WithAttachment(filename string) PresignOption {
+	value := mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+	if value == "" {
+		value = fmt.Sprintf("attachment; filename=%q", filename)
+	}
+	return WithResponseContentDisposition(value)
+}
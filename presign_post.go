@@ -0,0 +1,145 @@
+package s3
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	signingAlgorithm = "AWS4-HMAC-SHA256"
+	amzDateFormat    = "20060102T150405Z"
+	amzShortDate     = "20060102"
+)
+
+type PostPolicyOptions struct {
+	// Key is the exact object key. It may contain ${filename}, which S3
+	// replaces with the name of the uploaded file.
+	Key string
+	// KeyPrefix restricts uploads to keys starting with the prefix. The form
+	// must then supply the key field itself.
+	KeyPrefix   string
+	Expires     time.Duration
+	ContentType string
+	// ContentTypePrefix allows any content type starting with the prefix,
+	// e.g. "image/".
+	ContentTypePrefix string
+	MinSize           int64
+	MaxSize           int64
+	// Fields are additional form fields (success_action_status, acl, ...)
+	// that are both signed into the policy and returned in PresignedPost.
+	Fields map[string]string
+}
+
+type PresignedPost struct {
+	URL     string
+	Fields  map[string]string
+	Policy  string
+	Expires time.Time
+}
+
+func (c *Client) PresignPostPolicy(ctx context.Context, opts PostPolicyOptions) (*PresignedPost, error) {
+	if opts.Key == "" && opts.KeyPrefix == "" {
+		return nil, errors.New("either key or key prefix is required")
+	}
+	if opts.MaxSize < 0 || opts.MinSize < 0 || (opts.MaxSize > 0 && opts.MinSize > opts.MaxSize) {
+		return nil, errors.New("invalid content length range")
+	}
+	if opts.Expires <= 0 {
+		opts.Expires = c.presignTTL
+	}
+
+	creds, err := c.credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve credentials: %w", err)
+	}
+
+	now := time.Now().UTC()
+	expires := now.Add(opts.Expires)
+	credential := strings.Join([]string{creds.AccessKeyID, now.Format(amzShortDate), c.region, "s3", "aws4_request"}, "/")
+
+	fields := map[string]string{
+		"x-amz-algorithm":  signingAlgorithm,
+		"x-amz-credential": credential,
+		"x-amz-date":       now.Format(amzDateFormat),
+	}
+	if creds.SessionToken != "" {
+		fields["x-amz-security-token"] = creds.SessionToken
+	}
+	for k, v := range c.encryptionFields() {
+		fields[k] = v
+	}
+	for k, v := range opts.Fields {
+		fields[k] = v
+	}
+
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	conditions := []any{map[string]string{"bucket": c.bucket}}
+	for _, k := range names {
+		conditions = append(conditions, map[string]string{k: fields[k]})
+	}
+	if opts.Key != "" {
+		fields["key"] = opts.Key
+		if strings.Contains(opts.Key, "${filename}") {
+			conditions = append(conditions, []any{"starts-with", "$key", opts.Key[:strings.Index(opts.Key, "${filename}")]})
+		} else {
+			conditions = append(conditions, map[string]string{"key": opts.Key})
+		}
+	} else {
+		conditions = append(conditions, []any{"starts-with", "$key", opts.KeyPrefix})
+	}
+	if opts.ContentType != "" {
+		fields["Content-Type"] = opts.ContentType
+		conditions = append(conditions, map[string]string{"Content-Type": opts.ContentType})
+	} else if opts.ContentTypePrefix != "" {
+		conditions = append(conditions, []any{"starts-with", "$Content-Type", opts.ContentTypePrefix})
+	}
+	if opts.MaxSize > 0 {
+		conditions = append(conditions, []any{"content-length-range", opts.MinSize, opts.MaxSize})
+	}
+
+	policy, err := json.Marshal(map[string]any{
+		"expiration": expires.Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode post policy: %w", err)
+	}
+
+	encodedPolicy := base64.StdEncoding.EncodeToString(policy)
+	signingKey := deriveSigningKey(creds.SecretAccessKey, now, c.region, "s3")
+	fields["policy"] = encodedPolicy
+	fields["x-amz-signature"] = hex.EncodeToString(hmacSHA256(signingKey, []byte(encodedPolicy)))
+
+	return &PresignedPost{
+		URL:     c.bucketURL(),
+		Fields:  fields,
+		Policy:  string(policy),
+		Expires: expires,
+	}, nil
+}
+
+func deriveSigningKey(secret string, t time.Time, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), []byte(t.Format(amzShortDate)))
+	key = hmacSHA256(key, []byte(region))
+	key = hmacSHA256(key, []byte(service))
+	return hmacSHA256(key, []byte("aws4_request"))
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
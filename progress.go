@@ -0,0 +1,159 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// ProgressFunc receives the number of bytes transferred so far and the total
+// size, which is -1 when the size of an upload body cannot be determined.
+type ProgressFunc func(transferred, total int64)
+
+const progressInterval = 100 * time.Millisecond
+
+// progressTracker aggregates byte counts from concurrent parts and throttles
+// callbacks to one per progressInterval, plus a final one on completion.
+type progressTracker struct {
+	fn       ProgressFunc
+	total    int64
+	mu       sync.Mutex
+	done     int64
+	reported int64
+	last     time.Time
+}
+
+func newProgressTracker(fn ProgressFunc, total int64) *progressTracker {
+	if fn == nil {
+		return nil
+	}
+	return &progressTracker{fn: fn, total: total, reported: -1}
+}
+
+func (p *progressTracker) add(n int64) {
+	if p == nil || n == 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done += n
+	now := time.Now()
+	if now.Sub(p.last) >= progressInterval || (p.total > 0 && p.done >= p.total) {
+		p.last = now
+		p.report()
+	}
+}
+
+func (p *progressTracker) finish() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done != p.reported {
+		p.report()
+	}
+}
+
+func (p *progressTracker) report() {
+	p.reported = p.done
+	p.fn(p.done, p.total)
+}
+
+func (p *progressTracker) reader(r io.Reader) io.Reader {
+	if p == nil {
+		return r
+	}
+	return &progressReader{r: r, tracker: p}
+}
+
+func (p *progressTracker) readCloser(rc io.ReadCloser) io.ReadCloser {
+	if p == nil {
+		return rc
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{&progressReader{r: rc, tracker: p}, rc}
+}
+
+// apiOptions counts request body bytes as they are written to the
+// connection. Wrapping the body before the SDK sees it would also count the
+// read used for payload signing.
+func (p *progressTracker) apiOptions() []func(*s3.Options) {
+	if p == nil {
+		return nil
+	}
+	return []func(*s3.Options){
+		s3.WithAPIOptions(func(stack *middleware.Stack) error {
+			return stack.Deserialize.Add(&progressMiddleware{tracker: p}, middleware.After)
+		}),
+	}
+}
+
+type progressReader struct {
+	r       io.Reader
+	tracker *progressTracker
+	read    int64
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.read += int64(n)
+	r.tracker.add(int64(n))
+	return n, err
+}
+
+type progressMiddleware struct {
+	tracker *progressTracker
+	attempt *progressReader
+}
+
+func (*progressMiddleware) ID() string { return "go-s3.Progress" }
+
+func (m *progressMiddleware) HandleDeserialize(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler) (middleware.DeserializeOutput, middleware.Metadata, error) {
+	req, ok := in.Request.(*smithyhttp.Request)
+	if !ok || req.GetStream() == nil {
+		return next.HandleDeserialize(ctx, in)
+	}
+
+	// A retried attempt resends the body from the start.
+	if m.attempt != nil {
+		m.tracker.add(-m.attempt.read)
+	}
+	m.attempt = &progressReader{r: req.GetStream(), tracker: m.tracker}
+
+	counted, err := req.SetStream(struct{ io.Reader }{m.attempt})
+	if err != nil {
+		return middleware.DeserializeOutput{}, middleware.Metadata{}, err
+	}
+	in.Request = counted
+	return next.HandleDeserialize(ctx, in)
+}
+
+// readerSize returns the number of unread bytes in r, or -1 if unknown.
+func readerSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		return int64(v.Len())
+	case io.Seeker:
+		current, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		end, err := v.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+		if _, err := v.Seek(current, io.SeekStart); err != nil {
+			return -1
+		}
+		return end - current
+	}
+	return -1
+}
@@ -0,0 +1,169 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type RenameOption func(*renameOptions)
+
+type renameOptions struct {
+	dryRun      bool
+	concurrency int
+	progress    func(done, total int)
+}
+
+// WithDryRun makes RenamePrefix report the planned renames without touching
+// any object.
+func WithDryRun() RenameOption {
+	return func(o *renameOptions) { o.dryRun = true }
+}
+
+func WithRenameConcurrency(n int) RenameOption {
+	return func(o *renameOptions) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// WithRenameProgress is called after every processed object, successful or
+// not. Calls are serialized.
+func WithRenameProgress(fn func(done, total int)) RenameOption {
+	return func(o *renameOptions) { o.progress = fn }
+}
+
+type RenamedObject struct {
+	From string
+	To   string
+}
+
+type RenameError struct {
+	RenamedObject
+	Err error
+}
+
+func (e RenameError) Error() string {
+	return fmt.Sprintf("failed to rename %s to %s: %v", e.From, e.To, e.Err)
+}
+
+func (e RenameError) Unwrap() error { return e.Err }
+
+type RenameResult struct {
+	DryRun  bool
+	Renamed []RenamedObject
+	Failed  []RenameError
+}
+
+// RenamePrefix moves every object under oldPrefix to newPrefix with
+// server-side copies. An original is deleted only after its copy has been
+// verified, so a failed run leaves either the old or both objects in place and
+// can be repeated.
+func (c *Client) RenamePrefix(ctx context.Context, oldPrefix, newPrefix string, opts ...RenameOption) (*RenameResult, error) {
+	o := &renameOptions{concurrency: defaultConcurrency}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if oldPrefix == newPrefix {
+		return &RenameResult{DryRun: o.dryRun}, nil
+	}
+	if strings.HasPrefix(newPrefix, oldPrefix) {
+		return nil, fmt.Errorf("new prefix %q must not be nested in old prefix %q", newPrefix, oldPrefix)
+	}
+
+	var objects []ObjectInfo
+	err := c.ListAll(ctx, oldPrefix, func(obj ObjectInfo) error {
+		objects = append(objects, obj)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RenameResult{DryRun: o.dryRun}
+	if o.dryRun {
+		for _, obj := range objects {
+			result.Renamed = append(result.Renamed, RenamedObject{
+				From: obj.Key,
+				To:   newPrefix + strings.TrimPrefix(obj.Key, oldPrefix),
+			})
+		}
+		return result, nil
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+	jobs := make(chan ObjectInfo)
+	for i := 0; i < o.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for obj := range jobs {
+				renamed := RenamedObject{
+					From: obj.Key,
+					To:   newPrefix + strings.TrimPrefix(obj.Key, oldPrefix),
+				}
+				err := c.renameObject(ctx, obj, renamed.To)
+
+				mu.Lock()
+				if err != nil {
+					result.Failed = append(result.Failed, RenameError{RenamedObject: renamed, Err: err})
+				} else {
+					result.Renamed = append(result.Renamed, renamed)
+				}
+				done++
+				if o.progress != nil {
+					o.progress(done, len(objects))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, obj := range objects {
+		select {
+		case jobs <- obj:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return result, ctx.Err()
+}
+
+func (c *Client) renameObject(ctx context.Context, obj ObjectInfo, dstKey string) error {
+	src, err := c.copyFile(ctx, obj.Key, dstKey, &copyOptions{ifMatch: obj.ETag})
+	if err != nil {
+		return err
+	}
+
+	dst, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(dstKey),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to verify copy: %w", err)
+	}
+	if aws.ToInt64(dst.ContentLength) != aws.ToInt64(src.ContentLength) {
+		return fmt.Errorf("copy size mismatch: %d, expected %d", aws.ToInt64(dst.ContentLength), aws.ToInt64(src.ContentLength))
+	}
+	// Multipart ETags depend on the part layout, so only single-part ETags
+	// are comparable between source and copy.
+	srcETag, dstETag := aws.ToString(src.ETag), aws.ToString(dst.ETag)
+	if !strings.Contains(srcETag, "-") && !strings.Contains(dstETag, "-") && srcETag != dstETag {
+		return fmt.Errorf("copy etag mismatch: %s, expected %s", dstETag, srcETag)
+	}
+
+	return c.deleteObject(ctx, obj.Key, &deleteOptions{})
+}
@@ -0,0 +1,569 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultReplicationQueueSize = 1000
+
+// ErrReplicationQueueFull is recorded as the failure of writes made while
+// the queue of an asynchronous ReplicatedClient is full or closed.
+var ErrReplicationQueueFull = errors.New("replication queue full")
+
+type ReplicationOp string
+
+const (
+	// ReplicateObject makes the secondary's object match the primary's:
+	// copied when it exists, deleted when it does not.
+	ReplicateObject ReplicationOp = "object"
+	// ReplicateTags copies the primary's tags of an object.
+	ReplicateTags ReplicationOp = "tags"
+)
+
+type ReplicationFailure struct {
+	Op   ReplicationOp
+	Key  string
+	Err  error
+	Time time.Time
+}
+
+func (e ReplicationFailure) Error() string {
+	return fmt.Sprintf("failed to replicate %s of %s: %v", e.Op, e.Key, e.Err)
+}
+
+func (e ReplicationFailure) Unwrap() error { return e.Err }
+
+type ReplicationOption func(*replicationOptions)
+
+type replicationOptions struct {
+	async       bool
+	queueSize   int
+	concurrency int
+	onFailure   func(ReplicationFailure)
+}
+
+// WithAsyncReplication returns writes as soon as the primary is done and
+// replicates them in the background through a queue of queueSize writes
+// (1000 if queueSize <= 0). Writes made while the queue is full fail with
+// ErrReplicationQueueFull. Close the client to drain the queue.
+func WithAsyncReplication(queueSize int) ReplicationOption {
+	return func(o *replicationOptions) {
+		o.async = true
+		if queueSize > 0 {
+			o.queueSize = queueSize
+		}
+	}
+}
+
+// WithReplicationConcurrency sets the number of background replication
+// workers and of parallel copies in Reconcile.
+func WithReplicationConcurrency(n int) ReplicationOption {
+	return func(o *replicationOptions) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// WithReplicationFailureHandler calls fn for every failed replication, e.g.
+// to log it or persist it, in addition to queuing it for RetryFailures.
+func WithReplicationFailureHandler(fn func(ReplicationFailure)) ReplicationOption {
+	return func(o *replicationOptions) { o.onFailure = fn }
+}
+
+type replicationJob struct {
+	op  ReplicationOp
+	key string
+}
+
+// ReplicatedClient writes to a primary and a secondary client, e.g. another
+// region or provider, and reads from the primary only. A write succeeds when
+// the primary succeeds; the secondary is then brought in line by copying the
+// object from the primary, so the two clients may use different key
+// builders, encryption or providers. Each copy records the primary's ETag in
+// its metadata, which Reconcile compares since the ETags of the two sides
+// need not match. Secondary failures never fail a write:
+// they are kept in a failure queue for RetryFailures, and Reconcile repairs
+// whatever was missed while the process was down.
+type ReplicatedClient struct {
+	primary   S3Client
+	secondary S3Client
+	o         *replicationOptions
+
+	mu       sync.Mutex
+	failures []ReplicationFailure
+	closed   bool
+	jobs     chan replicationJob
+	wg       sync.WaitGroup
+}
+
+var _ S3Client = (*ReplicatedClient)(nil)
+
+func NewReplicatedClient(primary, secondary S3Client, opts ...ReplicationOption) *ReplicatedClient {
+	o := &replicationOptions{queueSize: defaultReplicationQueueSize, concurrency: defaultConcurrency}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	r := &ReplicatedClient{primary: primary, secondary: secondary, o: o}
+	if o.async {
+		r.jobs = make(chan replicationJob, o.queueSize)
+		for i := 0; i < o.concurrency; i++ {
+			r.wg.Add(1)
+			go func() {
+				defer r.wg.Done()
+				for job := range r.jobs {
+					r.run(context.Background(), job)
+				}
+			}()
+		}
+	}
+	return r
+}
+
+func (r *ReplicatedClient) Primary() S3Client   { return r.primary }
+func (r *ReplicatedClient) Secondary() S3Client { return r.secondary }
+
+// Close waits for the queued replications of an asynchronous client. Writes
+// made after Close are recorded as failures.
+func (r *ReplicatedClient) Close() {
+	r.mu.Lock()
+	if r.closed || r.jobs == nil {
+		r.closed = true
+		r.mu.Unlock()
+		return
+	}
+	r.closed = true
+	close(r.jobs)
+	r.mu.Unlock()
+	r.wg.Wait()
+}
+
+// Failures returns the replications that failed and were not retried yet.
+func (r *ReplicatedClient) Failures() []ReplicationFailure {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ReplicationFailure(nil), r.failures...)
+}
+
+// RetryFailures replays the failure queue and returns the replications that
+// failed again; they stay queued.
+func (r *ReplicatedClient) RetryFailures(ctx context.Context) []ReplicationFailure {
+	r.mu.Lock()
+	failures := r.failures
+	r.failures = nil
+	r.mu.Unlock()
+
+	var failed []ReplicationFailure
+	for i, f := range failures {
+		if ctx.Err() != nil {
+			// Not attempted: back in the queue as they were.
+			r.mu.Lock()
+			r.failures = append(r.failures, failures[i:]...)
+			r.mu.Unlock()
+			failed = append(failed, failures[i:]...)
+			break
+		}
+		if err := r.replicate(ctx, replicationJob{op: f.Op, key: f.Key}); err != nil {
+			failed = append(failed, r.fail(replicationJob{op: f.Op, key: f.Key}, err))
+		}
+	}
+	return failed
+}
+
+func (r *ReplicatedClient) schedule(ctx context.Context, op ReplicationOp, keys ...string) {
+	for _, key := range keys {
+		job := replicationJob{op: op, key: key}
+		if !r.o.async {
+			r.run(ctx, job)
+			continue
+		}
+		r.mu.Lock()
+		queued := false
+		if !r.closed {
+			select {
+			case r.jobs <- job:
+				queued = true
+			default:
+			}
+		}
+		r.mu.Unlock()
+		if !queued {
+			r.fail(job, ErrReplicationQueueFull)
+		}
+	}
+}
+
+func (r *ReplicatedClient) run(ctx context.Context, job replicationJob) {
+	if err := r.replicate(ctx, job); err != nil {
+		r.fail(job, err)
+	}
+}
+
+func (r *ReplicatedClient) fail(job replicationJob, err error) ReplicationFailure {
+	failure := ReplicationFailure{Op: job.op, Key: job.key, Err: err, Time: time.Now()}
+	r.mu.Lock()
+	r.failures = append(r.failures, failure)
+	r.mu.Unlock()
+	if r.o.onFailure != nil {
+		r.o.onFailure(failure)
+	}
+	return failure
+}
+
+func (r *ReplicatedClient) replicate(ctx context.Context, job replicationJob) error {
+	if job.op == ReplicateTags {
+		tags, err := r.primary.GetTags(ctx, job.key)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return r.secondary.DeleteTags(ctx, job.key)
+		}
+		return r.secondary.SetTags(ctx, job.key, tags)
+	}
+
+	body, info, err := r.primary.DownloadFile(ctx, job.key)
+	if errors.Is(err, ErrNotFound) {
+		if err := r.secondary.DeleteFile(ctx, job.key); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	err = r.secondary.UploadLarge(ctx, job.key, body,
+		WithContentType(info.ContentType),
+		WithCacheControl(info.CacheControl),
+		WithContentDisposition(info.ContentDisposition),
+		WithContentEncoding(info.ContentEncoding),
+		WithMetadata(withSourceETag(info.Metadata, info.ETag)),
+	)
+	if err != nil {
+		return err
+	}
+	tags, err := r.primary.GetTags(ctx, job.key)
+	if err != nil || len(tags) == 0 {
+		return err
+	}
+	return r.secondary.SetTags(ctx, job.key, tags)
+}
+
+// replicationSourceETag is the metadata key of a secondary object holding
+// the ETag of the primary object it was copied from.
+const replicationSourceETag = "replication-source-etag"
+
+type ReconcileOption func(*reconcileOptions)
+
+type reconcileOptions struct {
+	delete bool
+}
+
+// WithReconcileDelete deletes secondary objects missing from the primary.
+func WithReconcileDelete() ReconcileOption {
+	return func(o *reconcileOptions) { o.delete = true }
+}
+
+type ReconcileResult struct {
+	Copied  []string
+	Deleted []string
+	// InSync counts objects already matching on both sides.
+	InSync int
+	Failed []ReplicationFailure
+}
+
+// Reconcile compares both sides under prefix and copies to the secondary
+// the objects it lacks or has with another size or content. Objects of the
+// same size whose ETags differ, as with other encryption or part sizes, are
+// checked with a HEAD against the primary ETag recorded on replication.
+// Failed copies are returned, not queued.
+func (r *ReplicatedClient) Reconcile(ctx context.Context, prefix string, opts ...ReconcileOption) (*ReconcileResult, error) {
+	o := &reconcileOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	secondary := make(map[string]ObjectInfo)
+	err := r.secondary.ListAll(ctx, prefix, func(obj ObjectInfo) error {
+		secondary[obj.Key] = obj
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReconcileResult{}
+	var pending []reconcileJob
+	err = r.primary.ListAll(ctx, prefix, func(obj ObjectInfo) error {
+		replica, ok := secondary[obj.Key]
+		delete(secondary, obj.Key)
+		switch {
+		case ok && obj.Size == replica.Size && obj.ETag == replica.ETag:
+			result.InSync++
+		case ok && obj.Size == replica.Size:
+			pending = append(pending, reconcileJob{key: obj.Key, sourceETag: obj.ETag})
+		default:
+			pending = append(pending, reconcileJob{key: obj.Key})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if o.delete {
+		// Replicating a key missing from the primary deletes it.
+		for key := range secondary {
+			pending = append(pending, reconcileJob{key: key, extra: true})
+		}
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	jobs := make(chan reconcileJob)
+	for i := 0; i < min(r.o.concurrency, len(pending)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if job.sourceETag != "" && r.replicaOf(ctx, job.key, job.sourceETag) {
+					mu.Lock()
+					result.InSync++
+					mu.Unlock()
+					continue
+				}
+				err := r.replicate(ctx, replicationJob{op: ReplicateObject, key: job.key})
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					result.Failed = append(result.Failed, ReplicationFailure{Op: ReplicateObject, Key: job.key, Err: err, Time: time.Now()})
+				case job.extra:
+					result.Deleted = append(result.Deleted, job.key)
+				default:
+					result.Copied = append(result.Copied, job.key)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, job := range pending {
+		select {
+		case jobs <- job:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return result, ctx.Err()
+}
+
+type reconcileJob struct {
+	key string
+	// sourceETag is set when the sizes match but the ETags do not, so the
+	// secondary may still hold a copy of this primary version.
+	sourceETag string
+	// extra marks a secondary object missing from the primary.
+	extra bool
+}
+
+// replicaOf reports whether the secondary object at key was copied from the
+// primary object with sourceETag. A failed HEAD counts as a mismatch, which
+// makes Reconcile copy the object.
+func (r *ReplicatedClient) replicaOf(ctx context.Context, key, sourceETag string) bool {
+	info, err := r.secondary.GetObjectInfo(ctx, key)
+	if err != nil {
+		return false
+	}
+	return metadataValue(info.Metadata, replicationSourceETag) == sourceETag
+}
+
+// metadataValue looks name up in meta ignoring case, since providers differ
+// in how they case user metadata keys.
+func metadataValue(meta map[string]string, name string) string {
+	for k, v := range meta {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+func (r *ReplicatedClient) UploadFile(ctx context.Context, objectID string, key string, body io.Reader, contentType string, opts ...PutOption) (*UploadResult, error) {
+	result, err := r.primary.UploadFile(ctx, objectID, key, body, contentType, opts...)
+	if err != nil {
+		return nil, err
+	}
+	r.schedule(ctx, ReplicateObject, result.Key)
+	return result, nil
+}
+
+func (r *ReplicatedClient) UploadLarge(ctx context.Context, key string, rd io.Reader, opts ...UploadOption) error {
+	if err := r.primary.UploadLarge(ctx, key, rd, opts...); err != nil {
+		return err
+	}
+	r.schedule(ctx, ReplicateObject, key)
+	return nil
+}
+
+func (r *ReplicatedClient) DownloadFile(ctx context.Context, key string, opts ...DownloadOption) (io.ReadCloser, *ObjectInfo, error) {
+	return r.primary.DownloadFile(ctx, key, opts...)
+}
+
+func (r *ReplicatedClient) DownloadRange(ctx context.Context, key string, offset, length int64, opts ...DownloadOption) (io.ReadCloser, *ObjectInfo, error) {
+	return r.primary.DownloadRange(ctx, key, offset, length, opts...)
+}
+
+func (r *ReplicatedClient) DownloadResumable(ctx context.Context, key string, w io.WriterAt, cp *DownloadCheckpoint, opts ...DownloadOption) error {
+	return r.primary.DownloadResumable(ctx, key, w, cp, opts...)
+}
+
+func (r *ReplicatedClient) DownloadLarge(ctx context.Context, key string, w io.WriterAt, opts ...DownloadOption) (int64, error) {
+	return r.primary.DownloadLarge(ctx, key, w, opts...)
+}
+
+// DeleteFile deletes key on both sides. Deleting a specific version on the
+// primary replicates whatever the primary's current object is then.
+func (r *ReplicatedClient) DeleteFile(ctx context.Context, key string, opts ...DeleteOption) error {
+	if err := r.primary.DeleteFile(ctx, key, opts...); err != nil {
+		return err
+	}
+	r.schedule(ctx, ReplicateObject, key)
+	return nil
+}
+
+func (r *ReplicatedClient) DeleteFiles(ctx context.Context, keys []string) (*DeleteResult, error) {
+	result, err := r.primary.DeleteFiles(ctx, keys)
+	if result != nil {
+		r.schedule(ctx, ReplicateObject, result.Deleted...)
+	}
+	return result, err
+}
+
+func (r *ReplicatedClient) DeletePrefix(ctx context.Context, prefix string) (*DeleteResult, error) {
+	result, err := r.primary.DeletePrefix(ctx, prefix)
+	if result != nil {
+		r.schedule(ctx, ReplicateObject, result.Deleted...)
+	}
+	return result, err
+}
+
+func (r *ReplicatedClient) CopyFile(ctx context.Context, srcKey, dstKey string, opts ...CopyOption) error {
+	if err := r.primary.CopyFile(ctx, srcKey, dstKey, opts...); err != nil {
+		return err
+	}
+	r.schedule(ctx, ReplicateObject, dstKey)
+	return nil
+}
+
+func (r *ReplicatedClient) MoveFile(ctx context.Context, srcKey, dstKey string) error {
+	if err := r.primary.MoveFile(ctx, srcKey, dstKey); err != nil {
+		return err
+	}
+	r.schedule(ctx, ReplicateObject, dstKey, srcKey)
+	return nil
+}
+
+func (r *ReplicatedClient) RenamePrefix(ctx context.Context, oldPrefix, newPrefix string, opts ...RenameOption) (*RenameResult, error) {
+	result, err := r.primary.RenamePrefix(ctx, oldPrefix, newPrefix, opts...)
+	if result != nil && !result.DryRun {
+		for _, renamed := range result.Renamed {
+			r.schedule(ctx, ReplicateObject, renamed.To, renamed.From)
+		}
+	}
+	return result, err
+}
+
+func (r *ReplicatedClient) FileExists(ctx context.Context, key string) (bool, error) {
+	return r.primary.FileExists(ctx, key)
+}
+
+func (r *ReplicatedClient) GetObjectInfo(ctx context.Context, key string) (*ObjectInfo, error) {
+	return r.primary.GetObjectInfo(ctx, key)
+}
+
+func (r *ReplicatedClient) UpdateMetadata(ctx context.Context, key string, meta map[string]string) error {
+	if err := r.primary.UpdateMetadata(ctx, key, meta); err != nil {
+		return err
+	}
+	r.schedule(ctx, ReplicateObject, key)
+	return nil
+}
+
+func (r *ReplicatedClient) SetTags(ctx context.Context, key string, tags map[string]string) error {
+	if err := r.primary.SetTags(ctx, key, tags); err != nil {
+		return err
+	}
+	r.schedule(ctx, ReplicateTags, key)
+	return nil
+}
+
+func (r *ReplicatedClient) GetTags(ctx context.Context, key string) (map[string]string, error) {
+	return r.primary.GetTags(ctx, key)
+}
+
+func (r *ReplicatedClient) DeleteTags(ctx context.Context, key string) error {
+	if err := r.primary.DeleteTags(ctx, key); err != nil {
+		return err
+	}
+	r.schedule(ctx, ReplicateTags, key)
+	return nil
+}
+
+func (r *ReplicatedClient) List(prefix string) *ListIterator {
+	return r.primary.List(prefix)
+}
+
+func (r *ReplicatedClient) ListAll(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	return r.primary.ListAll(ctx, prefix, fn)
+}
+
+func (r *ReplicatedClient) ListObjectsInfo(ctx context.Context, prefix string, opts ...ListOption) ([]ObjectInfo, error) {
+	return r.primary.ListObjectsInfo(ctx, prefix, opts...)
+}
+
+func (r *ReplicatedClient) ListDirectory(ctx context.Context, prefix string) (*DirectoryListing, error) {
+	return r.primary.ListDirectory(ctx, prefix)
+}
+
+func (r *ReplicatedClient) GetObjects(ctx context.Context, prefix string) ([]string, error) {
+	return r.primary.GetObjects(ctx, prefix)
+}
+
+func (r *ReplicatedClient) GetPresignedURL(ctx context.Context, key string, expiration time.Duration, opts ...PresignOption) (string, error) {
+	return r.primary.GetPresignedURL(ctx, key, expiration, opts...)
+}
+
+// PresignPostPolicy presigns an upload to the primary. Such uploads bypass
+// the client and are only replicated by Reconcile.
+func (r *ReplicatedClient) PresignPostPolicy(ctx context.Context, opts PostPolicyOptions) (*PresignedPost, error) {
+	return r.primary.PresignPostPolicy(ctx, opts)
+}
+
+func (r *ReplicatedClient) KeyFromURL(rawURL string) (string, error) {
+	return r.primary.KeyFromURL(rawURL)
+}
+
+func (r *ReplicatedClient) FindKeyByPresignedURL(ctx context.Context, presignedURL string, prefix string) (string, error) {
+	return r.primary.FindKeyByPresignedURL(ctx, presignedURL, prefix)
+}
+
+func (r *ReplicatedClient) Bucket() string   { return r.primary.Bucket() }
+func (r *ReplicatedClient) Endpoint() string { return r.primary.Endpoint() }
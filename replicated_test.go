@@ -0,0 +1,111 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func putMemory(t *testing.T, m *MemoryClient, key, body string, opts ...UploadOption) {
+	t.Helper()
+	if err := m.UploadLarge(context.Background(), key, strings.NewReader(body), opts...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readMemory(t *testing.T, m *MemoryClient, key string) string {
+	t.Helper()
+	body, _, err := m.DownloadFile(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestReplicatedClientWriteRecordsSourceETag(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := NewMemoryClient("primary"), NewMemoryClient("secondary")
+	r := NewReplicatedClient(primary, secondary)
+	defer r.Close()
+
+	if err := r.UploadLarge(ctx, "docs/a.txt", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readMemory(t, secondary, "docs/a.txt"); got != "hello" {
+		t.Fatalf("secondary content = %q, want %q", got, "hello")
+	}
+	source, err := primary.GetObjectInfo(ctx, "docs/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.replicaOf(ctx, "docs/a.txt", source.ETag) {
+		t.Fatal("replica does not record the primary ETag")
+	}
+	if len(r.Failures()) != 0 {
+		t.Fatalf("failures = %v, want none", r.Failures())
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := NewMemoryClient("primary"), NewMemoryClient("secondary")
+	r := NewReplicatedClient(primary, secondary)
+	defer r.Close()
+
+	putMemory(t, primary, "docs/same.txt", "same")
+	putMemory(t, secondary, "docs/same.txt", "same")
+	putMemory(t, primary, "docs/missing.txt", "missing")
+	putMemory(t, primary, "docs/resized.txt", "new content")
+	putMemory(t, secondary, "docs/resized.txt", "old")
+	putMemory(t, secondary, "docs/extra.txt", "extra")
+
+	// Same size, another ETag: a replica made under other encryption or
+	// part sizes is recognised by its recorded source ETag.
+	putMemory(t, primary, "docs/marked.txt", "hello")
+	marked, err := primary.GetObjectInfo(ctx, "docs/marked.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	putMemory(t, secondary, "docs/marked.txt", "HELLO", WithMetadata(map[string]string{replicationSourceETag: marked.ETag}))
+	putMemory(t, primary, "docs/stale.txt", "world")
+	putMemory(t, secondary, "docs/stale.txt", "WORLD", WithMetadata(map[string]string{replicationSourceETag: `"stale"`}))
+
+	result, err := r.Reconcile(ctx, "docs/", WithReconcileDelete())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Failed) != 0 {
+		t.Fatalf("failed = %v, want none", result.Failed)
+	}
+	if result.InSync != 2 {
+		t.Errorf("in sync = %d, want 2", result.InSync)
+	}
+	sort.Strings(result.Copied)
+	if got := strings.Join(result.Copied, ","); got != "docs/missing.txt,docs/resized.txt,docs/stale.txt" {
+		t.Errorf("copied = %s", got)
+	}
+	if got := strings.Join(result.Deleted, ","); got != "docs/extra.txt" {
+		t.Errorf("deleted = %s", got)
+	}
+
+	for key, want := range map[string]string{
+		"docs/missing.txt": "missing",
+		"docs/resized.txt": "new content",
+		"docs/stale.txt":   "world",
+		"docs/marked.txt":  "HELLO",
+	} {
+		if got := readMemory(t, secondary, key); got != want {
+			t.Errorf("secondary %s = %q, want %q", key, got, want)
+		}
+	}
+	if exists, err := secondary.FileExists(ctx, "docs/extra.txt"); err != nil || exists {
+		t.Errorf("docs/extra.txt exists = %v (%v), want deleted", exists, err)
+	}
+}
@@ -0,0 +1,129 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// RequestOptions are per-call settings carried in the context, so they reach
+// the S3 requests a method makes, including each part of a multipart
+// upload, without changing method signatures. The audit actor is set the
+// same way with WithActor.
+type RequestOptions struct {
+	// UserAgent is appended to the User-Agent header, e.g. "billing/1.4".
+	UserAgent string
+	// RequestID is sent as X-Request-ID to correlate the requests with the
+	// caller's logs through proxies and gateways.
+	RequestID string
+	// IdempotencyToken is sent as Idempotency-Key, which S3-compatible
+	// gateways use to deduplicate retried writes. Amazon S3 ignores it. It
+	// goes only on the request that starts the write (PutObject, CopyObject
+	// or CreateMultipartUpload), since the parts and the completion of a
+	// multipart upload carry other payloads under the same token.
+	IdempotencyToken string
+	// Header holds extra headers, e.g. a Cache-Control hint for a caching
+	// proxy. They are signed with the request.
+	Header http.Header
+}
+
+type requestOptionsKey struct{}
+
+// WithRequestOptions returns a context whose requests carry opts. Fields
+// left empty keep the values of options already in ctx; headers are merged.
+func WithRequestOptions(ctx context.Context, opts RequestOptions) context.Context {
+	merged := RequestOptionsFromContext(ctx)
+	if opts.UserAgent != "" {
+		merged.UserAgent = opts.UserAgent
+	}
+	if opts.RequestID != "" {
+		merged.RequestID = opts.RequestID
+	}
+	if opts.IdempotencyToken != "" {
+		merged.IdempotencyToken = opts.IdempotencyToken
+	}
+	if len(opts.Header) > 0 {
+		header := merged.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		for name, values := range opts.Header {
+			header[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+		}
+		merged.Header = header
+	}
+	return context.WithValue(ctx, requestOptionsKey{}, merged)
+}
+
+func RequestOptionsFromContext(ctx context.Context) RequestOptions {
+	opts, _ := ctx.Value(requestOptionsKey{}).(RequestOptions)
+	return opts
+}
+
+func WithUserAgent(ctx context.Context, userAgent string) context.Context {
+	return WithRequestOptions(ctx, RequestOptions{UserAgent: userAgent})
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return WithRequestOptions(ctx, RequestOptions{RequestID: id})
+}
+
+func WithIdempotencyToken(ctx context.Context, token string) context.Context {
+	return WithRequestOptions(ctx, RequestOptions{IdempotencyToken: token})
+}
+
+// WithRequestHeader returns a context whose requests carry the header name:
+// value, replacing a value set earlier in ctx.
+func WithRequestHeader(ctx context.Context, name, value string) context.Context {
+	return WithRequestOptions(ctx, RequestOptions{Header: http.Header{name: {value}}})
+}
+
+// addRequestOptions applies RequestOptions after the SDK has set its own
+// User-Agent and before the request is signed. Presigned URLs are not
+// affected, since their users would have to send the headers.
+func addRequestOptions(stack *middleware.Stack) error {
+	if isPresignStack(stack) {
+		return nil
+	}
+	return stack.Build.Add(&requestOptionsMiddleware{operation: stack.ID()}, middleware.After)
+}
+
+// idempotentOperations start a write and carry the idempotency token.
+var idempotentOperations = map[string]bool{
+	"PutObject":             true,
+	"CopyObject":            true,
+	"CreateMultipartUpload": true,
+}
+
+type requestOptionsMiddleware struct {
+	operation string
+}
+
+func (*requestOptionsMiddleware) ID() string { return "go-s3.RequestOptions" }
+
+func (m *requestOptionsMiddleware) HandleBuild(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+	opts, ok := ctx.Value(requestOptionsKey{}).(RequestOptions)
+	req, isHTTP := in.Request.(*smithyhttp.Request)
+	if !ok || !isHTTP {
+		return next.HandleBuild(ctx, in)
+	}
+	if opts.UserAgent != "" {
+		if current := req.Header.Get("User-Agent"); current != "" {
+			req.Header.Set("User-Agent", current+" "+opts.UserAgent)
+		} else {
+			req.Header.Set("User-Agent", opts.UserAgent)
+		}
+	}
+	if opts.RequestID != "" {
+		req.Header.Set("X-Request-ID", opts.RequestID)
+	}
+	if opts.IdempotencyToken != "" && idempotentOperations[m.operation] {
+		req.Header.Set("Idempotency-Key", opts.IdempotencyToken)
+	}
+	for name, values := range opts.Header {
+		req.Header[name] = append([]string(nil), values...)
+	}
+	return next.HandleBuild(ctx, in)
+}
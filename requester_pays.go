@@ -0,0 +1,51 @@
+package s3
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+type requesterPaysKey struct{}
+
+// WithRequesterPays returns a context under which every request made with
+// it accepts being charged for reading a requester-pays bucket, regardless
+// of Config.RequesterPays.
+func WithRequesterPays(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requesterPaysKey{}, true)
+}
+
+// addRequesterPays sets RequestPayer on every input that has it. It also
+// runs on presign stacks: presigned URLs then sign the
+// "x-amz-request-payer: requester" header, which their users must send.
+func addRequesterPays(always bool) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(&requesterPaysMiddleware{always: always}, middleware.After)
+	}
+}
+
+type requesterPaysMiddleware struct {
+	always bool
+}
+
+func (*requesterPaysMiddleware) ID() string { return "go-s3.RequesterPays" }
+
+func (m *requesterPaysMiddleware) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	if m.always || ctx.Value(requesterPaysKey{}) != nil {
+		setRequestPayer(in.Parameters)
+	}
+	return next.HandleInitialize(ctx, in)
+}
+
+func setRequestPayer(params any) {
+	v := reflect.ValueOf(params)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+	f := v.Elem().FieldByName("RequestPayer")
+	if f.IsValid() && f.Type() == reflect.TypeOf(types.RequestPayer("")) && f.String() == "" {
+		f.SetString(string(types.RequestPayerRequester))
+	}
+}
@@ -0,0 +1,130 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// RestoreTier trades restore speed for cost.
+type RestoreTier string
+
+const (
+	RestoreExpedited RestoreTier = RestoreTier(types.TierExpedited)
+	RestoreStandard  RestoreTier = RestoreTier(types.TierStandard)
+	RestoreBulk      RestoreTier = RestoreTier(types.TierBulk)
+)
+
+var (
+	restoreOngoingPattern = regexp.MustCompile(`ongoing-request="(true|false)"`)
+	restoreExpiryPattern  = regexp.MustCompile(`expiry-date="([^"]+)"`)
+)
+
+// RestoreStatus describes whether an archived object can be downloaded.
+type RestoreStatus struct {
+	StorageClass string
+	// Archived is set for GLACIER and DEEP_ARCHIVE objects, and for
+	// Intelligent-Tiering objects in an archive tier.
+	Archived bool
+	// InProgress is set while a restore requested by RestoreObject runs.
+	InProgress bool
+	// Restored is set when a temporary copy is available until ExpiresAt.
+	Restored  bool
+	ExpiresAt time.Time
+}
+
+// Ready reports whether the object can be downloaded now.
+func (s *RestoreStatus) Ready() bool {
+	return !s.Archived || s.Restored
+}
+
+// RestoreObject starts restoring an archived object for days days. An empty
+// tier uses the S3 default, RestoreStandard. A restore that is already
+// running is not an error.
+func (c *Client) RestoreObject(ctx context.Context, key string, days int, tier RestoreTier) error {
+	request := &types.RestoreRequest{}
+	if tier != "" {
+		request.GlacierJobParameters = &types.GlacierJobParameters{Tier: types.Tier(tier)}
+	}
+	// Intelligent-Tiering archive tiers restore into the frequent access
+	// tier and reject Days.
+	if days > 0 {
+		request.Days = aws.Int32(int32(days))
+	}
+	_, err := c.client.RestoreObject(ctx, &s3.RestoreObjectInput{
+		Bucket:         aws.String(c.bucket),
+		Key:            aws.String(key),
+		RestoreRequest: request,
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "RestoreAlreadyInProgress" {
+			return nil
+		}
+		return fmt.Errorf("failed to restore object: %w", err)
+	}
+	return nil
+}
+
+// RestoreStatus reads the restore state of key from the x-amz-restore
+// header.
+func (c *Client) RestoreStatus(ctx context.Context, key string) (*RestoreStatus, error) {
+	head, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object info: %w", err)
+	}
+
+	status := &RestoreStatus{
+		StorageClass: string(head.StorageClass),
+		Archived: head.StorageClass == types.StorageClassGlacier ||
+			head.StorageClass == types.StorageClassDeepArchive ||
+			head.ArchiveStatus != "",
+	}
+	restore := aws.ToString(head.Restore)
+	if m := restoreOngoingPattern.FindStringSubmatch(restore); m != nil {
+		status.InProgress = m[1] == "true"
+		status.Restored = m[1] == "false"
+	}
+	if m := restoreExpiryPattern.FindStringSubmatch(restore); m != nil {
+		if expires, err := http.ParseTime(m[1]); err == nil {
+			status.ExpiresAt = expires
+		}
+	}
+	return status, nil
+}
+
+// WaitForRestore polls RestoreStatus every interval until key can be
+// downloaded or ctx is done. Restores take minutes to hours depending on
+// the tier, so run it in its own goroutine to get notified.
+func (c *Client) WaitForRestore(ctx context.Context, key string, interval time.Duration) (*RestoreStatus, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		status, err := c.RestoreStatus(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if status.Ready() {
+			return status, nil
+		}
+		if !status.InProgress {
+			return status, fmt.Errorf("no restore in progress for %s", key)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return status, ctx.Err()
+		}
+	}
+}
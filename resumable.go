@@ -0,0 +1,325 @@
+package s3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// UploadState is the persisted progress of a resumable upload: the multipart
+// upload it continues and the parts already stored.
+type UploadState struct {
+	Key      string         `json:"key"`
+	UploadID string         `json:"upload_id"`
+	Size     int64          `json:"size"`
+	PartSize int64          `json:"part_size"`
+	Parts    []UploadedPart `json:"parts"`
+}
+
+type UploadedPart struct {
+	Number         int32  `json:"number"`
+	ETag           string `json:"etag"`
+	ChecksumCRC32  string `json:"checksum_crc32,omitempty"`
+	ChecksumCRC32C string `json:"checksum_crc32c,omitempty"`
+	ChecksumSHA1   string `json:"checksum_sha1,omitempty"`
+	ChecksumSHA256 string `json:"checksum_sha256,omitempty"`
+}
+
+// UploadStateStore persists the state of one resumable upload. Load returns
+// nil without an error when nothing was saved.
+type UploadStateStore interface {
+	Load() (*UploadState, error)
+	Save(state *UploadState) error
+	Delete() error
+}
+
+// FileUploadStateStore keeps the upload state as JSON in path. Saves replace
+// the file atomically, so a crash never leaves a truncated state.
+func FileUploadStateStore(path string) UploadStateStore {
+	return fileStateStore(path)
+}
+
+type fileStateStore string
+
+func (s fileStateStore) Load() (*UploadState, error) {
+	data, err := os.ReadFile(string(s))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload state: %w", err)
+	}
+	var state UploadState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode upload state: %w", err)
+	}
+	return &state, nil
+}
+
+func (s fileStateStore) Save(state *UploadState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode upload state: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(string(s)), filepath.Base(string(s))+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write upload state: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), string(s))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write upload state: %w", err)
+	}
+	return nil
+}
+
+func (s fileStateStore) Delete() error {
+	if err := os.Remove(string(s)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete upload state: %w", err)
+	}
+	return nil
+}
+
+// UploadResumable uploads size bytes of r to key in parts and records every
+// stored part in store. When a previous attempt failed or the process died,
+// calling it again with the same store uploads only the missing parts. The
+// multipart upload is left in place on failure so it can be resumed; abort it
+// with AbortStaleUploads if it is abandoned. If the multipart upload no
+// longer exists, the upload starts over.
+func (c *Client) UploadResumable(ctx context.Context, key string, r io.ReaderAt, size int64, store UploadStateStore, opts ...UploadOption) error {
+	if store == nil {
+		return errors.New("upload state store is required")
+	}
+	o := c.newUploadOptions(opts)
+	if o.partSize < minPartSize || o.partSize > maxPartSize {
+		return fmt.Errorf("part size must be between %d and %d bytes", minPartSize, maxPartSize)
+	}
+	if (size+o.partSize-1)/o.partSize > maxUploadParts {
+		return fmt.Errorf("upload exceeds %d parts, increase the part size", maxUploadParts)
+	}
+	state, err := store.Load()
+	if err != nil {
+		return err
+	}
+	if state == nil && size <= o.partSize {
+		return c.UploadLarge(ctx, key, io.NewSectionReader(r, 0, size), opts...)
+	}
+	if state != nil && (state.Key != key || state.Size != size) {
+		return fmt.Errorf("upload state belongs to %s (%d bytes), not %s (%d bytes)", state.Key, state.Size, key, size)
+	}
+	if state != nil {
+		// The part size of the interrupted upload wins so that its parts
+		// line up.
+		o.partSize = state.PartSize
+		if state.Parts, err = c.storedParts(ctx, key, state.UploadID); errors.Is(err, errUploadGone) {
+			state = nil
+		} else if err != nil {
+			return err
+		}
+	}
+	if state == nil {
+		if o.contentType == "" {
+			if o.contentType, _, err = c.sniffContentType(key, io.NewSectionReader(r, 0, sniffLen)); err != nil {
+				return err
+			}
+		}
+		if err := c.validateSource(r, size, o.contentType); err != nil {
+			return err
+		}
+		createInput := &s3.CreateMultipartUploadInput{
+			Bucket: aws.String(c.bucket),
+			Key:    aws.String(key),
+		}
+		o.applyCreateMultipart(createInput)
+		created, err := c.client.CreateMultipartUpload(ctx, createInput)
+		if err != nil {
+			return fmt.Errorf("failed to create multipart upload: %w", err)
+		}
+		state = &UploadState{Key: key, UploadID: aws.ToString(created.UploadId), Size: size, PartSize: o.partSize}
+	}
+	if err := store.Save(state); err != nil {
+		return err
+	}
+
+	if err := c.uploadMissingParts(ctx, r, state, store, o); err != nil {
+		return err
+	}
+
+	parts := make([]types.CompletedPart, 0, len(state.Parts))
+	for _, p := range state.Parts {
+		parts = append(parts, types.CompletedPart{
+			PartNumber:     aws.Int32(p.Number),
+			ETag:           aws.String(p.ETag),
+			ChecksumCRC32:  stringOrNil(p.ChecksumCRC32),
+			ChecksumCRC32C: stringOrNil(p.ChecksumCRC32C),
+			ChecksumSHA1:   stringOrNil(p.ChecksumSHA1),
+			ChecksumSHA256: stringOrNil(p.ChecksumSHA256),
+		})
+	}
+	_, err = c.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(c.bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(state.UploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	}, o.conditionalWrite()...)
+	if err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	return store.Delete()
+}
+
+// validateSource applies Config.Validation before the first part is sent,
+// since parts are not uploaded in order. A Validator reads the whole source.
+func (c *Client) validateSource(r io.ReaderAt, size int64, contentType string) error {
+	if err := c.validation.checkContentType(contentType); err != nil {
+		return err
+	}
+	validated := c.validation.body(io.NewSectionReader(r, 0, size))
+	if validated == nil {
+		return nil
+	}
+	if _, err := io.Copy(io.Discard, validated); err != nil {
+		validated.close()
+		return fmt.Errorf("failed to read upload body: %w", err)
+	}
+	return validated.verdict()
+}
+
+var errUploadGone = errors.New("multipart upload no longer exists")
+
+// storedParts returns the parts S3 holds for the upload. They are the source
+// of truth: a part may have been stored just before a crash prevented the
+// state from being saved.
+func (c *Client) storedParts(ctx context.Context, key, uploadID string) ([]UploadedPart, error) {
+	var parts []UploadedPart
+	paginator := s3.NewListPartsPaginator(c.client, &s3.ListPartsInput{
+		Bucket:   aws.String(c.bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			var apiErr smithy.APIError
+			if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchUpload" {
+				return nil, errUploadGone
+			}
+			return nil, fmt.Errorf("failed to list uploaded parts: %w", err)
+		}
+		for _, p := range page.Parts {
+			parts = append(parts, UploadedPart{
+				Number:         aws.ToInt32(p.PartNumber),
+				ETag:           aws.ToString(p.ETag),
+				ChecksumCRC32:  aws.ToString(p.ChecksumCRC32),
+				ChecksumCRC32C: aws.ToString(p.ChecksumCRC32C),
+				ChecksumSHA1:   aws.ToString(p.ChecksumSHA1),
+				ChecksumSHA256: aws.ToString(p.ChecksumSHA256),
+			})
+		}
+	}
+	return parts, nil
+}
+
+func (c *Client) uploadMissingParts(ctx context.Context, r io.ReaderAt, state *UploadState, store UploadStateStore, o *uploadOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	count := int32((state.Size + state.PartSize - 1) / state.PartSize)
+	stored := make(map[int32]bool, len(state.Parts))
+	for _, p := range state.Parts {
+		stored[p.Number] = true
+	}
+	progress := newProgressTracker(o.progress, state.Size)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	setErr := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+		mu.Unlock()
+	}
+	sem := make(chan struct{}, o.concurrency)
+	for number := int32(1); number <= count; number++ {
+		offset := int64(number-1) * state.PartSize
+		length := min(state.PartSize, state.Size-offset)
+		if stored[number] {
+			progress.add(length)
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(number int32, offset, length int64) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			output, err := c.client.UploadPart(ctx, &s3.UploadPartInput{
+				Bucket:            aws.String(c.bucket),
+				Key:               aws.String(state.Key),
+				UploadId:          aws.String(state.UploadID),
+				PartNumber:        aws.Int32(number),
+				Body:              io.NewSectionReader(r, offset, length),
+				ContentLength:     aws.Int64(length),
+				ChecksumAlgorithm: o.checksumAlgorithm(),
+			}, progress.apiOptions()...)
+			if err != nil {
+				setErr(fmt.Errorf("failed to upload part %d: %w", number, err))
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			state.Parts = append(state.Parts, UploadedPart{
+				Number:         number,
+				ETag:           aws.ToString(output.ETag),
+				ChecksumCRC32:  aws.ToString(output.ChecksumCRC32),
+				ChecksumCRC32C: aws.ToString(output.ChecksumCRC32C),
+				ChecksumSHA1:   aws.ToString(output.ChecksumSHA1),
+				ChecksumSHA256: aws.ToString(output.ChecksumSHA256),
+			})
+			if err := store.Save(state); err != nil && firstErr == nil {
+				firstErr = err
+				cancel()
+			}
+		}(number, offset, length)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	sort.Slice(state.Parts, func(i, j int) bool { return state.Parts[i].Number < state.Parts[j].Number })
+	progress.finish()
+	return nil
+}
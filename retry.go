@@ -0,0 +1,132 @@
+package s3
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+)
+
+// RetryClass selects which kinds of failures are retried. Classes can be
+// combined with |.
+type RetryClass uint
+
+const (
+	// RetryThrottling covers SlowDown, 429 and the other throttling codes.
+	RetryThrottling RetryClass = 1 << iota
+	// RetryServerErrors covers HTTP 500, 502, 503, 504 and RequestTimeout.
+	RetryServerErrors
+	// RetryConnectionErrors covers connection resets, refused connections
+	// and transport timeouts.
+	RetryConnectionErrors
+
+	RetryAll = RetryThrottling | RetryServerErrors | RetryConnectionErrors
+)
+
+type RetryOptions struct {
+	// MaxAttempts includes the first attempt; 1 disables retries. Zero keeps
+	// the SDK default of 3.
+	MaxAttempts int
+	// BaseDelay and MaxBackoff configure exponential backoff with full
+	// jitter: attempt n sleeps a random duration in [0, min(MaxBackoff,
+	// BaseDelay*2^(n-1))]. Zero keeps the SDK backoff.
+	BaseDelay  time.Duration
+	MaxBackoff time.Duration
+	// Classes restricts retries to the given failure classes. Zero keeps the
+	// SDK defaults, which match RetryAll.
+	Classes RetryClass
+	// DisableRetryQuota turns off the SDK client-side retry token bucket,
+	// which otherwise stops retrying after a burst of failures.
+	DisableRetryQuota bool
+}
+
+type noRetryKey struct{}
+
+// WithNoRetry returns a context under which every S3 request is attempted
+// exactly once, for latency-sensitive paths that prefer a fast failure.
+func WithNoRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryKey{}, true)
+}
+
+func newRetryer(opts RetryOptions) func() aws.Retryer {
+	return func() aws.Retryer {
+		standard := retry.NewStandard(func(o *retry.StandardOptions) {
+			if opts.MaxAttempts > 0 {
+				o.MaxAttempts = opts.MaxAttempts
+			}
+			if opts.MaxBackoff > 0 {
+				o.MaxBackoff = opts.MaxBackoff
+				o.Backoff = retry.NewExponentialJitterBackoff(opts.MaxBackoff)
+			}
+			if opts.BaseDelay > 0 {
+				maxBackoff := opts.MaxBackoff
+				if maxBackoff <= 0 {
+					maxBackoff = retry.DefaultMaxBackoff
+				}
+				o.Backoff = jitterBackoff{base: opts.BaseDelay, max: maxBackoff}
+			}
+			if opts.Classes != 0 {
+				o.Retryables = retryablesFor(opts.Classes)
+			}
+			if opts.DisableRetryQuota {
+				o.RateLimiter = ratelimit.None
+			}
+		})
+		return &contextRetryer{RetryerV2: standard}
+	}
+}
+
+func retryablesFor(classes RetryClass) []retry.IsErrorRetryable {
+	retryables := []retry.IsErrorRetryable{
+		retry.NoRetryCanceledError{},
+		retry.RetryableError{},
+	}
+	if classes&RetryThrottling != 0 {
+		retryables = append(retryables,
+			retry.RetryableErrorCode{Codes: retry.DefaultThrottleErrorCodes},
+			retry.RetryableHTTPStatusCode{Codes: map[int]struct{}{429: {}}},
+		)
+	}
+	if classes&RetryServerErrors != 0 {
+		retryables = append(retryables,
+			retry.RetryableHTTPStatusCode{Codes: retry.DefaultRetryableHTTPStatusCodes},
+			retry.RetryableErrorCode{Codes: retry.DefaultRetryableErrorCodes},
+		)
+	}
+	if classes&RetryConnectionErrors != 0 {
+		retryables = append(retryables, retry.RetryableConnectionError{})
+	}
+	return retryables
+}
+
+type jitterBackoff struct {
+	base time.Duration
+	max  time.Duration
+}
+
+func (b jitterBackoff) BackoffDelay(attempt int, _ error) (time.Duration, error) {
+	ceiling := b.max
+	if shift := attempt - 1; shift < 32 {
+		if d := b.base << shift; d > 0 && d < ceiling {
+			ceiling = d
+		}
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1)), nil
+}
+
+// contextRetryer refuses retries for requests made under WithNoRetry. The
+// retry middleware returns the GetRetryToken error as is, so the caller sees
+// the original request error.
+type contextRetryer struct {
+	aws.RetryerV2
+}
+
+func (r *contextRetryer) GetRetryToken(ctx context.Context, opErr error) (func(error) error, error) {
+	if noRetry, _ := ctx.Value(noRetryKey{}).(bool); noRetry {
+		return nil, opErr
+	}
+	return r.RetryerV2.GetRetryToken(ctx, opErr)
+}
@@ -0,0 +1,263 @@
+// Package s3mock provides a stub implementation of s3.S3Client whose
+// behaviour is set per method through function fields.
+//
+//	client := &s3mock.Client{
+//		FileExistsFunc: func(ctx context.Context, key string) (bool, error) {
+//			return true, nil
+//		},
+//	}
+//
+// Methods without a function return ErrNotImplemented so tests fail loudly
+// on unexpected calls.
+package s3mock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	s3 "github.com/aranoy15/go-s3"
+)
+
+var ErrNotImplemented = errors.New("s3mock: method not implemented")
+
+type Client struct {
+	UploadFileFunc            func(ctx context.Context, objectID string, key string, body io.Reader, contentType string, opts ...s3.PutOption) (*s3.UploadResult, error)
+	UploadLargeFunc           func(ctx context.Context, key string, r io.Reader, opts ...s3.UploadOption) error
+	DownloadFileFunc          func(ctx context.Context, key string, opts ...s3.DownloadOption) (io.ReadCloser, *s3.ObjectInfo, error)
+	DownloadRangeFunc         func(ctx context.Context, key string, offset, length int64, opts ...s3.DownloadOption) (io.ReadCloser, *s3.ObjectInfo, error)
+	DownloadResumableFunc     func(ctx context.Context, key string, w io.WriterAt, cp *s3.DownloadCheckpoint, opts ...s3.DownloadOption) error
+	DownloadLargeFunc         func(ctx context.Context, key string, w io.WriterAt, opts ...s3.DownloadOption) (int64, error)
+	DeleteFileFunc            func(ctx context.Context, key string, opts ...s3.DeleteOption) error
+	DeleteFilesFunc           func(ctx context.Context, keys []string) (*s3.DeleteResult, error)
+	DeletePrefixFunc          func(ctx context.Context, prefix string) (*s3.DeleteResult, error)
+	CopyFileFunc              func(ctx context.Context, srcKey, dstKey string, opts ...s3.CopyOption) error
+	MoveFileFunc              func(ctx context.Context, srcKey, dstKey string) error
+	RenamePrefixFunc          func(ctx context.Context, oldPrefix, newPrefix string, opts ...s3.RenameOption) (*s3.RenameResult, error)
+	FileExistsFunc            func(ctx context.Context, key string) (bool, error)
+	GetObjectInfoFunc         func(ctx context.Context, key string) (*s3.ObjectInfo, error)
+	UpdateMetadataFunc        func(ctx context.Context, key string, meta map[string]string) error
+	SetTagsFunc               func(ctx context.Context, key string, tags map[string]string) error
+	GetTagsFunc               func(ctx context.Context, key string) (map[string]string, error)
+	DeleteTagsFunc            func(ctx context.Context, key string) error
+	ListFunc                  func(prefix string) *s3.ListIterator
+	ListAllFunc               func(ctx context.Context, prefix string, fn func(s3.ObjectInfo) error) error
+	ListObjectsInfoFunc       func(ctx context.Context, prefix string, opts ...s3.ListOption) ([]s3.ObjectInfo, error)
+	ListDirectoryFunc         func(ctx context.Context, prefix string) (*s3.DirectoryListing, error)
+	GetObjectsFunc            func(ctx context.Context, prefix string) ([]string, error)
+	GetPresignedURLFunc       func(ctx context.Context, key string, expiration time.Duration, opts ...s3.PresignOption) (string, error)
+	PresignPostPolicyFunc     func(ctx context.Context, opts s3.PostPolicyOptions) (*s3.PresignedPost, error)
+	KeyFromURLFunc            func(rawURL string) (string, error)
+	FindKeyByPresignedURLFunc func(ctx context.Context, presignedURL string, prefix string) (string, error)
+	BucketFunc                func() string
+	EndpointFunc              func() string
+}
+
+var _ s3.S3Client = (*Client)(nil)
+
+func (m *Client) UploadFile(ctx context.Context, objectID string, key string, body io.Reader, contentType string, opts ...s3.PutOption) (*s3.UploadResult, error) {
+	if m.UploadFileFunc != nil {
+		return m.UploadFileFunc(ctx, objectID, key, body, contentType, opts...)
+	}
+	return nil, fmt.Errorf("%w: UploadFile", ErrNotImplemented)
+}
+
+func (m *Client) UploadLarge(ctx context.Context, key string, r io.Reader, opts ...s3.UploadOption) error {
+	if m.UploadLargeFunc != nil {
+		return m.UploadLargeFunc(ctx, key, r, opts...)
+	}
+	return fmt.Errorf("%w: UploadLarge", ErrNotImplemented)
+}
+
+func (m *Client) DownloadFile(ctx context.Context, key string, opts ...s3.DownloadOption) (io.ReadCloser, *s3.ObjectInfo, error) {
+	if m.DownloadFileFunc != nil {
+		return m.DownloadFileFunc(ctx, key, opts...)
+	}
+	return nil, nil, fmt.Errorf("%w: DownloadFile", ErrNotImplemented)
+}
+
+func (m *Client) DownloadRange(ctx context.Context, key string, offset, length int64, opts ...s3.DownloadOption) (io.ReadCloser, *s3.ObjectInfo, error) {
+	if m.DownloadRangeFunc != nil {
+		return m.DownloadRangeFunc(ctx, key, offset, length, opts...)
+	}
+	return nil, nil, fmt.Errorf("%w: DownloadRange", ErrNotImplemented)
+}
+
+func (m *Client) DownloadResumable(ctx context.Context, key string, w io.WriterAt, cp *s3.DownloadCheckpoint, opts ...s3.DownloadOption) error {
+	if m.DownloadResumableFunc != nil {
+		return m.DownloadResumableFunc(ctx, key, w, cp, opts...)
+	}
+	return fmt.Errorf("%w: DownloadResumable", ErrNotImplemented)
+}
+
+func (m *Client) DownloadLarge(ctx context.Context, key string, w io.WriterAt, opts ...s3.DownloadOption) (int64, error) {
+	if m.DownloadLargeFunc != nil {
+		return m.DownloadLargeFunc(ctx, key, w, opts...)
+	}
+	return 0, fmt.Errorf("%w: DownloadLarge", ErrNotImplemented)
+}
+
+func (m *Client) DeleteFile(ctx context.Context, key string, opts ...s3.DeleteOption) error {
+	if m.DeleteFileFunc != nil {
+		return m.DeleteFileFunc(ctx, key, opts...)
+	}
+	return fmt.Errorf("%w: DeleteFile", ErrNotImplemented)
+}
+
+func (m *Client) DeleteFiles(ctx context.Context, keys []string) (*s3.DeleteResult, error) {
+	if m.DeleteFilesFunc != nil {
+		return m.DeleteFilesFunc(ctx, keys)
+	}
+	return nil, fmt.Errorf("%w: DeleteFiles", ErrNotImplemented)
+}
+
+func (m *Client) DeletePrefix(ctx context.Context, prefix string) (*s3.DeleteResult, error) {
+	if m.DeletePrefixFunc != nil {
+		return m.DeletePrefixFunc(ctx, prefix)
+	}
+	return nil, fmt.Errorf("%w: DeletePrefix", ErrNotImplemented)
+}
+
+func (m *Client) CopyFile(ctx context.Context, srcKey, dstKey string, opts ...s3.CopyOption) error {
+	if m.CopyFileFunc != nil {
+		return m.CopyFileFunc(ctx, srcKey, dstKey, opts...)
+	}
+	return fmt.Errorf("%w: CopyFile", ErrNotImplemented)
+}
+
+func (m *Client) MoveFile(ctx context.Context, srcKey, dstKey string) error {
+	if m.MoveFileFunc != nil {
+		return m.MoveFileFunc(ctx, srcKey, dstKey)
+	}
+	return fmt.Errorf("%w: MoveFile", ErrNotImplemented)
+}
+
+func (m *Client) RenamePrefix(ctx context.Context, oldPrefix, newPrefix string, opts ...s3.RenameOption) (*s3.RenameResult, error) {
+	if m.RenamePrefixFunc != nil {
+		return m.RenamePrefixFunc(ctx, oldPrefix, newPrefix, opts...)
+	}
+	return nil, fmt.Errorf("%w: RenamePrefix", ErrNotImplemented)
+}
+
+func (m *Client) FileExists(ctx context.Context, key string) (bool, error) {
+	if m.FileExistsFunc != nil {
+		return m.FileExistsFunc(ctx, key)
+	}
+	return false, fmt.Errorf("%w: FileExists", ErrNotImplemented)
+}
+
+func (m *Client) GetObjectInfo(ctx context.Context, key string) (*s3.ObjectInfo, error) {
+	if m.GetObjectInfoFunc != nil {
+		return m.GetObjectInfoFunc(ctx, key)
+	}
+	return nil, fmt.Errorf("%w: GetObjectInfo", ErrNotImplemented)
+}
+
+func (m *Client) UpdateMetadata(ctx context.Context, key string, meta map[string]string) error {
+	if m.UpdateMetadataFunc != nil {
+		return m.UpdateMetadataFunc(ctx, key, meta)
+	}
+	return fmt.Errorf("%w: UpdateMetadata", ErrNotImplemented)
+}
+
+func (m *Client) SetTags(ctx context.Context, key string, tags map[string]string) error {
+	if m.SetTagsFunc != nil {
+		return m.SetTagsFunc(ctx, key, tags)
+	}
+	return fmt.Errorf("%w: SetTags", ErrNotImplemented)
+}
+
+func (m *Client) GetTags(ctx context.Context, key string) (map[string]string, error) {
+	if m.GetTagsFunc != nil {
+		return m.GetTagsFunc(ctx, key)
+	}
+	return nil, fmt.Errorf("%w: GetTags", ErrNotImplemented)
+}
+
+func (m *Client) DeleteTags(ctx context.Context, key string) error {
+	if m.DeleteTagsFunc != nil {
+		return m.DeleteTagsFunc(ctx, key)
+	}
+	return fmt.Errorf("%w: DeleteTags", ErrNotImplemented)
+}
+
+// List has no error to report, so without ListFunc it returns an empty
+// iterator.
+func (m *Client) List(prefix string) *s3.ListIterator {
+	if m.ListFunc != nil {
+		return m.ListFunc(prefix)
+	}
+	return s3.NewListIterator(nil)
+}
+
+func (m *Client) ListAll(ctx context.Context, prefix string, fn func(s3.ObjectInfo) error) error {
+	if m.ListAllFunc != nil {
+		return m.ListAllFunc(ctx, prefix, fn)
+	}
+	return fmt.Errorf("%w: ListAll", ErrNotImplemented)
+}
+
+func (m *Client) ListObjectsInfo(ctx context.Context, prefix string, opts ...s3.ListOption) ([]s3.ObjectInfo, error) {
+	if m.ListObjectsInfoFunc != nil {
+		return m.ListObjectsInfoFunc(ctx, prefix, opts...)
+	}
+	return nil, fmt.Errorf("%w: ListObjectsInfo", ErrNotImplemented)
+}
+
+func (m *Client) ListDirectory(ctx context.Context, prefix string) (*s3.DirectoryListing, error) {
+	if m.ListDirectoryFunc != nil {
+		return m.ListDirectoryFunc(ctx, prefix)
+	}
+	return nil, fmt.Errorf("%w: ListDirectory", ErrNotImplemented)
+}
+
+func (m *Client) GetObjects(ctx context.Context, prefix string) ([]string, error) {
+	if m.GetObjectsFunc != nil {
+		return m.GetObjectsFunc(ctx, prefix)
+	}
+	return nil, fmt.Errorf("%w: GetObjects", ErrNotImplemented)
+}
+
+func (m *Client) GetPresignedURL(ctx context.Context, key string, expiration time.Duration, opts ...s3.PresignOption) (string, error) {
+	if m.GetPresignedURLFunc != nil {
+		return m.GetPresignedURLFunc(ctx, key, expiration, opts...)
+	}
+	return "", fmt.Errorf("%w: GetPresignedURL", ErrNotImplemented)
+}
+
+func (m *Client) PresignPostPolicy(ctx context.Context, opts s3.PostPolicyOptions) (*s3.PresignedPost, error) {
+	if m.PresignPostPolicyFunc != nil {
+		return m.PresignPostPolicyFunc(ctx, opts)
+	}
+	return nil, fmt.Errorf("%w: PresignPostPolicy", ErrNotImplemented)
+}
+
+func (m *Client) KeyFromURL(rawURL string) (string, error) {
+	if m.KeyFromURLFunc != nil {
+		return m.KeyFromURLFunc(rawURL)
+	}
+	return "", fmt.Errorf("%w: KeyFromURL", ErrNotImplemented)
+}
+
+func (m *Client) FindKeyByPresignedURL(ctx context.Context, presignedURL string, prefix string) (string, error) {
+	if m.FindKeyByPresignedURLFunc != nil {
+		return m.FindKeyByPresignedURLFunc(ctx, presignedURL, prefix)
+	}
+	return "", fmt.Errorf("%w: FindKeyByPresignedURL", ErrNotImplemented)
+}
+
+func (m *Client) Bucket() string {
+	if m.BucketFunc != nil {
+		return m.BucketFunc()
+	}
+	return ""
+}
+
+func (m *Client) Endpoint() string {
+	if m.EndpointFunc != nil {
+		return m.EndpointFunc()
+	}
+	return ""
+}
@@ -0,0 +1,152 @@
+// Package s3test runs a throwaway MinIO container for end-to-end tests.
+//
+// Docker must be reachable through the usual testcontainers-go discovery
+// (DOCKER_HOST, the local socket, or ~/.testcontainers.properties).
+package s3test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	s3 "github.com/aranoy15/go-s3"
+)
+
+const (
+	DefaultImage = "minio/minio:RELEASE.2024-05-10T01-41-38Z"
+
+	accessKeyID     = "minioadmin"
+	secretAccessKey = "minioadmin"
+	region          = "us-east-1"
+	apiPort         = "9000/tcp"
+)
+
+type options struct {
+	image string
+}
+
+type Option func(*options)
+
+// WithImage overrides the MinIO image, DefaultImage by default.
+func WithImage(image string) Option {
+	return func(o *options) {
+		o.image = image
+	}
+}
+
+// Server is a running MinIO container.
+type Server struct {
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	Region          string
+
+	container testcontainers.Container
+}
+
+// Start launches a MinIO container and waits until it is healthy. Callers
+// own the container and must call Terminate; tests should prefer NewClient.
+func Start(ctx context.Context, opts ...Option) (*Server, error) {
+	o := &options{image: DefaultImage}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        o.image,
+			Cmd:          []string{"server", "/data"},
+			ExposedPorts: []string{apiPort},
+			Env: map[string]string{
+				"MINIO_ROOT_USER":     accessKeyID,
+				"MINIO_ROOT_PASSWORD": secretAccessKey,
+			},
+			WaitingFor: wait.ForHTTP("/minio/health/live").
+				WithPort(apiPort).
+				WithStartupTimeout(time.Minute),
+		},
+		Started: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start MinIO container: %w", err)
+	}
+
+	endpoint, err := container.PortEndpoint(ctx, apiPort, "http")
+	if err != nil {
+		_ = container.Terminate(context.Background())
+		return nil, fmt.Errorf("failed to resolve MinIO endpoint: %w", err)
+	}
+
+	return &Server{
+		Endpoint:        endpoint,
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		Region:          region,
+		container:       container,
+	}, nil
+}
+
+// Terminate stops and removes the container.
+func (s *Server) Terminate(ctx context.Context) error {
+	return s.container.Terminate(ctx)
+}
+
+// Config returns a client config for bucket on this server.
+func (s *Server) Config(bucket string) *s3.Config {
+	return &s3.Config{
+		Endpoint:        s.Endpoint,
+		AccessKeyID:     s.AccessKeyID,
+		SecretAccessKey: s.SecretAccessKey,
+		BucketName:      bucket,
+		Region:          s.Region,
+	}
+}
+
+// CreateBucket creates bucket on the server.
+func (s *Server) CreateBucket(ctx context.Context, bucket string) error {
+	client := awss3.New(awss3.Options{
+		BaseEndpoint: aws.String(s.Endpoint),
+		Region:       s.Region,
+		Credentials:  credentials.NewStaticCredentialsProvider(s.AccessKeyID, s.SecretAccessKey, ""),
+		UsePathStyle: true,
+	})
+	if _, err := client.CreateBucket(ctx, &awss3.CreateBucketInput{Bucket: aws.String(bucket)}); err != nil {
+		return fmt.Errorf("failed to create bucket %q: %w", bucket, err)
+	}
+	return nil
+}
+
+// NewClient starts a MinIO container, creates bucket and returns a client
+// for it. The container is removed when the test finishes. The test is
+// skipped if Docker is not available.
+func NewClient(t *testing.T, bucket string, opts ...Option) *s3.Client {
+	t.Helper()
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	ctx := context.Background()
+	server, err := Start(ctx, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := server.Terminate(context.Background()); err != nil {
+			t.Logf("failed to terminate MinIO container: %v", err)
+		}
+	})
+
+	if err := server.CreateBucket(ctx, bucket); err != nil {
+		t.Fatal(err)
+	}
+	client, err := s3.New(server.Config(bucket))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
@@ -0,0 +1,110 @@
+package s3test_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	s3 "github.com/aranoy15/go-s3"
+	"github.com/aranoy15/go-s3/s3test"
+)
+
+func TestSync(t *testing.T) {
+	client := s3test.NewClient(t, "sync")
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	for name, body := range map[string]string{"a.txt": "a", "sub/b.txt": "b"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A sibling prefix that must survive WithSyncDelete on "site".
+	if err := client.UploadLarge(ctx, "site-old/keep.txt", strings.NewReader("keep")); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := client.Sync(ctx, dir, "site", s3.WithSyncDelete())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Failed) != 0 {
+		t.Fatalf("failed = %v", result.Failed)
+	}
+	sort.Strings(result.Uploaded)
+	if got := strings.Join(result.Uploaded, ","); got != "site/a.txt,site/sub/b.txt" {
+		t.Errorf("uploaded = %s", got)
+	}
+	if len(result.Deleted) != 0 {
+		t.Errorf("deleted = %v, want none", result.Deleted)
+	}
+	if exists, err := client.FileExists(ctx, "site-old/keep.txt"); err != nil || !exists {
+		t.Errorf("site-old/keep.txt exists = %v (%v), want kept", exists, err)
+	}
+
+	result, err = client.Sync(ctx, dir, "site/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Uploaded) != 0 || result.Unchanged != 2 {
+		t.Errorf("second sync uploaded %v, unchanged %d; want nothing uploaded, 2 unchanged", result.Uploaded, result.Unchanged)
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	client := s3test.NewClient(t, "cas")
+	ctx := context.Background()
+
+	created, err := client.CompareAndSwap(ctx, "state.json", "", strings.NewReader("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CompareAndSwap(ctx, "state.json", "", strings.NewReader("2")); !errors.Is(err, s3.ErrPreconditionFailed) {
+		t.Fatalf("create over an existing object: err = %v, want ErrPreconditionFailed", err)
+	}
+	if _, err := client.CompareAndSwap(ctx, "state.json", created.ETag, strings.NewReader("2")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CompareAndSwap(ctx, "state.json", created.ETag, strings.NewReader("3")); !errors.Is(err, s3.ErrPreconditionFailed) {
+		t.Fatalf("swap with a stale ETag: err = %v, want ErrPreconditionFailed", err)
+	}
+}
+
+func TestLease(t *testing.T) {
+	client := s3test.NewClient(t, "lease")
+	ctx := context.Background()
+
+	lease, err := client.AcquireLease(ctx, "locks/job", "a", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.AcquireLease(ctx, "locks/job", "b", time.Minute); !errors.Is(err, s3.ErrLeaseHeld) {
+		t.Fatalf("err = %v, want ErrLeaseHeld", err)
+	}
+	if err := lease.Renew(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := lease.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	taken, err := client.AcquireLease(ctx, "locks/job", "b", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lease.Renew(ctx); !errors.Is(err, s3.ErrLeaseLost) {
+		t.Fatalf("renew after takeover: err = %v, want ErrLeaseLost", err)
+	}
+	if err := taken.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,413 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrKeyOutsideScope is returned by ScopedClient for keys that would leave
+// its prefix.
+var ErrKeyOutsideScope = errors.New("key outside of scope")
+
+// ScopedClient is a view of a client in which every key is relative to a
+// fixed prefix, e.g. one per tenant. Keys that are absolute or contain ".."
+// segments are rejected, and keys returned by listings and URL parsing are
+// relative again, so callers cannot reach objects of another scope.
+type ScopedClient struct {
+	client     S3Client
+	prefix     string
+	keyBuilder KeyBuilder
+	presignTTL time.Duration
+}
+
+var _ S3Client = (*ScopedClient)(nil)
+
+// Scoped returns a view of c restricted to prefix. A "/" is appended to
+// prefix if missing.
+func (c *Client) Scoped(prefix string) *ScopedClient {
+	return newScopedClient(c, prefix, c.keyBuilder, c.presignTTL)
+}
+
+func (m *MemoryClient) Scoped(prefix string) *ScopedClient {
+	return newScopedClient(m, prefix, m.keyBuilder, m.presignTTL)
+}
+
+func newScopedClient(client S3Client, prefix string, keyBuilder KeyBuilder, presignTTL time.Duration) *ScopedClient {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &ScopedClient{client: client, prefix: prefix, keyBuilder: keyBuilder, presignTTL: presignTTL}
+}
+
+// Prefix returns the prefix all keys are stored under.
+func (s *ScopedClient) Prefix() string { return s.prefix }
+
+func (s *ScopedClient) key(key string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("%w: empty key", ErrKeyOutsideScope)
+	}
+	return s.subPrefix(key)
+}
+
+// subPrefix is key for listing prefixes, which may be empty to cover the
+// whole scope.
+func (s *ScopedClient) subPrefix(prefix string) (string, error) {
+	if strings.HasPrefix(prefix, "/") {
+		return "", fmt.Errorf("%w: %q", ErrKeyOutsideScope, prefix)
+	}
+	for _, segment := range strings.Split(prefix, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("%w: %q", ErrKeyOutsideScope, prefix)
+		}
+	}
+	return s.prefix + prefix, nil
+}
+
+func (s *ScopedClient) relative(key string) string {
+	return strings.TrimPrefix(key, s.prefix)
+}
+
+func (s *ScopedClient) relativeInfo(info *ObjectInfo) *ObjectInfo {
+	if info != nil {
+		info.Key = s.relative(info.Key)
+	}
+	return info
+}
+
+func (s *ScopedClient) relativeDelete(result *DeleteResult) *DeleteResult {
+	if result == nil {
+		return nil
+	}
+	for i := range result.Deleted {
+		result.Deleted[i] = s.relative(result.Deleted[i])
+	}
+	for i := range result.Failed {
+		result.Failed[i].Key = s.relative(result.Failed[i].Key)
+	}
+	return result
+}
+
+// UploadFile stores the body under the key built by the client's
+// KeyBuilder, inside the scope. The result's Key is relative to the scope.
+func (s *ScopedClient) UploadFile(ctx context.Context, objectID string, key string, body io.Reader, contentType string, opts ...PutOption) (*UploadResult, error) {
+	objectKey, err := s.key(s.keyBuilder(objectID, key))
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]UploadOption{WithContentType(contentType)}, opts...)
+	var result *UploadResult
+	if uploader, ok := s.client.(resultUploader); ok {
+		if result, err = uploader.uploadLarge(ctx, objectKey, body, opts...); err != nil {
+			return nil, err
+		}
+	} else {
+		// Other implementations only report the result through a HEAD,
+		// which has no object URL.
+		if err := s.client.UploadLarge(ctx, objectKey, body, opts...); err != nil {
+			return nil, err
+		}
+		info, err := s.client.GetObjectInfo(ctx, objectKey)
+		if err != nil {
+			return nil, err
+		}
+		result = &UploadResult{Key: objectKey, ETag: info.ETag, VersionID: info.VersionID, Size: info.Size}
+	}
+	if expiration := newUploadOptions(opts).presignExpiration; expiration > 0 {
+		if result.URL, err = s.client.GetPresignedURL(ctx, objectKey, expiration); err != nil {
+			return nil, fmt.Errorf("failed to generate presigned URL: %w", err)
+		}
+	}
+	result.Key = s.relative(result.Key)
+	return result, nil
+}
+
+// resultUploader is implemented by the clients that report the stored object
+// of an UploadLarge call.
+type resultUploader interface {
+	uploadLarge(ctx context.Context, key string, r io.Reader, opts ...UploadOption) (*UploadResult, error)
+}
+
+func (s *ScopedClient) UploadLarge(ctx context.Context, key string, r io.Reader, opts ...UploadOption) error {
+	key, err := s.key(key)
+	if err != nil {
+		return err
+	}
+	return s.client.UploadLarge(ctx, key, r, opts...)
+}
+
+func (s *ScopedClient) DownloadFile(ctx context.Context, key string, opts ...DownloadOption) (io.ReadCloser, *ObjectInfo, error) {
+	key, err := s.key(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, info, err := s.client.DownloadFile(ctx, key, opts...)
+	return body, s.relativeInfo(info), err
+}
+
+func (s *ScopedClient) DownloadRange(ctx context.Context, key string, offset, length int64, opts ...DownloadOption) (io.ReadCloser, *ObjectInfo, error) {
+	key, err := s.key(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, info, err := s.client.DownloadRange(ctx, key, offset, length, opts...)
+	return body, s.relativeInfo(info), err
+}
+
+func (s *ScopedClient) DownloadResumable(ctx context.Context, key string, w io.WriterAt, cp *DownloadCheckpoint, opts ...DownloadOption) error {
+	key, err := s.key(key)
+	if err != nil {
+		return err
+	}
+	return s.client.DownloadResumable(ctx, key, w, cp, opts...)
+}
+
+func (s *ScopedClient) DownloadLarge(ctx context.Context, key string, w io.WriterAt, opts ...DownloadOption) (int64, error) {
+	key, err := s.key(key)
+	if err != nil {
+		return 0, err
+	}
+	return s.client.DownloadLarge(ctx, key, w, opts...)
+}
+
+func (s *ScopedClient) DeleteFile(ctx context.Context, key string, opts ...DeleteOption) error {
+	key, err := s.key(key)
+	if err != nil {
+		return err
+	}
+	return s.client.DeleteFile(ctx, key, opts...)
+}
+
+func (s *ScopedClient) DeleteFiles(ctx context.Context, keys []string) (*DeleteResult, error) {
+	scoped := make([]string, 0, len(keys))
+	for _, key := range keys {
+		key, err := s.key(key)
+		if err != nil {
+			return nil, err
+		}
+		scoped = append(scoped, key)
+	}
+	result, err := s.client.DeleteFiles(ctx, scoped)
+	return s.relativeDelete(result), err
+}
+
+func (s *ScopedClient) DeletePrefix(ctx context.Context, prefix string) (*DeleteResult, error) {
+	prefix, err := s.subPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.client.DeletePrefix(ctx, prefix)
+	return s.relativeDelete(result), err
+}
+
+func (s *ScopedClient) CopyFile(ctx context.Context, srcKey, dstKey string, opts ...CopyOption) error {
+	srcKey, err := s.key(srcKey)
+	if err != nil {
+		return err
+	}
+	dstKey, err = s.key(dstKey)
+	if err != nil {
+		return err
+	}
+	return s.client.CopyFile(ctx, srcKey, dstKey, opts...)
+}
+
+func (s *ScopedClient) MoveFile(ctx context.Context, srcKey, dstKey string) error {
+	srcKey, err := s.key(srcKey)
+	if err != nil {
+		return err
+	}
+	dstKey, err = s.key(dstKey)
+	if err != nil {
+		return err
+	}
+	return s.client.MoveFile(ctx, srcKey, dstKey)
+}
+
+func (s *ScopedClient) RenamePrefix(ctx context.Context, oldPrefix, newPrefix string, opts ...RenameOption) (*RenameResult, error) {
+	oldPrefix, err := s.subPrefix(oldPrefix)
+	if err != nil {
+		return nil, err
+	}
+	newPrefix, err = s.subPrefix(newPrefix)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.client.RenamePrefix(ctx, oldPrefix, newPrefix, opts...)
+	if result != nil {
+		for i := range result.Renamed {
+			result.Renamed[i].From = s.relative(result.Renamed[i].From)
+			result.Renamed[i].To = s.relative(result.Renamed[i].To)
+		}
+		for i := range result.Failed {
+			result.Failed[i].From = s.relative(result.Failed[i].From)
+			result.Failed[i].To = s.relative(result.Failed[i].To)
+		}
+	}
+	return result, err
+}
+
+func (s *ScopedClient) FileExists(ctx context.Context, key string) (bool, error) {
+	key, err := s.key(key)
+	if err != nil {
+		return false, err
+	}
+	return s.client.FileExists(ctx, key)
+}
+
+func (s *ScopedClient) GetObjectInfo(ctx context.Context, key string) (*ObjectInfo, error) {
+	key, err := s.key(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.client.GetObjectInfo(ctx, key)
+	return s.relativeInfo(info), err
+}
+
+func (s *ScopedClient) UpdateMetadata(ctx context.Context, key string, meta map[string]string) error {
+	key, err := s.key(key)
+	if err != nil {
+		return err
+	}
+	return s.client.UpdateMetadata(ctx, key, meta)
+}
+
+func (s *ScopedClient) SetTags(ctx context.Context, key string, tags map[string]string) error {
+	key, err := s.key(key)
+	if err != nil {
+		return err
+	}
+	return s.client.SetTags(ctx, key, tags)
+}
+
+func (s *ScopedClient) GetTags(ctx context.Context, key string) (map[string]string, error) {
+	key, err := s.key(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.GetTags(ctx, key)
+}
+
+func (s *ScopedClient) DeleteTags(ctx context.Context, key string) error {
+	key, err := s.key(key)
+	if err != nil {
+		return err
+	}
+	return s.client.DeleteTags(ctx, key)
+}
+
+func (s *ScopedClient) List(prefix string) *ListIterator {
+	prefix, err := s.subPrefix(prefix)
+	if err != nil {
+		return &ListIterator{err: err}
+	}
+	inner := s.client.List(prefix)
+	return &ListIterator{
+		more: true,
+		fetch: func(ctx context.Context) ([]ObjectInfo, bool, error) {
+			if !inner.Next(ctx) {
+				return nil, false, inner.Err()
+			}
+			obj := inner.Object()
+			return []ObjectInfo{*s.relativeInfo(&obj)}, true, nil
+		},
+	}
+}
+
+func (s *ScopedClient) ListAll(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	prefix, err := s.subPrefix(prefix)
+	if err != nil {
+		return err
+	}
+	return s.client.ListAll(ctx, prefix, func(obj ObjectInfo) error {
+		return fn(*s.relativeInfo(&obj))
+	})
+}
+
+func (s *ScopedClient) ListObjectsInfo(ctx context.Context, prefix string, opts ...ListOption) ([]ObjectInfo, error) {
+	prefix, err := s.subPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := s.client.ListObjectsInfo(ctx, prefix, opts...)
+	for i := range objects {
+		s.relativeInfo(&objects[i])
+	}
+	return objects, err
+}
+
+func (s *ScopedClient) ListDirectory(ctx context.Context, prefix string) (*DirectoryListing, error) {
+	prefix, err := s.subPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	listing, err := s.client.ListDirectory(ctx, prefix)
+	if listing != nil {
+		listing.Prefix = s.relative(listing.Prefix)
+		for i := range listing.Files {
+			s.relativeInfo(&listing.Files[i])
+		}
+		for i := range listing.Folders {
+			listing.Folders[i] = s.relative(listing.Folders[i])
+		}
+	}
+	return listing, err
+}
+
+func (s *ScopedClient) GetObjects(ctx context.Context, prefix string) ([]string, error) {
+	prefix, err := s.subPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.GetObjects(ctx, prefix)
+}
+
+func (s *ScopedClient) GetPresignedURL(ctx context.Context, key string, expiration time.Duration, opts ...PresignOption) (string, error) {
+	key, err := s.key(key)
+	if err != nil {
+		return "", err
+	}
+	return s.client.GetPresignedURL(ctx, key, expiration, opts...)
+}
+
+// PresignPostPolicy scopes Key and KeyPrefix; with neither set the form may
+// upload anywhere inside the scope.
+func (s *ScopedClient) PresignPostPolicy(ctx context.Context, opts PostPolicyOptions) (*PresignedPost, error) {
+	var err error
+	if opts.Key != "" {
+		if opts.Key, err = s.key(opts.Key); err != nil {
+			return nil, err
+		}
+	} else if opts.KeyPrefix, err = s.subPrefix(opts.KeyPrefix); err != nil {
+		return nil, err
+	}
+	return s.client.PresignPostPolicy(ctx, opts)
+}
+
+// KeyFromURL returns the key relative to the scope, or ErrKeyOutsideScope
+// for URLs of objects outside of it.
+func (s *ScopedClient) KeyFromURL(rawURL string) (string, error) {
+	key, err := s.client.KeyFromURL(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(key, s.prefix) {
+		return "", fmt.Errorf("%w: %q", ErrKeyOutsideScope, key)
+	}
+	return s.relative(key), nil
+}
+
+// Deprecated: Use KeyFromURL.
+func (s *ScopedClient) FindKeyByPresignedURL(ctx context.Context, presignedURL string, prefix string) (string, error) {
+	key, err := s.KeyFromURL(presignedURL)
+	if err != nil || !strings.HasPrefix(key, prefix) {
+		return "", fmt.Errorf("object not found for the given presigned URL")
+	}
+	return key, nil
+}
+
+func (s *ScopedClient) Bucket() string   { return s.client.Bucket() }
+func (s *ScopedClient) Endpoint() string { return s.client.Endpoint() }
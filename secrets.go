@@ -0,0 +1,254 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+const (
+	defaultVaultMount             = "aws"
+	defaultSecretsRefreshInterval = time.Hour
+	credentialsExpiryWindow       = time.Minute
+)
+
+// VaultConfig reads S3 keys from the HashiCorp Vault AWS secrets engine.
+type VaultConfig struct {
+	// Address defaults to VAULT_ADDR and Token to VAULT_TOKEN.
+	Address string
+	Token   string
+	// TokenFunc is called for every read instead of using Token, e.g. to
+	// return a token renewed by a Vault agent.
+	TokenFunc func(ctx context.Context) (string, error)
+	// Namespace is the Vault Enterprise namespace.
+	Namespace string
+	// Mount is the path of the secrets engine, "aws" by default.
+	Mount string
+	Role  string
+	// STS reads temporary credentials from <mount>/sts/<role> instead of
+	// IAM user keys from <mount>/creds/<role>.
+	STS bool
+	// TTL requests a lease duration for STS credentials.
+	TTL        time.Duration
+	HTTPClient *http.Client
+}
+
+// NewVaultCredentials returns a provider for Config.CredentialsProvider or
+// SetCredentialsProvider that reads keys from Vault. New keys are read a
+// minute before the lease of the current ones ends.
+func NewVaultCredentials(cfg VaultConfig) (aws.CredentialsProvider, error) {
+	if cfg.Address == "" {
+		cfg.Address = os.Getenv("VAULT_ADDR")
+	}
+	if cfg.Token == "" && cfg.TokenFunc == nil {
+		cfg.Token = os.Getenv("VAULT_TOKEN")
+	}
+	if cfg.Mount == "" {
+		cfg.Mount = defaultVaultMount
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	switch {
+	case cfg.Address == "":
+		return nil, errors.New("vault address not configured")
+	case cfg.Token == "" && cfg.TokenFunc == nil:
+		return nil, errors.New("vault token not configured")
+	case cfg.Role == "":
+		return nil, errors.New("vault role not configured")
+	}
+	return aws.NewCredentialsCache(&vaultCredentials{cfg: cfg}, func(o *aws.CredentialsCacheOptions) {
+		o.ExpiryWindow = credentialsExpiryWindow
+	}), nil
+}
+
+type vaultCredentials struct {
+	cfg VaultConfig
+}
+
+func (p *vaultCredentials) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	token := p.cfg.Token
+	if p.cfg.TokenFunc != nil {
+		var err error
+		if token, err = p.cfg.TokenFunc(ctx); err != nil {
+			return aws.Credentials{}, fmt.Errorf("failed to get vault token: %w", err)
+		}
+	}
+
+	kind := "creds"
+	if p.cfg.STS {
+		kind = "sts"
+	}
+	url := strings.TrimSuffix(p.cfg.Address, "/") + "/v1/" + strings.Trim(p.cfg.Mount, "/") + "/" + kind + "/" + p.cfg.Role
+	if p.cfg.STS && p.cfg.TTL > 0 {
+		url += "?ttl=" + p.cfg.TTL.String()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return aws.Credentials{}, fmt.Errorf("failed to read vault credentials: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if p.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.cfg.Namespace)
+	}
+
+	var secret struct {
+		LeaseDuration int64 `json:"lease_duration"`
+		Data          struct {
+			AccessKey     string `json:"access_key"`
+			SecretKey     string `json:"secret_key"`
+			SecurityToken string `json:"security_token"`
+		} `json:"data"`
+	}
+	if err := doJSON(p.cfg.HTTPClient, req, &secret); err != nil {
+		return aws.Credentials{}, fmt.Errorf("failed to read vault credentials: %w", err)
+	}
+	if secret.Data.AccessKey == "" || secret.Data.SecretKey == "" {
+		return aws.Credentials{}, errors.New("vault secret has no AWS keys")
+	}
+	creds := aws.Credentials{
+		AccessKeyID:     secret.Data.AccessKey,
+		SecretAccessKey: secret.Data.SecretKey,
+		SessionToken:    secret.Data.SecurityToken,
+		Source:          "Vault",
+	}
+	if secret.LeaseDuration > 0 {
+		creds.CanExpire = true
+		creds.Expires = time.Now().Add(time.Duration(secret.LeaseDuration) * time.Second)
+	}
+	return creds, nil
+}
+
+// SecretsManagerAPI is the subset of *secretsmanager.Client used by
+// NewSecretsManagerCredentials.
+type SecretsManagerAPI interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// SecretsManagerConfig reads S3 keys from an AWS Secrets Manager secret
+// holding JSON such as {"accessKeyId": "...", "secretAccessKey": "..."}.
+// The snake_case and aws_-prefixed spellings of the AWS CLI are accepted too,
+// as is an optional session token.
+type SecretsManagerConfig struct {
+	// SecretID is the name or ARN of the secret.
+	SecretID string
+	// VersionStage defaults to AWSCURRENT.
+	VersionStage string
+	Region       string
+	// Endpoint overrides the Secrets Manager endpoint, e.g. for a VPC
+	// endpoint. The SDK resolves it for the region and partition otherwise.
+	Endpoint string
+	// Credentials used to call Secrets Manager; the AWS default chain when
+	// nil.
+	Credentials aws.CredentialsProvider
+	// RefreshInterval is how often the secret is read again to pick up a
+	// rotation, every hour by default.
+	RefreshInterval time.Duration
+	HTTPClient      *http.Client
+	// Client replaces the Secrets Manager client built from the fields
+	// above.
+	Client SecretsManagerAPI
+}
+
+// NewSecretsManagerCredentials returns a provider for
+// Config.CredentialsProvider or SetCredentialsProvider that reads keys from
+// Secrets Manager and reads them again every RefreshInterval.
+func NewSecretsManagerCredentials(cfg SecretsManagerConfig) (aws.CredentialsProvider, error) {
+	if cfg.SecretID == "" {
+		return nil, errors.New("secret ID not configured")
+	}
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = defaultSecretsRefreshInterval
+	}
+	if cfg.Client == nil {
+		awsCfg, err := awsconfig.LoadDefaultConfig(context.TODO(), awsconfig.WithRegion(cfg.Region))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		}
+		if awsCfg.Region == "" {
+			return nil, errors.New("secrets manager region not configured")
+		}
+		cfg.Client = secretsmanager.NewFromConfig(awsCfg, func(o *secretsmanager.Options) {
+			if cfg.Credentials != nil {
+				o.Credentials = cfg.Credentials
+			}
+			if cfg.Endpoint != "" {
+				o.BaseEndpoint = aws.String(cfg.Endpoint)
+			}
+			if cfg.HTTPClient != nil {
+				o.HTTPClient = cfg.HTTPClient
+			}
+		})
+	}
+	return aws.NewCredentialsCache(&secretsManagerCredentials{cfg: cfg}), nil
+}
+
+type secretsManagerCredentials struct {
+	cfg SecretsManagerConfig
+}
+
+func (p *secretsManagerCredentials) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	output, err := p.cfg.Client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId:     aws.String(p.cfg.SecretID),
+		VersionStage: stringOrNil(p.cfg.VersionStage),
+	})
+	if err != nil {
+		return aws.Credentials{}, fmt.Errorf("failed to read secret: %w", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(aws.ToString(output.SecretString)), &fields); err != nil {
+		return aws.Credentials{}, fmt.Errorf("failed to decode secret: %w", err)
+	}
+	creds := aws.Credentials{
+		AccessKeyID:     secretField(fields, "accessKeyId", "access_key_id", "aws_access_key_id"),
+		SecretAccessKey: secretField(fields, "secretAccessKey", "secret_access_key", "aws_secret_access_key"),
+		SessionToken:    secretField(fields, "sessionToken", "session_token", "aws_session_token"),
+		Source:          "SecretsManager",
+		CanExpire:       true,
+		Expires:         time.Now().Add(p.cfg.RefreshInterval),
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return aws.Credentials{}, errors.New("secret has no AWS keys")
+	}
+	return creds, nil
+}
+
+// secretField returns the first of names present in fields, ignoring case.
+func secretField(fields map[string]any, names ...string) string {
+	for _, name := range names {
+		for k, v := range fields {
+			if s, ok := v.(string); ok && strings.EqualFold(k, name) {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// doJSON sends req and decodes a successful JSON response into v.
+func doJSON(client *http.Client, req *http.Request, v any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(data))
+	}
+	return json.Unmarshal(data, v)
+}
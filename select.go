@@ -0,0 +1,169 @@
+package s3
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const maxSelectRowSize = 16 * 1024 * 1024
+
+// SelectFormat is the serialization of S3 Select input or output.
+type SelectFormat string
+
+const (
+	SelectCSV     SelectFormat = "CSV"
+	SelectJSON    SelectFormat = "JSON"
+	SelectParquet SelectFormat = "Parquet"
+)
+
+type SelectOptions struct {
+	// Expression is the SQL query, e.g.
+	// SELECT s.id FROM S3Object s WHERE s.status = 'failed'.
+	Expression  string
+	InputFormat SelectFormat
+	// InputCompression is GZIP or BZIP2 for compressed CSV and JSON input.
+	InputCompression types.CompressionType
+	// CSVHeader tells how the first CSV line is used; USE makes columns
+	// addressable by name. CSVDelimiter defaults to ",".
+	CSVHeader    types.FileHeaderInfo
+	CSVDelimiter string
+	// JSONLines reads JSON input as one object per line instead of a single
+	// document.
+	JSONLines bool
+	// OutputFormat is SelectCSV or SelectJSON (the default). Rows are
+	// separated by newlines either way.
+	OutputFormat SelectFormat
+}
+
+// SelectStats reports the bytes S3 Select read to answer the query.
+type SelectStats struct {
+	BytesScanned   int64
+	BytesProcessed int64
+	BytesReturned  int64
+}
+
+// SelectRows iterates over the rows returned by Select as they are streamed.
+//
+//	rows, err := client.Select(ctx, key, opts)
+//	defer rows.Close()
+//	for rows.Next() {
+//		row := rows.Row()
+//	}
+//	if err := rows.Err(); err != nil { ... }
+type SelectRows struct {
+	stream  *s3.SelectObjectContentEventStream
+	records *selectRecords
+	scanner *bufio.Scanner
+}
+
+// Select runs an SQL query over a CSV, JSON or Parquet object without
+// downloading it.
+func (c *Client) Select(ctx context.Context, key string, opts SelectOptions) (*SelectRows, error) {
+	input := &s3.SelectObjectContentInput{
+		Bucket:         aws.String(c.bucket),
+		Key:            aws.String(key),
+		Expression:     aws.String(opts.Expression),
+		ExpressionType: types.ExpressionTypeSql,
+		InputSerialization: &types.InputSerialization{
+			CompressionType: opts.InputCompression,
+		},
+		OutputSerialization: &types.OutputSerialization{},
+	}
+	switch opts.InputFormat {
+	case SelectCSV:
+		input.InputSerialization.CSV = &types.CSVInput{
+			FileHeaderInfo: opts.CSVHeader,
+			FieldDelimiter: stringOrNil(opts.CSVDelimiter),
+		}
+	case SelectJSON:
+		jsonType := types.JSONTypeDocument
+		if opts.JSONLines {
+			jsonType = types.JSONTypeLines
+		}
+		input.InputSerialization.JSON = &types.JSONInput{Type: jsonType}
+	case SelectParquet:
+		input.InputSerialization.Parquet = &types.ParquetInput{}
+	default:
+		return nil, fmt.Errorf("unsupported select input format %q", opts.InputFormat)
+	}
+	switch opts.OutputFormat {
+	case SelectCSV:
+		input.OutputSerialization.CSV = &types.CSVOutput{RecordDelimiter: aws.String("\n")}
+	case SelectJSON, "":
+		input.OutputSerialization.JSON = &types.JSONOutput{RecordDelimiter: aws.String("\n")}
+	default:
+		return nil, fmt.Errorf("unsupported select output format %q", opts.OutputFormat)
+	}
+
+	output, err := c.client.SelectObjectContent(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select object content: %w", err)
+	}
+	stream := output.GetStream()
+	records := &selectRecords{stream: stream}
+	scanner := bufio.NewScanner(records)
+	scanner.Buffer(nil, maxSelectRowSize)
+	return &SelectRows{stream: stream, records: records, scanner: scanner}, nil
+}
+
+func (r *SelectRows) Next() bool { return r.scanner.Scan() }
+
+// Row returns the current row without its trailing newline. It is only valid
+// until the next call to Next.
+func (r *SelectRows) Row() []byte { return r.scanner.Bytes() }
+
+func (r *SelectRows) Err() error { return r.scanner.Err() }
+
+// Stats is filled in once all rows have been read.
+func (r *SelectRows) Stats() SelectStats { return r.records.stats }
+
+func (r *SelectRows) Close() error { return r.stream.Close() }
+
+// selectRecords reads the payloads of the Records events in a Select event
+// stream as one byte stream.
+type selectRecords struct {
+	stream *s3.SelectObjectContentEventStream
+	buf    []byte
+	stats  SelectStats
+	ended  bool
+}
+
+func (r *selectRecords) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.ended {
+			return 0, io.EOF
+		}
+		event, ok := <-r.stream.Events()
+		if !ok {
+			if err := r.stream.Err(); err != nil {
+				return 0, fmt.Errorf("failed to read select results: %w", err)
+			}
+			// S3 sends End last; a stream closing before it was cut off.
+			return 0, errors.New("select results ended unexpectedly")
+		}
+		switch e := event.(type) {
+		case *types.SelectObjectContentEventStreamMemberRecords:
+			r.buf = e.Value.Payload
+		case *types.SelectObjectContentEventStreamMemberStats:
+			if details := e.Value.Details; details != nil {
+				r.stats = SelectStats{
+					BytesScanned:   aws.ToInt64(details.BytesScanned),
+					BytesProcessed: aws.ToInt64(details.BytesProcessed),
+					BytesReturned:  aws.ToInt64(details.BytesReturned),
+				}
+			}
+		case *types.SelectObjectContentEventStreamMemberEnd:
+			r.ended = true
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,176 @@
+package s3
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// statsSizeBuckets are the upper bounds of the StorageStats.Histogram
+// buckets.
+var statsSizeBuckets = []int64{
+	1 << 10,
+	1 << 20,
+	16 << 20,
+	128 << 20,
+	1 << 30,
+	math.MaxInt64,
+}
+
+type StatsOption func(*statsOptions)
+
+type statsOptions struct {
+	concurrency int
+}
+
+// WithStatsConcurrency sets how many sub-prefixes PrefixStats lists at once.
+func WithStatsConcurrency(n int) StatsOption {
+	return func(o *statsOptions) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+type ClassStats struct {
+	Objects int64
+	Size    int64
+}
+
+// SizeBucket counts the objects smaller than MaxSize and at least as large
+// as the MaxSize of the previous bucket.
+type SizeBucket struct {
+	MaxSize int64
+	Objects int64
+	Size    int64
+}
+
+// StorageStats aggregates the objects under a prefix.
+type StorageStats struct {
+	Prefix         string
+	Objects        int64
+	Size           int64
+	ByStorageClass map[string]ClassStats
+	Histogram      []SizeBucket
+	LastModified   time.Time
+}
+
+func newStorageStats(prefix string) *StorageStats {
+	stats := &StorageStats{
+		Prefix:         prefix,
+		ByStorageClass: make(map[string]ClassStats),
+		Histogram:      make([]SizeBucket, len(statsSizeBuckets)),
+	}
+	for i, maxSize := range statsSizeBuckets {
+		stats.Histogram[i].MaxSize = maxSize
+	}
+	return stats
+}
+
+func (s *StorageStats) add(obj ObjectInfo) {
+	s.Objects++
+	s.Size += obj.Size
+	class := s.ByStorageClass[obj.StorageClass]
+	class.Objects++
+	class.Size += obj.Size
+	s.ByStorageClass[obj.StorageClass] = class
+	for i := range s.Histogram {
+		if obj.Size < s.Histogram[i].MaxSize {
+			s.Histogram[i].Objects++
+			s.Histogram[i].Size += obj.Size
+			break
+		}
+	}
+	if obj.LastModified.After(s.LastModified) {
+		s.LastModified = obj.LastModified
+	}
+}
+
+func (s *StorageStats) merge(other *StorageStats) {
+	s.Objects += other.Objects
+	s.Size += other.Size
+	for name, class := range other.ByStorageClass {
+		total := s.ByStorageClass[name]
+		total.Objects += class.Objects
+		total.Size += class.Size
+		s.ByStorageClass[name] = total
+	}
+	for i := range s.Histogram {
+		s.Histogram[i].Objects += other.Histogram[i].Objects
+		s.Histogram[i].Size += other.Histogram[i].Size
+	}
+	if other.LastModified.After(s.LastModified) {
+		s.LastModified = other.LastModified
+	}
+}
+
+// PrefixStats walks every object under prefix and reports the object count,
+// total size, a size histogram and a breakdown by storage class. The
+// immediate sub-prefixes ("folders") are listed concurrently, which suits
+// per-tenant layouts such as "tenants/<id>/...".
+func (c *Client) PrefixStats(ctx context.Context, prefix string, opts ...StatsOption) (*StorageStats, error) {
+	o := &statsOptions{concurrency: defaultConcurrency}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	listing, err := c.ListDirectory(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	stats := newStorageStats(prefix)
+	for _, obj := range listing.Files {
+		stats.add(obj)
+	}
+	if len(listing.Folders) == 0 {
+		return stats, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	jobs := make(chan string)
+	for i := 0; i < min(o.concurrency, len(listing.Folders)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for folder := range jobs {
+				folderStats := newStorageStats(folder)
+				err := c.ListAll(ctx, folder, func(obj ObjectInfo) error {
+					folderStats.add(obj)
+					return nil
+				})
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				stats.merge(folderStats)
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, folder := range listing.Folders {
+		select {
+		case jobs <- folder:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,183 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+var errWriterClosed = errors.New("object writer is closed")
+
+// ObjectWriter streams writes into an object through UploadLarge. Nothing is
+// visible in the bucket until Close returns nil; a failed write, a canceled
+// context or CloseWithError aborts the upload instead.
+type ObjectWriter struct {
+	pw   *io.PipeWriter
+	done chan struct{}
+	err  error
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewWriter returns a writer for key. The upload options are those of
+// UploadLarge, so bodies up to the multipart threshold are sent with a single
+// PutObject and larger ones in parts while writing continues.
+func (c *Client) NewWriter(ctx context.Context, key string, opts ...UploadOption) *ObjectWriter {
+	pr, pw := io.Pipe()
+	w := &ObjectWriter{pw: pw, done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		w.err = c.UploadLarge(ctx, key, pr, opts...)
+		// Unblocks writers when the upload stops reading early.
+		if w.err != nil {
+			pr.CloseWithError(w.err)
+		} else {
+			pr.CloseWithError(errWriterClosed)
+		}
+	}()
+	return w
+}
+
+// Write fails with the upload error once the upload has stopped.
+func (w *ObjectWriter) Write(p []byte) (int, error) {
+	return w.pw.Write(p)
+}
+
+// Close finishes the upload and returns its error.
+func (w *ObjectWriter) Close() error {
+	return w.CloseWithError(nil)
+}
+
+// CloseWithError aborts the upload with err; a nil err commits it like
+// Close. Only the first call has an effect.
+func (w *ObjectWriter) CloseWithError(err error) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return errWriterClosed
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	if err != nil {
+		w.pw.CloseWithError(err)
+	} else {
+		w.pw.Close()
+	}
+	<-w.done
+	if err != nil {
+		return fmt.Errorf("upload aborted: %w", err)
+	}
+	return w.err
+}
+
+// ObjectReader reads an object with ranged GETs. It implements io.ReaderAt
+// for random access, e.g. with archive/zip, and io.ReadSeeker for sequential
+// reads that keep one response body open. Every request is pinned to the ETag
+// seen when the reader was created, so a concurrent overwrite fails with
+// ErrPreconditionFailed instead of mixing two versions.
+type ObjectReader struct {
+	client *Client
+	ctx    context.Context
+	key    string
+	size   int64
+	etag   string
+
+	mu     sync.Mutex
+	offset int64
+	body   io.ReadCloser
+}
+
+func (c *Client) NewReaderAt(ctx context.Context, key string) (*ObjectReader, error) {
+	info, err := c.GetObjectInfo(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectReader{client: c, ctx: ctx, key: key, size: info.Size, etag: info.ETag}, nil
+}
+
+// Size returns the object size, as needed by zip.NewReader.
+func (r *ObjectReader) Size() int64 { return r.size }
+
+// ReadAt is safe for concurrent use; each call issues its own ranged GET.
+func (r *ObjectReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("offset must not be negative")
+	}
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	length := min(int64(len(p)), r.size-off)
+	if length == 0 {
+		return 0, nil
+	}
+	body, _, err := r.client.DownloadRange(r.ctx, r.key, off, length, WithDownloadIfMatch(r.etag))
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+	n, err := io.ReadFull(body, p[:length])
+	if err != nil {
+		return n, fmt.Errorf("failed to read range: %w", err)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *ObjectReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, _, err := r.client.DownloadRange(r.ctx, r.key, r.offset, 0, WithDownloadIfMatch(r.etag))
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *ObjectReader) Seek(offset int64, whence int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	if offset != r.offset && r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+// Close releases the response body of sequential reads.
+func (r *ObjectReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.body != nil {
+		err := r.body.Close()
+		r.body = nil
+		return err
+	}
+	return nil
+}
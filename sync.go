@@ -0,0 +1,310 @@
+package s3
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+type SyncOption func(*syncOptions)
+
+type syncOptions struct {
+	delete      bool
+	dryRun      bool
+	compareETag bool
+	concurrency int
+	upload      []UploadOption
+}
+
+// WithSyncDelete removes objects under the prefix that have no local file.
+func WithSyncDelete() SyncOption {
+	return func(o *syncOptions) { o.delete = true }
+}
+
+// WithSyncDryRun makes Sync report the planned changes without touching the
+// bucket.
+func WithSyncDryRun() SyncOption {
+	return func(o *syncOptions) { o.dryRun = true }
+}
+
+// WithSyncCompareETag compares file contents with the object ETag instead of
+// modification times when sizes match. Multipart, SSE-KMS and SSE-C ETags are
+// not content hashes; such objects fall back to the modification time. A
+// mismatching ETag costs a HeadObject to tell the encryption.
+func WithSyncCompareETag() SyncOption {
+	return func(o *syncOptions) { o.compareETag = true }
+}
+
+func WithSyncConcurrency(n int) SyncOption {
+	return func(o *syncOptions) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// WithSyncUploadOptions applies opts to every upload. The content type is
+// guessed from the file extension unless set here.
+func WithSyncUploadOptions(opts ...UploadOption) SyncOption {
+	return func(o *syncOptions) { o.upload = append(o.upload, opts...) }
+}
+
+type SyncError struct {
+	Key string
+	Err error
+}
+
+func (e SyncError) Error() string {
+	return fmt.Sprintf("failed to sync %s: %v", e.Key, e.Err)
+}
+
+func (e SyncError) Unwrap() error { return e.Err }
+
+type SyncResult struct {
+	DryRun   bool
+	Uploaded []string
+	Deleted  []string
+	// Unchanged counts objects that were already up to date.
+	Unchanged int
+	Failed    []SyncError
+}
+
+type syncFile struct {
+	path    string
+	key     string
+	size    int64
+	modTime time.Time
+}
+
+// Sync makes the objects under prefix match the regular files in dir, like
+// "aws s3 sync": files whose size differs, or that are newer than their
+// object, are uploaded concurrently. Objects with no local file are kept
+// unless WithSyncDelete is given. A "/" is appended to prefix if missing, so
+// sibling prefixes such as "photos-old/" are never touched.
+func (c *Client) Sync(ctx context.Context, dir, prefix string, opts ...SyncOption) (*SyncResult, error) {
+	o := &syncOptions{concurrency: defaultConcurrency}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	local, err := localFiles(dir, prefix)
+	if err != nil {
+		return nil, err
+	}
+	remote := make(map[string]ObjectInfo)
+	err = c.ListAll(ctx, prefix, func(obj ObjectInfo) error {
+		remote[obj.Key] = obj
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(local))
+	for key := range local {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := &SyncResult{DryRun: o.dryRun}
+	var uploads []syncFile
+	for _, key := range keys {
+		file := local[key]
+		obj, ok := remote[file.key]
+		if !ok {
+			uploads = append(uploads, file)
+			continue
+		}
+		changed, err := c.syncChanged(ctx, file, obj, o)
+		if err != nil {
+			result.Failed = append(result.Failed, SyncError{Key: file.key, Err: err})
+			continue
+		}
+		if changed {
+			uploads = append(uploads, file)
+		} else {
+			result.Unchanged++
+		}
+	}
+	var extraneous []string
+	if o.delete {
+		for key := range remote {
+			if _, ok := local[key]; !ok {
+				extraneous = append(extraneous, key)
+			}
+		}
+		sort.Strings(extraneous)
+	}
+
+	if o.dryRun {
+		for _, file := range uploads {
+			result.Uploaded = append(result.Uploaded, file.key)
+		}
+		result.Deleted = extraneous
+		return result, nil
+	}
+
+	c.syncUploads(ctx, uploads, o, result)
+	sort.Strings(result.Uploaded)
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	if len(extraneous) > 0 {
+		deleted, err := c.DeleteFiles(ctx, extraneous)
+		if deleted != nil {
+			result.Deleted = deleted.Deleted
+			for _, failed := range deleted.Failed {
+				result.Failed = append(result.Failed, SyncError{Key: failed.Key, Err: failed})
+			}
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func (c *Client) syncUploads(ctx context.Context, files []syncFile, o *syncOptions, result *SyncResult) {
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	jobs := make(chan syncFile)
+	for i := 0; i < o.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range jobs {
+				err := c.uploadSyncFile(ctx, file, o)
+
+				mu.Lock()
+				if err != nil {
+					result.Failed = append(result.Failed, SyncError{Key: file.key, Err: err})
+				} else {
+					result.Uploaded = append(result.Uploaded, file.key)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, file := range files {
+		select {
+		case jobs <- file:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func (c *Client) uploadSyncFile(ctx context.Context, file syncFile, o *syncOptions) error {
+	f, err := os.Open(file.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var opts []UploadOption
+	if contentType := mime.TypeByExtension(path.Ext(file.key)); contentType != "" {
+		opts = append(opts, WithContentType(contentType))
+	}
+	return c.UploadLarge(ctx, file.key, f, append(opts, o.upload...)...)
+}
+
+// syncChanged reports whether file needs to be uploaded over obj.
+func (c *Client) syncChanged(ctx context.Context, file syncFile, obj ObjectInfo, o *syncOptions) (bool, error) {
+	if file.size != obj.Size {
+		return true, nil
+	}
+	etag := strings.Trim(obj.ETag, `"`)
+	if o.compareETag && etag != "" && !strings.Contains(etag, "-") {
+		sum, err := fileMD5(file.path)
+		if err != nil {
+			return false, err
+		}
+		if sum == etag {
+			return false, nil
+		}
+		// Listings carry no encryption details, and only the ETags of
+		// unencrypted and SSE-S3 objects are MD5 sums.
+		head, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(c.bucket),
+			Key:    aws.String(obj.Key),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to get object info: %w", err)
+		}
+		if head.SSECustomerAlgorithm == nil && (head.ServerSideEncryption == "" || head.ServerSideEncryption == types.ServerSideEncryptionAes256) {
+			return true, nil
+		}
+	}
+	return file.modTime.After(obj.LastModified), nil
+}
+
+// localFiles maps the object key of every regular file under dir to the
+// file.
+func localFiles(dir, prefix string) (map[string]syncFile, error) {
+	files := make(map[string]syncFile)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		key := prefix + filepath.ToSlash(rel)
+		files[key] = syncFile{
+			path:    p,
+			key:     key,
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+func fileMD5(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
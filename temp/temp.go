@@ -0,0 +1,197 @@
+// Package temp stores short-lived objects, such as export files or one-time
+// download links, under a dedicated prefix. Every object records its expiry
+// in the x-amz-meta-expires-at header and the expires-at tag; Sweep deletes
+// expired objects and InstallLifecycleRule adds an S3 lifecycle rule as a
+// backstop for when Sweep does not run.
+//
+//	store := temp.New(client)
+//	key, err := store.PutTemp(ctx, "exports/report.csv", body, 24*time.Hour)
+//	url, err := store.URL(ctx, key)
+package temp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	s3 "github.com/aranoy15/go-s3"
+)
+
+const (
+	DefaultPrefix = ".tmp/"
+
+	expiresAtKey    = "expires-at"
+	lifecycleRuleID = "go-s3-temp"
+)
+
+// ErrExpired is returned for objects whose TTL has passed but that have not
+// been swept yet.
+var ErrExpired = errors.New("temporary object expired")
+
+type lifecycleClient interface {
+	GetLifecycleRules(ctx context.Context) ([]s3.LifecycleRule, error)
+	SetLifecycleRules(ctx context.Context, rules []s3.LifecycleRule) error
+}
+
+type options struct {
+	prefix string
+}
+
+type Option func(*options)
+
+// WithPrefix overrides the prefix objects are stored under, DefaultPrefix by
+// default.
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		if prefix != "" {
+			o.prefix = prefix
+		}
+	}
+}
+
+// Store keeps temporary objects in the bucket of its client.
+type Store struct {
+	client s3.S3Client
+	prefix string
+}
+
+func New(client s3.S3Client, opts ...Option) *Store {
+	o := &options{prefix: DefaultPrefix}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Store{client: client, prefix: o.prefix}
+}
+
+// Prefix returns the prefix the store keeps its objects under.
+func (s *Store) Prefix() string { return s.prefix }
+
+// PutTemp uploads r as the store prefix followed by key, to expire after
+// ttl, and returns the object key.
+func (s *Store) PutTemp(ctx context.Context, key string, r io.Reader, ttl time.Duration, opts ...s3.UploadOption) (string, error) {
+	if ttl <= 0 {
+		return "", fmt.Errorf("temporary object TTL must be positive, got %s", ttl)
+	}
+	objectKey := s.prefix + key
+	expiresAt := time.Now().Add(ttl).UTC().Format(time.RFC3339)
+	opts = append(opts,
+		s3.WithMetadata(map[string]string{expiresAtKey: expiresAt}),
+		s3.WithTags(map[string]string{expiresAtKey: expiresAt}),
+	)
+	if err := s.client.UploadLarge(ctx, objectKey, r, opts...); err != nil {
+		return "", err
+	}
+	return objectKey, nil
+}
+
+// Open downloads a temporary object by the key returned from PutTemp.
+func (s *Store) Open(ctx context.Context, key string, opts ...s3.DownloadOption) (io.ReadCloser, *s3.ObjectInfo, error) {
+	body, info, err := s.client.DownloadFile(ctx, key, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if expiresAt, ok := expiry(info); ok && !time.Now().Before(expiresAt) {
+		body.Close()
+		return nil, nil, ErrExpired
+	}
+	return body, info, nil
+}
+
+// URL presigns a download URL for key that expires together with the
+// object.
+func (s *Store) URL(ctx context.Context, key string, opts ...s3.PresignOption) (string, error) {
+	info, err := s.client.GetObjectInfo(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	expiresAt, ok := expiry(info)
+	if !ok {
+		return "", fmt.Errorf("%s is not a temporary object", key)
+	}
+	remaining := time.Until(expiresAt)
+	if remaining <= 0 {
+		return "", ErrExpired
+	}
+	return s.client.GetPresignedURL(ctx, key, remaining, opts...)
+}
+
+// Sweep deletes the expired objects under the store prefix. Objects without
+// a recorded expiry are left alone.
+func (s *Store) Sweep(ctx context.Context) (*s3.DeleteResult, error) {
+	var candidates []string
+	err := s.client.ListAll(ctx, s.prefix, func(obj s3.ObjectInfo) error {
+		candidates = append(candidates, obj.Key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var expired []string
+	for _, key := range candidates {
+		info, err := s.client.GetObjectInfo(ctx, key)
+		if errors.Is(err, s3.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if expiresAt, ok := expiry(info); ok && !now.Before(expiresAt) {
+			expired = append(expired, key)
+		}
+	}
+	if len(expired) == 0 {
+		return &s3.DeleteResult{}, nil
+	}
+	return s.client.DeleteFiles(ctx, expired)
+}
+
+// InstallLifecycleRule makes S3 delete everything under the store prefix
+// maxTTL after upload, rounded up to whole days, keeping the other rules of
+// the bucket. Use the longest TTL passed to PutTemp. It needs a *s3.Client.
+func (s *Store) InstallLifecycleRule(ctx context.Context, maxTTL time.Duration) error {
+	lc, ok := s.client.(lifecycleClient)
+	if !ok {
+		return errors.New("client does not support lifecycle rules")
+	}
+	rules, err := lc.GetLifecycleRules(ctx)
+	if err != nil {
+		return err
+	}
+	rule := s3.LifecycleRule{
+		ID:                              lifecycleRuleID,
+		Prefix:                          s.prefix,
+		ExpireAfterDays:                 int32(math.Ceil(maxTTL.Hours() / 24)),
+		AbortIncompleteUploadsAfterDays: 1,
+	}
+	if rule.ExpireAfterDays < 1 {
+		rule.ExpireAfterDays = 1
+	}
+	replaced := false
+	for i := range rules {
+		if rules[i].ID == lifecycleRuleID {
+			rules[i] = rule
+			replaced = true
+		}
+	}
+	if !replaced {
+		rules = append(rules, rule)
+	}
+	return lc.SetLifecycleRules(ctx, rules)
+}
+
+func expiry(info *s3.ObjectInfo) (time.Time, bool) {
+	value, ok := info.Metadata[expiresAtKey]
+	if !ok {
+		return time.Time{}, false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return expiresAt, true
+}
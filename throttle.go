@@ -0,0 +1,133 @@
+package s3
+
+import (
+	"context"
+	"io"
+
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"golang.org/x/time/rate"
+)
+
+// RateLimitOptions caps the client's request rate and bandwidth. Limits are
+// shared by all requests of the client, including concurrent multipart
+// parts; zero values mean unlimited.
+type RateLimitOptions struct {
+	// RequestsPerSecond limits how fast requests, retries included, are
+	// sent. Burst is the number of requests allowed at once and defaults to
+	// 1.
+	RequestsPerSecond float64
+	Burst             int
+	// UploadBytesPerSecond and DownloadBytesPerSecond limit request and
+	// response body throughput.
+	UploadBytesPerSecond   int64
+	DownloadBytesPerSecond int64
+}
+
+// addRateLimit registers the limiters configured in opts. They are created
+// once and shared by every request of the client.
+func addRateLimit(opts RateLimitOptions) func(*middleware.Stack) error {
+	var requests *rate.Limiter
+	if opts.RequestsPerSecond > 0 {
+		burst := opts.Burst
+		if burst <= 0 {
+			burst = 1
+		}
+		requests = rate.NewLimiter(rate.Limit(opts.RequestsPerSecond), burst)
+	}
+	bandwidth := &bandwidthMiddleware{
+		upload:   bandwidthLimiter(opts.UploadBytesPerSecond),
+		download: bandwidthLimiter(opts.DownloadBytesPerSecond),
+	}
+
+	return func(stack *middleware.Stack) error {
+		if isPresignStack(stack) {
+			return nil
+		}
+		if requests != nil {
+			// After Retry, so that every attempt waits for a token.
+			mw := &rateLimitMiddleware{limiter: requests}
+			var err error
+			if _, ok := stack.Finalize.Get("Retry"); ok {
+				err = stack.Finalize.Insert(mw, "Retry", middleware.After)
+			} else {
+				err = stack.Finalize.Add(mw, middleware.After)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if bandwidth.upload != nil || bandwidth.download != nil {
+			return stack.Deserialize.Add(bandwidth, middleware.After)
+		}
+		return nil
+	}
+}
+
+// bandwidthLimiter returns a token bucket holding one second worth of bytes.
+func bandwidthLimiter(bytesPerSecond int64) *rate.Limiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), int(bytesPerSecond))
+}
+
+type rateLimitMiddleware struct {
+	limiter *rate.Limiter
+}
+
+func (*rateLimitMiddleware) ID() string { return "go-s3.RateLimit" }
+
+func (m *rateLimitMiddleware) HandleFinalize(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+	if err := m.limiter.Wait(ctx); err != nil {
+		return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+	}
+	return next.HandleFinalize(ctx, in)
+}
+
+// bandwidthMiddleware paces the request body as it is written to the
+// connection and the response body as the caller reads it.
+type bandwidthMiddleware struct {
+	upload   *rate.Limiter
+	download *rate.Limiter
+}
+
+func (*bandwidthMiddleware) ID() string { return "go-s3.Bandwidth" }
+
+func (m *bandwidthMiddleware) HandleDeserialize(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler) (middleware.DeserializeOutput, middleware.Metadata, error) {
+	if req, ok := in.Request.(*smithyhttp.Request); ok && m.upload != nil && req.GetStream() != nil {
+		throttled, err := req.SetStream(struct{ io.Reader }{&throttledReader{r: req.GetStream(), ctx: ctx, limiter: m.upload}})
+		if err != nil {
+			return middleware.DeserializeOutput{}, middleware.Metadata{}, err
+		}
+		in.Request = throttled
+	}
+
+	out, metadata, err := next.HandleDeserialize(ctx, in)
+	if resp, ok := out.RawResponse.(*smithyhttp.Response); ok && m.download != nil && resp.Body != nil {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{&throttledReader{r: resp.Body, ctx: ctx, limiter: m.download}, resp.Body}
+	}
+	return out, metadata, err
+}
+
+type throttledReader struct {
+	r       io.Reader
+	ctx     context.Context
+	limiter *rate.Limiter
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	if burst := r.limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,98 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+)
+
+const defaultMetadataTimeout = 30 * time.Second
+
+// TimeoutOptions bound S3 calls whose context has no earlier deadline, so a
+// call site without context.WithTimeout cannot hang forever. A timeout covers
+// the operation with all its retries.
+type TimeoutOptions struct {
+	// Metadata bounds operations that carry no object data: HEAD, listings,
+	// tags, deletes, bucket configuration. It defaults to 30 seconds; a
+	// negative value disables it.
+	Metadata time.Duration
+	// Transfer bounds operations that move object data (GetObject,
+	// PutObject, UploadPart, CopyObject, UploadPartCopy and
+	// CompleteMultipartUpload), including reading a GetObject body. Zero means
+	// no timeout, since their duration grows with the object size.
+	Transfer time.Duration
+	// Operations overrides the timeout of single operations by SDK name,
+	// e.g. {"ListObjectsV2": time.Minute}; a negative value disables it.
+	Operations map[string]time.Duration
+}
+
+// transferOperations take time proportional to the amount of data.
+var transferOperations = map[string]bool{
+	"GetObject":               true,
+	"PutObject":               true,
+	"UploadPart":              true,
+	"CopyObject":              true,
+	"UploadPartCopy":          true,
+	"CompleteMultipartUpload": true,
+}
+
+func (o TimeoutOptions) timeout(operation string) time.Duration {
+	if d, ok := o.Operations[operation]; ok {
+		return d
+	}
+	if transferOperations[operation] {
+		return o.Transfer
+	}
+	if o.Metadata == 0 {
+		return defaultMetadataTimeout
+	}
+	return o.Metadata
+}
+
+func addTimeouts(opts TimeoutOptions) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		// SelectObjectContent streams its events after the call returns,
+		// which a deadline would cut off.
+		if isPresignStack(stack) || stack.ID() == "SelectObjectContent" {
+			return nil
+		}
+		d := opts.timeout(stack.ID())
+		if d <= 0 {
+			return nil
+		}
+		return stack.Initialize.Add(&timeoutMiddleware{timeout: d}, middleware.Before)
+	}
+}
+
+type timeoutMiddleware struct {
+	timeout time.Duration
+}
+
+func (*timeoutMiddleware) ID() string { return "go-s3.Timeout" }
+
+func (m *timeoutMiddleware) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	out, metadata, err := next.HandleInitialize(ctx, in)
+	if output, ok := out.Result.(*s3.GetObjectOutput); ok && err == nil && output.Body != nil {
+		// The deadline also covers reading the body, so it is released
+		// when the body is closed.
+		output.Body = &cancelOnClose{ReadCloser: output.Body, cancel: cancel}
+		return out, metadata, err
+	}
+	cancel()
+	return out, metadata, err
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
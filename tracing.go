@@ -0,0 +1,114 @@
+package s3
+
+import (
+	"context"
+	"reflect"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/aranoy15/go-s3"
+
+// addTracing starts a client span around every S3 API call, so all client
+// methods, including the ones that issue several requests, show up as child
+// spans of the caller's context.
+func addTracing(tp trace.TracerProvider) func(*middleware.Stack) error {
+	tracer := tp.Tracer(instrumentationName)
+	return func(stack *middleware.Stack) error {
+		if isPresignStack(stack) {
+			return nil
+		}
+		if err := stack.Initialize.Add(&tracingMiddleware{tracer: tracer}, middleware.After); err != nil {
+			return err
+		}
+		return stack.Build.Add(&requestSizeMiddleware{}, middleware.After)
+	}
+}
+
+type tracingMiddleware struct {
+	tracer trace.Tracer
+}
+
+func (*tracingMiddleware) ID() string { return "go-s3.Tracing" }
+
+func (m *tracingMiddleware) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	operation := awsmiddleware.GetOperationName(ctx)
+	bucket, key := operationTarget(in.Parameters)
+
+	ctx, span := m.tracer.Start(ctx, "S3."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "aws-api"),
+			attribute.String("rpc.service", "S3"),
+			attribute.String("rpc.method", operation),
+			attribute.String("aws.s3.bucket", bucket),
+		),
+	)
+	defer span.End()
+	if key != "" {
+		span.SetAttributes(attribute.String("aws.s3.key", key))
+	}
+
+	out, metadata, err := next.HandleInitialize(ctx, in)
+
+	if requestID, ok := awsmiddleware.GetRequestIDMetadata(metadata); ok {
+		span.SetAttributes(attribute.String("aws.request_id", requestID))
+	}
+	if resp, ok := awsmiddleware.GetRawResponse(metadata).(*smithyhttp.Response); ok {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		if resp.ContentLength > 0 {
+			span.SetAttributes(attribute.Int64("http.response.body.size", resp.ContentLength))
+		}
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return out, metadata, err
+}
+
+// requestSizeMiddleware records the request body size once the SDK has
+// computed Content-Length.
+type requestSizeMiddleware struct{}
+
+func (*requestSizeMiddleware) ID() string { return "go-s3.RequestSize" }
+
+func (*requestSizeMiddleware) HandleBuild(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+	if req, ok := in.Request.(*smithyhttp.Request); ok && req.ContentLength > 0 {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("http.request.body.size", req.ContentLength))
+	}
+	return next.HandleBuild(ctx, in)
+}
+
+// isPresignStack reports whether the stack only presigns a request; such
+// stacks never reach the network and must not be instrumented.
+func isPresignStack(stack *middleware.Stack) bool {
+	_, ok := stack.Initialize.Get("AsIsPresigningMiddleware")
+	return ok
+}
+
+// operationTarget reads the Bucket and Key fields shared by S3 input types.
+func operationTarget(params any) (bucket, key string) {
+	v := reflect.ValueOf(params)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return "", ""
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return "", ""
+	}
+	return stringField(v, "Bucket"), stringField(v, "Key")
+}
+
+func stringField(v reflect.Value, name string) string {
+	f := v.FieldByName(name)
+	if !f.IsValid() || f.Kind() != reflect.Pointer || f.IsNil() || f.Elem().Kind() != reflect.String {
+		return ""
+	}
+	return f.Elem().String()
+}
@@ -0,0 +1,147 @@
+package s3
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+)
+
+// HTTPOptions tunes the SDK's default transport. Zero values keep the SDK
+// defaults.
+type HTTPOptions struct {
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	IdleConnTimeout       time.Duration
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	// Timeout bounds a whole request including reading the body, so leave
+	// it unset when transferring large objects.
+	Timeout time.Duration
+	// ProxyURL routes all requests through the proxy. When empty the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables apply.
+	ProxyURL string
+}
+
+// TLSOptions configures how the endpoint's certificate is verified and
+// which client certificate is presented.
+type TLSOptions struct {
+	// RootCAs replaces the system roots. CAFile is a PEM bundle added to
+	// RootCAs, or to the system roots when RootCAs is nil, e.g. the private
+	// CA of an on-prem MinIO.
+	RootCAs *x509.CertPool
+	CAFile  string
+	// Certificates are presented for mutual TLS; CertFile and KeyFile load
+	// one more from PEM files.
+	Certificates []tls.Certificate
+	CertFile     string
+	KeyFile      string
+	// InsecureSkipVerify accepts any certificate. Only for local
+	// development against self-signed endpoints.
+	InsecureSkipVerify bool
+}
+
+func (o TLSOptions) enabled() bool {
+	return o.RootCAs != nil || o.CAFile != "" || len(o.Certificates) > 0 || o.CertFile != "" || o.KeyFile != "" || o.InsecureSkipVerify
+}
+
+// apply loads the files of o into tlsConfig.
+func (o TLSOptions) apply(tlsConfig *tls.Config) error {
+	if o.RootCAs != nil {
+		// A copy: the SDK appends AWS_CA_BUNDLE to the pool in place.
+		tlsConfig.RootCAs = o.RootCAs.Clone()
+	}
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA file: %w", err)
+		}
+		if tlsConfig.RootCAs == nil {
+			if tlsConfig.RootCAs, err = x509.SystemCertPool(); err != nil {
+				tlsConfig.RootCAs = x509.NewCertPool()
+			}
+		}
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in CA file %s", o.CAFile)
+		}
+	}
+	tlsConfig.Certificates = append(tlsConfig.Certificates, o.Certificates...)
+	if o.CertFile != "" || o.KeyFile != "" {
+		if o.CertFile == "" || o.KeyFile == "" {
+			return errors.New("TLS client certificate requires both CertFile and KeyFile")
+		}
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load TLS client certificate: %w", err)
+		}
+		tlsConfig.Certificates = append(tlsConfig.Certificates, cert)
+	}
+	tlsConfig.InsecureSkipVerify = o.InsecureSkipVerify
+	return nil
+}
+
+func newHTTPClient(cfg *Config) (aws.HTTPClient, error) {
+	if cfg.HTTPClient != nil {
+		return cfg.HTTPClient, nil
+	}
+
+	var tlsConfig *tls.Config
+	if cfg.TLS.enabled() {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		if err := cfg.TLS.apply(tlsConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	opts := cfg.HTTP
+	var proxy func(*http.Request) (*url.URL, error)
+	if opts.ProxyURL != "" {
+		proxyURL, err := url.Parse(opts.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	client := awshttp.NewBuildableClient().WithTransportOptions(func(t *http.Transport) {
+		if opts.MaxIdleConns > 0 {
+			t.MaxIdleConns = opts.MaxIdleConns
+		}
+		if opts.MaxIdleConnsPerHost > 0 {
+			t.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+		}
+		if opts.IdleConnTimeout > 0 {
+			t.IdleConnTimeout = opts.IdleConnTimeout
+		}
+		if opts.TLSHandshakeTimeout > 0 {
+			t.TLSHandshakeTimeout = opts.TLSHandshakeTimeout
+		}
+		if opts.ResponseHeaderTimeout > 0 {
+			t.ResponseHeaderTimeout = opts.ResponseHeaderTimeout
+		}
+		if proxy != nil {
+			t.Proxy = proxy
+		}
+		if tlsConfig != nil {
+			t.TLSClientConfig = tlsConfig
+		}
+	})
+	if opts.DialTimeout > 0 {
+		client = client.WithDialerOptions(func(d *net.Dialer) {
+			d.Timeout = opts.DialTimeout
+		})
+	}
+	if opts.Timeout > 0 {
+		client = client.WithTimeout(opts.Timeout)
+	}
+	return client, nil
+}
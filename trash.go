@@ -0,0 +1,150 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const defaultTrashPrefix = ".trash/"
+
+var errSoftDeleteDisabled = errors.New("soft delete not configured")
+
+// SoftDeleteConfig makes DeleteFile keep deleted objects so that Restore can
+// bring them back until PurgeTrash removes them.
+type SoftDeleteConfig struct {
+	// TrashPrefix is where DeleteFile moves objects, keeping their key under
+	// it. Defaults to ".trash/". Deleting an object inside the trash removes
+	// it permanently.
+	TrashPrefix string
+	// UseVersioning relies on the delete markers of a versioned bucket
+	// instead of moving objects, so nothing is copied on delete.
+	UseVersioning bool
+}
+
+func newSoftDeleteConfig(cfg *SoftDeleteConfig) *SoftDeleteConfig {
+	if cfg == nil {
+		return nil
+	}
+	resolved := *cfg
+	if resolved.TrashPrefix == "" {
+		resolved.TrashPrefix = defaultTrashPrefix
+	}
+	return &resolved
+}
+
+// trashed reports whether DeleteFile should move key to the trash rather
+// than delete it. Deletes of a specific version are always permanent.
+func (c *Client) trashed(key string, o *deleteOptions) bool {
+	return c.softDelete != nil && !c.softDelete.UseVersioning &&
+		o.versionID == "" && !strings.HasPrefix(key, c.softDelete.TrashPrefix)
+}
+
+func (c *Client) moveToTrash(ctx context.Context, key string, o *deleteOptions) error {
+	src, err := c.copyFile(ctx, key, c.softDelete.TrashPrefix+key, &copyOptions{ifMatch: o.ifMatch})
+	if err != nil {
+		return fmt.Errorf("failed to move file to trash: %w", err)
+	}
+	return c.deleteObject(ctx, key, &deleteOptions{ifMatch: aws.ToString(src.ETag)})
+}
+
+// Restore undoes a soft delete of key. It fails with ErrNotFound if there is
+// nothing to restore.
+func (c *Client) Restore(ctx context.Context, key string) error {
+	if c.softDelete == nil {
+		return errSoftDeleteDisabled
+	}
+	if c.softDelete.UseVersioning {
+		return c.restoreVersioned(ctx, key)
+	}
+
+	trashKey := c.softDelete.TrashPrefix + key
+	src, err := c.copyFile(ctx, trashKey, key, &copyOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to restore file from trash: %w", err)
+	}
+	return c.deleteObject(ctx, trashKey, &deleteOptions{ifMatch: aws.ToString(src.ETag)})
+}
+
+// restoreVersioned removes the delete marker hiding the latest version.
+func (c *Client) restoreVersioned(ctx context.Context, key string) error {
+	versions, err := c.ListVersions(ctx, key)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if v.Key != key || !v.IsLatest {
+			continue
+		}
+		if !v.IsDeleteMarker {
+			return nil
+		}
+		return c.deleteObject(ctx, key, &deleteOptions{versionID: v.VersionID})
+	}
+	return fmt.Errorf("failed to restore %s: %w", key, ErrNotFound)
+}
+
+// PurgeTrash permanently deletes objects that were soft deleted more than
+// olderThan ago. With UseVersioning every version of such objects is removed.
+func (c *Client) PurgeTrash(ctx context.Context, olderThan time.Duration) (*DeleteResult, error) {
+	if c.softDelete == nil {
+		return nil, errSoftDeleteDisabled
+	}
+	cutoff := time.Now().Add(-olderThan)
+	if c.softDelete.UseVersioning {
+		return c.purgeVersions(ctx, cutoff)
+	}
+
+	var keys []string
+	err := c.ListAll(ctx, c.softDelete.TrashPrefix, func(obj ObjectInfo) error {
+		// The copy into the trash resets LastModified to the deletion time.
+		if obj.LastModified.Before(cutoff) {
+			keys = append(keys, obj.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.DeleteFiles(ctx, keys)
+}
+
+// purgeVersions deletes every version of the keys whose latest version is a
+// delete marker older than cutoff.
+func (c *Client) purgeVersions(ctx context.Context, cutoff time.Time) (*DeleteResult, error) {
+	versions, err := c.ListVersions(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	purge := make(map[string]bool)
+	for _, v := range versions {
+		if v.IsLatest && v.IsDeleteMarker && v.LastModified.Before(cutoff) {
+			purge[v.Key] = true
+		}
+	}
+
+	var objects []types.ObjectIdentifier
+	for _, v := range versions {
+		if purge[v.Key] {
+			objects = append(objects, types.ObjectIdentifier{
+				Key:       aws.String(v.Key),
+				VersionId: aws.String(v.VersionID),
+			})
+		}
+	}
+	result := &DeleteResult{}
+	for start := 0; start < len(objects); start += maxDeleteKeys {
+		end := min(start+maxDeleteKeys, len(objects))
+		batch, err := c.deleteObjects(ctx, objects[start:end])
+		if err != nil {
+			return result, err
+		}
+		result.merge(batch)
+	}
+	return result, nil
+}
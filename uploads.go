@@ -0,0 +1,98 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// MultipartUpload is a multipart upload that was started but neither
+// completed nor aborted. Its parts are billed as storage until it is.
+type MultipartUpload struct {
+	Key          string
+	UploadID     string
+	Initiated    time.Time
+	StorageClass string
+}
+
+type AbortError struct {
+	Upload MultipartUpload
+	Err    error
+}
+
+type AbortResult struct {
+	Aborted []MultipartUpload
+	Failed  []AbortError
+}
+
+// ListMultipartUploads returns the incomplete multipart uploads of keys
+// under prefix, oldest first.
+func (c *Client) ListMultipartUploads(ctx context.Context, prefix string) ([]MultipartUpload, error) {
+	paginator := s3.NewListMultipartUploadsPaginator(c.client, &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(c.bucket),
+		Prefix: stringOrNil(prefix),
+	})
+	var uploads []MultipartUpload
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list multipart uploads: %w", err)
+		}
+		for _, upload := range page.Uploads {
+			uploads = append(uploads, MultipartUpload{
+				Key:          aws.ToString(upload.Key),
+				UploadID:     aws.ToString(upload.UploadId),
+				Initiated:    aws.ToTime(upload.Initiated),
+				StorageClass: string(upload.StorageClass),
+			})
+		}
+	}
+	sort.SliceStable(uploads, func(i, j int) bool {
+		return uploads[i].Initiated.Before(uploads[j].Initiated)
+	})
+	return uploads, nil
+}
+
+// AbortStaleUploads aborts the multipart uploads started more than olderThan
+// ago, freeing their parts. Uploads S3 refused to abort are reported in
+// AbortResult.Failed; the returned error is reserved for a failed listing.
+// Keep olderThan well above the longest upload, or in-flight uploads fail.
+func (c *Client) AbortStaleUploads(ctx context.Context, olderThan time.Duration) (*AbortResult, error) {
+	uploads, err := c.ListMultipartUploads(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	result := &AbortResult{}
+	for _, upload := range uploads {
+		if !upload.Initiated.Before(cutoff) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		_, err := c.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(c.bucket),
+			Key:      aws.String(upload.Key),
+			UploadId: aws.String(upload.UploadID),
+		})
+		var noSuchUpload *types.NoSuchUpload
+		if errors.As(err, &noSuchUpload) {
+			// Completed or aborted since it was listed.
+			continue
+		}
+		if err != nil {
+			result.Failed = append(result.Failed, AbortError{Upload: upload, Err: err})
+			continue
+		}
+		result.Aborted = append(result.Aborted, upload)
+	}
+	return result, nil
+}
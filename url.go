@@ -0,0 +1,128 @@
+package s3
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrURLExpired is returned by VerifyPresignedURL for URLs past their
+// expiry.
+var ErrURLExpired = errors.New("presigned URL has expired")
+
+// PresignedURLInfo describes a URL accepted by VerifyPresignedURL.
+type PresignedURLInfo struct {
+	Key       string
+	SignedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// KeyFromURL extracts the object key from a URL produced for this client's
+// bucket, presigned or not. Both path-style (endpoint/bucket/key) and
+// virtual-hosted-style (bucket.endpoint/key) URLs are accepted; URLs pointing
+// to another host or bucket are rejected.
+func (c *Client) KeyFromURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse URL: %w", err)
+	}
+	if u.Host == "" {
+		return "", errors.New("URL has no host")
+	}
+
+	host := strings.ToLower(u.Hostname())
+	path := strings.TrimPrefix(u.Path, "/")
+
+	var key string
+	switch {
+	case c.isAccessPointHost(host):
+		key = path
+	case c.accessPoint != nil:
+		return "", fmt.Errorf("URL host %q does not match the client access point", host)
+	case c.isServiceHost(host):
+		bucket, rest, _ := strings.Cut(path, "/")
+		if bucket != c.bucket {
+			return "", fmt.Errorf("URL points to bucket %q, expected %q", bucket, c.bucket)
+		}
+		key = rest
+	case strings.HasPrefix(host, c.bucket+".") && c.isServiceHost(strings.TrimPrefix(host, c.bucket+".")):
+		key = path
+	default:
+		return "", fmt.Errorf("URL host %q does not match the client endpoint", host)
+	}
+
+	if key == "" {
+		return "", errors.New("URL does not contain an object key")
+	}
+	return key, nil
+}
+
+// VerifyPresignedURL checks that rawURL is a SigV4 presigned URL for an
+// object in this client's bucket and region, and that it has not expired.
+// The signature itself is left to S3, so this is a sanity check for URLs
+// echoed back by clients, not proof that the URL is genuine.
+func (c *Client) VerifyPresignedURL(rawURL string) (*PresignedURLInfo, error) {
+	key, err := c.KeyFromURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	u, _ := url.Parse(rawURL)
+	query := u.Query()
+	if query.Get("X-Amz-Signature") == "" {
+		return nil, errors.New("URL is not presigned with SigV4")
+	}
+	scope := strings.Split(query.Get("X-Amz-Credential"), "/")
+	switch query.Get("X-Amz-Algorithm") {
+	case "AWS4-HMAC-SHA256":
+		// The credential scope is <key id>/<date>/<region>/s3/aws4_request.
+		if len(scope) != 5 || scope[3] != "s3" {
+			return nil, errors.New("URL has an invalid credential scope")
+		}
+		if c.region != "" && scope[2] != c.region && (c.accessPoint == nil || scope[2] != c.accessPoint.region) {
+			return nil, fmt.Errorf("URL is signed for region %q, expected %q", scope[2], c.region)
+		}
+	case "AWS4-ECDSA-P256-SHA256":
+		// SigV4a, used by Multi-Region Access Points, signs for a region
+		// set rather than a region: <key id>/<date>/s3/aws4_request.
+		if len(scope) != 4 || scope[2] != "s3" {
+			return nil, errors.New("URL has an invalid credential scope")
+		}
+	default:
+		return nil, errors.New("URL is not presigned with SigV4")
+	}
+
+	signedAt, err := time.Parse(amzDateFormat, query.Get("X-Amz-Date"))
+	if err != nil {
+		return nil, fmt.Errorf("URL has an invalid X-Amz-Date: %w", err)
+	}
+	seconds, err := strconv.Atoi(query.Get("X-Amz-Expires"))
+	if err != nil || seconds <= 0 {
+		return nil, errors.New("URL has an invalid X-Amz-Expires")
+	}
+	info := &PresignedURLInfo{
+		Key:       key,
+		SignedAt:  signedAt,
+		ExpiresAt: signedAt.Add(time.Duration(seconds) * time.Second),
+	}
+	if !time.Now().Before(info.ExpiresAt) {
+		return info, ErrURLExpired
+	}
+	return info, nil
+}
+
+// isServiceHost reports whether host is the S3 endpoint this client talks to.
+// Without a custom endpoint any regional AWS S3 host is accepted.
+func (c *Client) isServiceHost(host string) bool {
+	if c.endpoint != "" {
+		endpoint := c.endpoint
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "https://" + endpoint
+		}
+		u, err := url.Parse(endpoint)
+		return err == nil && strings.EqualFold(u.Hostname(), host)
+	}
+	return strings.HasSuffix(host, ".amazonaws.com") && strings.HasPrefix(host, "s3")
+}
@@ -0,0 +1,130 @@
+package s3
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrUploadRejected is returned when an upload fails Config.Validation.
+var ErrUploadRejected = errors.New("upload rejected")
+
+var errUploadAborted = errors.New("upload aborted")
+
+// Validator inspects an upload body, e.g. with a virus scanner. It reads the
+// bytes while they are sent to S3; returning an error aborts the upload
+// before the object is stored. A Validator may stop reading early.
+type Validator func(io.Reader) error
+
+// ValidationConfig checks every upload made through UploadFile and
+// UploadLarge, and through the helpers built on them, before the object is
+// committed.
+type ValidationConfig struct {
+	// MaxSize rejects bodies larger than MaxSize bytes with ErrFileTooLarge.
+	MaxSize int64
+	// AllowedContentTypes lists the accepted media types; "image/*" accepts
+	// a whole type. Empty accepts everything.
+	AllowedContentTypes []string
+	// SanitizeFilenames reduces the key passed to UploadFile to its base
+	// name, so a client-supplied file name cannot escape the objectID
+	// through "../" or absolute paths.
+	SanitizeFilenames bool
+	Validator         Validator
+}
+
+func (v *ValidationConfig) filename(name string) (string, error) {
+	if v == nil || !v.SanitizeFilenames {
+		return name, nil
+	}
+	sanitized := sanitizeFilename(name)
+	if sanitized == "" {
+		return "", fmt.Errorf("%w: file name %q is not usable", ErrUploadRejected, name)
+	}
+	return sanitized, nil
+}
+
+func (v *ValidationConfig) checkContentType(contentType string) error {
+	if v == nil || len(v.AllowedContentTypes) == 0 {
+		return nil
+	}
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, allowed := range v.AllowedContentTypes {
+		allowed = strings.ToLower(allowed)
+		if allowed == mediaType {
+			return nil
+		}
+		if group, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mediaType, group+"/") {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: content type %q is not allowed", ErrUploadRejected, contentType)
+}
+
+// body wraps r with the size limit and starts the Validator on a copy of
+// the stream. It returns nil when there is nothing to check while reading.
+func (v *ValidationConfig) body(r io.Reader) *validatedBody {
+	if v == nil || (v.MaxSize <= 0 && v.Validator == nil) {
+		return nil
+	}
+	b := &validatedBody{r: r}
+	if v.MaxSize > 0 {
+		b.r = &limitedReader{r: r, remaining: v.MaxSize}
+	}
+	if v.Validator != nil {
+		pr, pw := io.Pipe()
+		b.pw = pw
+		b.done = make(chan error, 1)
+		go func() {
+			err := v.Validator(pr)
+			if err != nil {
+				err = fmt.Errorf("%w: %w", ErrUploadRejected, err)
+				pr.CloseWithError(err)
+			} else {
+				// Keep accepting the stream so the upload is not blocked.
+				_, _ = io.Copy(io.Discard, pr)
+			}
+			b.done <- err
+		}()
+	}
+	return b
+}
+
+// validatedBody copies what the upload reads to the Validator and fails the
+// read as soon as the Validator rejects the body.
+type validatedBody struct {
+	r    io.Reader
+	pw   *io.PipeWriter
+	done chan error
+}
+
+func (b *validatedBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if b.pw != nil {
+		if n > 0 {
+			if _, writeErr := b.pw.Write(p[:n]); writeErr != nil {
+				return n, writeErr
+			}
+		}
+		if err == io.EOF {
+			b.pw.Close()
+		}
+	}
+	return n, err
+}
+
+// verdict waits for the Validator once the body has been read to EOF.
+func (b *validatedBody) verdict() error {
+	if b == nil || b.pw == nil {
+		return nil
+	}
+	return <-b.done
+}
+
+// close stops the Validator of an upload that ended early.
+func (b *validatedBody) close() {
+	if b != nil && b.pw != nil {
+		b.pw.CloseWithError(errUploadAborted)
+	}
+}
@@ -0,0 +1,131 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+type WatchEventType string
+
+const (
+	WatchCreated  WatchEventType = "created"
+	WatchModified WatchEventType = "modified"
+	WatchDeleted  WatchEventType = "deleted"
+)
+
+// WatchEvent is a change seen by Watch. For WatchDeleted, Object is the last
+// listing of the object.
+type WatchEvent struct {
+	Type   WatchEventType
+	Object ObjectInfo
+}
+
+type WatchOption func(*watchOptions)
+
+type watchOptions struct {
+	existing bool
+}
+
+// WithWatchExisting emits WatchCreated for the objects already under the
+// prefix when Watch starts, so a drop folder is drained on startup.
+func WithWatchExisting() WatchOption {
+	return func(o *watchOptions) { o.existing = true }
+}
+
+// Watch lists prefix every interval and emits the objects created, modified
+// (new ETag or size) or deleted since the previous listing, for providers
+// without event notifications. The first listing is done before Watch
+// returns and its error is returned; later listing errors are logged and the
+// listing is retried on the next tick. The channel is closed when ctx is
+// done. Changes that revert within one interval are not seen.
+func (c *Client) Watch(ctx context.Context, prefix string, interval time.Duration, opts ...WatchOption) (<-chan WatchEvent, error) {
+	if interval <= 0 {
+		return nil, errors.New("watch interval must be positive")
+	}
+	o := &watchOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	snapshot, err := c.watchSnapshot(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan WatchEvent)
+	go func() {
+		defer close(events)
+		var pending []WatchEvent
+		if o.existing {
+			pending = diffSnapshots(map[string]ObjectInfo{}, snapshot)
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			for _, event := range pending {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+
+			current, err := c.watchSnapshot(ctx, prefix)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				c.logger.ErrorContext(ctx, "failed to list watched prefix",
+					slog.String("op", "Watch"),
+					slog.String("prefix", prefix),
+					slog.Any("error", err),
+				)
+				pending = nil
+				continue
+			}
+			pending = diffSnapshots(snapshot, current)
+			snapshot = current
+		}
+	}()
+	return events, nil
+}
+
+func (c *Client) watchSnapshot(ctx context.Context, prefix string) (map[string]ObjectInfo, error) {
+	snapshot := make(map[string]ObjectInfo)
+	err := c.ListAll(ctx, prefix, func(obj ObjectInfo) error {
+		snapshot[obj.Key] = obj
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// diffSnapshots returns the changes from previous to current, ordered by key.
+func diffSnapshots(previous, current map[string]ObjectInfo) []WatchEvent {
+	var events []WatchEvent
+	for key, obj := range current {
+		before, ok := previous[key]
+		switch {
+		case !ok:
+			events = append(events, WatchEvent{Type: WatchCreated, Object: obj})
+		case before.ETag != obj.ETag || before.Size != obj.Size:
+			events = append(events, WatchEvent{Type: WatchModified, Object: obj})
+		}
+	}
+	for key, obj := range previous {
+		if _, ok := current[key]; !ok {
+			events = append(events, WatchEvent{Type: WatchDeleted, Object: obj})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Object.Key < events[j].Object.Key })
+	return events
+}